@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotPath  string
+	analyzeJSON   bool
+	analyzeFormat string
+	analyzeOutput string
+
+	// outputTemplate is the parsed --format template.
+	outputTemplate *formatter.Template
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Report the unused resources without removing anything",
+	Long: `Runs the same analysis as dockr itself and prints the unused resources it
+would remove. With --snapshot the analysis reads a file written by
+dockr snapshot instead of the daemon.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		client, err := newReadClient(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		report, err := client.Analyze(ctx)
+		if err != nil {
+			return err
+		}
+
+		if analyzeJSON {
+			return writeJSON(report.Resources.Items())
+		}
+		if analyzeOutput != formatter.OutputText {
+			doc := formatter.NewDocument(report.Resources, report.Host, string(report.Engine), report.GeneratedAt, nil)
+			return formatter.WriteDocument(os.Stdout, doc, analyzeOutput)
+		}
+		if outputTemplate != nil {
+			// The resources are embedded so templates can range over .Images.
+			return outputTemplate.Execute(os.Stdout, struct {
+				*dockr.Report
+				*dockr.UnusedResources
+			}{report, report.Resources})
+		}
+
+		formatter.PrintReport(report.Resources, true)
+		return nil
+	},
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Print an overview of the host's disk usage and unused resources",
+	Long: `Prints the engine and its disk usage by resource type, as docker system df
+does, with the space the analysis would reclaim. With --snapshot the report
+reads a file written by dockr snapshot instead of the daemon.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		client, err := newReadClient(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		host, err := client.HostReport(ctx)
+		if err != nil {
+			return err
+		}
+
+		report, err := client.Analyze(ctx)
+		if err != nil {
+			return err
+		}
+
+		if analyzeJSON {
+			return writeJSON(struct {
+				*dockr.HostReport
+				Unused []domain.Resource `json:"unused"`
+			}{host, report.Resources.Items()})
+		}
+		if analyzeOutput != formatter.OutputText {
+			doc := formatter.NewDocument(report.Resources, report.Host, string(report.Engine), report.GeneratedAt, host)
+			return formatter.WriteDocument(os.Stdout, doc, analyzeOutput)
+		}
+		if outputTemplate != nil {
+			return outputTemplate.Execute(os.Stdout, struct {
+				*dockr.HostReport
+				Unused *dockr.UnusedResources
+			}{host, report.Resources})
+		}
+
+		formatter.PrintHostReport(host, report.Resources)
+		return nil
+	},
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <resource>",
+	Short: "Explain why a resource would be removed or kept",
+	Long: `Finds the images, containers, volumes and networks matching the argument by
+ID prefix, name or image tag, and lists what the analysis saw: the containers
+using them, their state, excluded tags, the volume policy and rule decisions.
+With --snapshot it reads a file written by dockr snapshot instead of the daemon.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		client, err := newReadClient(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		explanations, err := client.Explain(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		if analyzeJSON {
+			return writeJSON(explanations)
+		}
+		if outputTemplate != nil {
+			return outputTemplate.Execute(os.Stdout, explanations)
+		}
+
+		formatter.PrintExplanations(explanations)
+		return nil
+	},
+}
+
+// newReadClient creates a client for commands that only read the host: from
+// the --snapshot file when it is given, otherwise from the engine.
+func newReadClient(ctx context.Context, cmd *cobra.Command) (*dockr.Client, error) {
+	switch analyzeOutput {
+	case formatter.OutputText:
+	case formatter.OutputMarkdown, formatter.OutputHTML:
+		if analyzeJSON || analyzeFormat != "" {
+			return nil, errors.New("--output cannot be used together with --json or --format")
+		}
+	default:
+		return nil, fmt.Errorf("unknown --output %q, want text, markdown or html", analyzeOutput)
+	}
+
+	if analyzeFormat != "" {
+		if analyzeJSON {
+			return nil, errors.New("--json and --format cannot be used together")
+		}
+
+		var err error
+		if outputTemplate, err = formatter.ParseTemplate(analyzeFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	opts, err := analysisOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotPath != "" {
+		if opts.Snapshot, err = dockr.LoadSnapshot(snapshotPath); err != nil {
+			return nil, err
+		}
+		if !analyzeJSON && outputTemplate == nil && analyzeOutput == formatter.OutputText {
+			formatter.Info("Reading snapshot of %s captured at %s", opts.Snapshot.Host,
+				opts.Snapshot.CapturedAt.Format("2006-01-02 15:04 MST"))
+		}
+	}
+
+	return dockr.New(ctx, opts)
+}
+
+// analysisOptions returns the options that make the analysis match a
+// cleanup with the same flags and configuration.
+func analysisOptions(cmd *cobra.Command) (dockr.Options, error) {
+	cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+	if err != nil {
+		return dockr.Options{}, err
+	}
+
+	policy, err := volumePolicy(cfg)
+	if err != nil {
+		return dockr.Options{}, err
+	}
+
+	rulePolicy, err := dockr.CompilePolicy(cfg.Policy)
+	if err != nil {
+		return dockr.Options{}, err
+	}
+
+	analyzers, err := dockr.LoadAnalyzers(cfg.Analyzers)
+	if err != nil {
+		return dockr.Options{}, err
+	}
+
+	eng, err := domain.ParseEngine(engine)
+	if err != nil {
+		return dockr.Options{}, err
+	}
+
+	return dockr.Options{Engine: eng, ExcludeTags: excludeTags, Volumes: policy, Analyzers: analyzers, Policy: rulePolicy}, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{analyzeCmd, reportCmd, explainCmd} {
+		c.Flags().StringVar(&snapshotPath, "snapshot", "", "Read the host from a file written by dockr snapshot instead of the daemon")
+		c.Flags().BoolVar(&analyzeJSON, "json", false, "Print the result as JSON")
+		c.Flags().StringVar(&analyzeFormat, "format", "", `Format the result with a Go template; a "table " prefix aligns its columns`)
+		rootCmd.AddCommand(c)
+	}
+
+	for _, c := range []*cobra.Command{analyzeCmd, reportCmd} {
+		c.Flags().StringVar(&analyzeOutput, "output", formatter.OutputText, "Output format: text, markdown or html (a self-contained page)")
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/hooks"
+	"github.com/DobryySoul/dockr/internal/notify"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+// applyPlan removes the planned resources with the configured hooks around the
+// run, records it in the audit log and sends the notifications. With an
+// archive repository configured, the images are pushed there first.
+// It returns a nil run if the run never started: because a before_run hook
+// vetoed it (the error wraps domain.ErrSkipped) or the configuration is invalid.
+// Otherwise the error is the one that stopped the cleanup, archiving included,
+// if any.
+func applyPlan(ctx context.Context, cmd *cobra.Command, client *dockr.Client, cfg *config.Config, plan *dockr.Plan) (*audit.Run, error) {
+	hookRunner, err := hooks.New(cfg.Hooks)
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers, err := notify.New(cfg.Notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := hookRunner.BeforeRun(ctx, plan.Resources); err != nil {
+		return nil, err
+	}
+
+	recorder := audit.NewRecorder(newAuditRun(cmd, client), client.Inspect)
+	observers := []dockr.Observer{recorder, hookRunner}
+
+	// Images that could not be archived are kept, before any hook hears of them.
+	// A run that cannot archive removes nothing but is still recorded,
+	// reported to the after_run hooks and notified like any failed run.
+	archived, cleanErr := archiveImages(ctx, client, cfg, plan.Resources.Images)
+	if cleanErr == nil {
+		if archived != nil {
+			recorder.Run.Archives = archived.Results
+			observers = append([]dockr.Observer{archived}, observers...)
+		}
+		_, cleanErr = client.Clean(ctx, plan, observers...)
+	}
+	run := recorder.Finish(cleanErr)
+
+	if err := audit.Append(auditLog, run); err != nil {
+		formatter.Error("Failed to write audit log: %v", err)
+	}
+
+	if err := hookRunner.AfterRun(ctx, run); err != nil {
+		formatter.Error("%v", err)
+	}
+
+	if err := notify.Send(ctx, notifiers, run); err != nil {
+		formatter.Error("Failed to send notifications: %v", err)
+	}
+
+	return run, cleanErr
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/docker/docker/api/types/image"
+)
+
+var archiveTo string
+
+// archiveImages pushes the images selected by the archive configuration to
+// the archive repository (--archive-to overrides it). It returns nil when
+// archiving is not configured or no image is selected.
+func archiveImages(ctx context.Context, client *dockr.Client, cfg *config.Config, images []*image.Summary) (*dockr.ArchiveResults, error) {
+	archiveCfg := cfg.Archive
+	if archiveTo != "" {
+		archiveCfg.Repository = archiveTo
+	}
+	if !archiveCfg.Enabled() || len(images) == 0 {
+		return nil, nil
+	}
+
+	checker, err := newRegistryChecker(cfg.Registry)
+	if err != nil {
+		return nil, err
+	}
+	// Verification must ask the archive itself.
+	checker.Offline = false
+
+	archiver, err := client.Archiver(archiveCfg, checker)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(archiver.Plan(images)) == 0 {
+		return nil, nil
+	}
+
+	formatter.Info("Archiving images to %s...", archiveCfg.Repository)
+
+	results := archiver.Archive(ctx, images)
+	formatter.PrintArchive(results.Results)
+
+	if n := results.Failed(); n > 0 {
+		formatter.Error("%d tag(s) could not be archived; their images are kept", n)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+)
+
+// planBudget narrows resources down to the least disruptive set that frees
+// the --reclaim target and prints the chosen set with its estimate.
+func planBudget(ctx context.Context, client *dockr.Client, resources *domain.UnusedResources) (*domain.UnusedResources, *dockr.Budget, error) {
+	budget, err := client.Budget(ctx, resources, int64(reclaim))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	formatter.PrintBudget(budget)
+
+	return budget.Resources(resources), budget, nil
+}
+
+// verifyBudget compares the disk space actually freed with the estimate and
+// fails when it falls short of the target.
+func verifyBudget(ctx context.Context, client *dockr.Client, budget *dockr.Budget, before int64) error {
+	after, err := client.Footprint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify reclaimed space: %w", err)
+	}
+
+	freed := before - after
+	if freed >= budget.Target {
+		formatter.Success("Verified: freed %s (target %s, estimate %s)",
+			domain.HumanSize(freed), domain.HumanSize(budget.Target), domain.HumanSize(budget.Estimate))
+		return nil
+	}
+
+	return fmt.Errorf("freed %s, below the %s target (estimate %s)",
+		domain.HumanSize(freed), domain.HumanSize(budget.Target), domain.HumanSize(budget.Estimate))
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/planner"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciGroupBy       []string
+	ciPipelineLabel string
+	ciBranchLabel   string
+	ciOlderThan     time.Duration
+	ciKeepPipelines int
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Remove resources of finished CI pipeline jobs on a shared runner",
+	Long: `Groups containers, networks, volumes and images by the values of the --group-by
+labels (by default the job and pipeline labels GitLab Runner sets) and removes
+whole groups whose newest member is older than --older-than. Groups with
+running containers and every group of the --keep-pipelines most recent
+pipelines per branch are kept. For other CI systems, label the resources
+of a job yourself and pass those label keys.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(ciGroupBy) == 0 {
+			return errors.New("--group-by needs at least one label key")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+		if err != nil {
+			return err
+		}
+
+		client, err := dockr.New(ctx, dockr.Options{Engine: eng, OnEvent: printEvent})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		report, err := client.Labeled(ctx, ciGroupBy)
+		if err != nil {
+			return err
+		}
+
+		groups, err := client.Groups(ctx, report, dockr.GroupPolicy{
+			GroupBy:       ciGroupBy,
+			PipelineLabel: ciPipelineLabel,
+			BranchLabel:   ciBranchLabel,
+			OlderThan:     ciOlderThan,
+			KeepPipelines: ciKeepPipelines,
+		})
+		if err != nil {
+			return err
+		}
+
+		formatter.PrintGroups(groups, dryRun)
+
+		resources := groups.Resources(report.Resources)
+		if dryRun || resources.IsEmpty() {
+			return nil
+		}
+
+		run, err := applyPlan(ctx, cmd, client, cfg, &dockr.Plan{Resources: resources})
+		if run == nil {
+			if errors.Is(err, domain.ErrSkipped) {
+				formatter.Info("Operation cancelled: %v", err)
+				return nil
+			}
+			return err
+		}
+
+		if err != nil {
+			return fmt.Errorf("cleanup error: %w", err)
+		}
+
+		formatter.Success("Cleanup completed! Reclaimed: %.2f MB", float64(run.Reclaimed())/mb)
+		return nil
+	},
+}
+
+func init() {
+	ciCmd.Flags().StringSliceVar(&ciGroupBy, "group-by", []string{planner.GitLabPipelineLabel, planner.GitLabJobLabel}, "Label keys whose values form a group")
+	ciCmd.Flags().StringVar(&ciPipelineLabel, "pipeline-label", planner.GitLabPipelineLabel, "Label key holding the pipeline ID")
+	ciCmd.Flags().StringVar(&ciBranchLabel, "branch-label", planner.GitLabBranchLabel, "Label key holding the branch")
+	ciCmd.Flags().DurationVar(&ciOlderThan, "older-than", 24*time.Hour, "Remove groups whose newest member is older than this")
+	ciCmd.Flags().IntVar(&ciKeepPipelines, "keep-pipelines", 3, "Keep the resources of this many most recent pipelines per branch")
+	rootCmd.AddCommand(ciCmd)
+}
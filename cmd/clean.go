@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove unused resources (same as running dockr without a command)",
+	Long: `Analyzes the host and removes unused Docker resources, exactly like the root
+command. This is the entry point of the 'docker dockr clean' CLI plugin.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCleanup(cmd, interactive && isTerminal())
+	},
+}
+
+func init() {
+	cleanCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Pick resources to remove in a terminal UI (plain confirmation when not on a terminal)")
+	cleanCmd.Flags().BoolVarP(&all, "all", "a", false, "Remove ALL unused resources (including important ones)")
+	cleanCmd.Flags().StringVar(&checkRegistry, "check-registry", "", "Check that tagged images can be pulled again before removing them: off, flag or protect")
+	cleanCmd.Flags().BoolVar(&registryOffline, "registry-offline", false, "Answer the registry check from its cache only")
+	cleanCmd.Flags().BoolVar(&withVolumes, "with-volumes", false, "Remove the anonymous volumes of removed containers with them")
+	rootCmd.AddCommand(cleanCmd)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var diffJSON bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <before.json> [after.json]",
+	Short: "Show what changed on the host between two snapshots",
+	Long: `Compares two files written by dockr snapshot, or a snapshot with the live host
+when only one is given, and lists the images, containers, volumes and networks
+that were added, removed or changed with their size deltas. Containers change
+with their state, images with their tags, volumes and networks with the
+number of containers using them. New resources that the analysis already
+reports as unused are highlighted:
+
+  dockr snapshot > before.json
+  make integration-test
+  dockr diff before.json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		opts, err := analysisOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		before, err := dockr.LoadSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+
+		var after *dockr.Snapshot
+		if len(args) == 2 {
+			if after, err = dockr.LoadSnapshot(args[1]); err != nil {
+				return err
+			}
+		} else {
+			live, err := dockr.New(ctx, opts)
+			if err != nil {
+				return err
+			}
+			after, err = live.Snapshot(ctx)
+			live.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		opts.Snapshot = after
+		client, err := dockr.New(ctx, opts)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		report, err := client.Analyze(ctx)
+		if err != nil {
+			return err
+		}
+
+		diff := dockr.CompareSnapshots(before, after)
+		diff.MarkUnused(report.Resources)
+
+		if diffJSON {
+			return writeJSON(diff)
+		}
+
+		formatter.PrintDiff(diff)
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Print the changes as JSON")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/planner"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dupKeepTag     string
+	dupAcrossRepos bool
+	dupUntag       bool
+	dupCollapse    bool
+)
+
+var duplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "Find redundant tags and images built from identical content",
+	Long: `Groups images by ID to find tags pointing to the same image, and by a digest
+of their layers and configuration to find separate images built from
+identical content.
+
+--untag removes the redundant tags, keeping one canonical tag per repository
+(per image with --across-repos) chosen by --keep-tag; the images themselves
+stay. --collapse moves the tags of identical images to one of them and
+deletes the others. Tags matching --exclude-tags are never removed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keep, err := planner.ParseTagPolicy(dupKeepTag)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+		if err != nil {
+			return err
+		}
+
+		client, err := dockr.New(ctx, dockr.Options{Engine: eng, OnEvent: printEvent})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		dups, err := client.Duplicates(ctx, dockr.DuplicatePolicy{
+			Keep:               keep,
+			AcrossRepositories: dupAcrossRepos,
+			Collapse:           dupCollapse,
+			ExcludeTags:        excludeTags,
+		})
+		if err != nil {
+			return err
+		}
+
+		formatter.PrintDuplicates(dups)
+
+		plan := &dockr.Plan{Resources: &domain.UnusedResources{}}
+		if dupCollapse {
+			plan.Tags, plan.Resources.Images = dups.Collapse()
+		}
+		if dupUntag {
+			plan.Tags = append(plan.Tags, dups.Untag()...)
+		}
+
+		if dryRun || len(plan.Tags) == 0 && plan.Resources.IsEmpty() {
+			return nil
+		}
+
+		run, err := applyPlan(ctx, cmd, client, cfg, plan)
+		if run == nil {
+			if errors.Is(err, domain.ErrSkipped) {
+				formatter.Info("Operation cancelled: %v", err)
+				return nil
+			}
+			return err
+		}
+
+		if err != nil {
+			return fmt.Errorf("cleanup error: %w", err)
+		}
+
+		formatter.Success("Duplicates done! Reclaimed: %.2f MB", float64(run.Reclaimed())/mb)
+		return nil
+	},
+}
+
+func init() {
+	duplicatesCmd.Flags().StringVar(&dupKeepTag, "keep-tag", string(planner.TagPolicyLatest), "Canonical tag to keep: latest, version (highest) or shortest")
+	duplicatesCmd.Flags().BoolVar(&dupAcrossRepos, "across-repos", false, "Keep one tag per image instead of one per repository")
+	duplicatesCmd.Flags().BoolVar(&dupUntag, "untag", false, "Remove redundant tags, keeping the images")
+	duplicatesCmd.Flags().BoolVar(&dupCollapse, "collapse", false, "Move the tags of identical images to one of them and delete the others")
+	rootCmd.AddCommand(duplicatesCmd)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySince    string
+	historyUser     string
+	historyHost     string
+	historyKind     string
+	historyResource string
+	historyFailed   bool
+	historyLimit    int
+	historyJSON     bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past cleanup runs from the audit log",
+	Long: `Lists cleanup runs recorded in the audit log, newest first.
+Use 'dockr history show <run-id>' to see every resource a run removed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := historyFilter()
+		if err != nil {
+			return err
+		}
+
+		runs, err := audit.Load(auditLog)
+		if err != nil {
+			return err
+		}
+
+		var matched []*audit.Run
+		for i := len(runs) - 1; i >= 0; i-- {
+			if filter.Match(runs[i]) {
+				matched = append(matched, runs[i])
+			}
+			if historyLimit > 0 && len(matched) == historyLimit {
+				break
+			}
+		}
+
+		if historyJSON {
+			return writeJSON(matched)
+		}
+
+		if len(matched) == 0 {
+			formatter.Info("No cleanup runs found.")
+			return nil
+		}
+
+		formatter.PrintHistory(matched)
+		return nil
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show the resources removed by a cleanup run",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runs, err := audit.Load(auditLog)
+		if err != nil {
+			return err
+		}
+
+		run, err := audit.Find(runs, args[0])
+		if err != nil {
+			return err
+		}
+
+		if historyJSON {
+			return writeJSON(run)
+		}
+
+		formatter.PrintRun(run)
+		return nil
+	},
+}
+
+func historyFilter() (audit.Filter, error) {
+	filter := audit.Filter{
+		User:     historyUser,
+		Host:     historyHost,
+		Kind:     domain.ResourceKind(historyKind),
+		Resource: historyResource,
+		Failed:   historyFailed,
+	}
+
+	if historySince != "" {
+		since, err := audit.ParseSince(historySince, time.Now())
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+
+	return filter, nil
+}
+
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func init() {
+	historyCmd.PersistentFlags().BoolVar(&historyJSON, "json", false, "Print the runs as JSON")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show runs started after this time (e.g. 24h, 7d, 2024-05-01)")
+	historyCmd.Flags().StringVar(&historyUser, "user", "", "Only show runs by this user")
+	historyCmd.Flags().StringVar(&historyHost, "host", "", "Only show runs on this host or Docker host")
+	historyCmd.Flags().StringVar(&historyKind, "kind", "", "Only show runs that removed this kind of resource (image, container, volume, network)")
+	historyCmd.Flags().StringVar(&historyResource, "resource", "", "Only show runs that removed a resource with this ID prefix or name")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "Only show runs with failures")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Maximum number of runs to show (0 for all)")
+
+	historyCmd.AddCommand(historyShowCmd)
+	rootCmd.AddCommand(historyCmd)
+}
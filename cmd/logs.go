@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/logs"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsThreshold = domain.ByteSize(100 * mb)
+	logsRoot      string
+	logsTruncate  bool
+	logsRotate    bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Report container log sizes and truncate or rotate oversized logs",
+	Long: `Inspects the log path and log driver options of every container and reports
+the size of its log files and their rotation settings. Logs without rotation
+and logs over --threshold are flagged.
+
+--truncate empties the oversized logs; --rotate compresses them next to the
+original first. Both need write access to the engine's data directory, which
+usually means running as root on the engine's host. Use --logs-root when that
+directory is mounted elsewhere, e.g. /host when dockr runs in a container.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if logsTruncate && logsRotate {
+			return errors.New("--truncate and --rotate are mutually exclusive")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		client, err := dockr.New(ctx, dockr.Options{Engine: eng})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		report, err := client.Logs(ctx, logsRoot, int64(logsThreshold))
+		if err != nil {
+			return err
+		}
+
+		formatter.PrintLogs(report)
+
+		if !logsTruncate && !logsRotate {
+			return nil
+		}
+
+		oversized := report.Oversized()
+		if len(oversized) == 0 {
+			formatter.Info("No logs over %s.", logsThreshold)
+			return nil
+		}
+
+		var (
+			freed  int64
+			failed int
+		)
+		for _, e := range oversized {
+			if ok, why := e.Shrinkable(report.Threshold); !ok {
+				formatter.Info("Skipped log of %s: %s", e.Name, why)
+				continue
+			}
+
+			if dryRun {
+				formatter.Info("Would %s log of %s (%s)", logsAction(), e.Name, domain.HumanSize(e.Current))
+				continue
+			}
+
+			if logsRotate {
+				var dst string
+				if dst, err = logs.Rotate(e, time.Now()); err == nil {
+					formatter.Info("Rotated log of %s to %s", e.Name, dst)
+				}
+			} else if err = logs.Truncate(e); err == nil {
+				formatter.Info("Truncated log of %s (%s)", e.Name, domain.HumanSize(e.Current))
+			}
+
+			if err != nil {
+				formatter.Error("%v", err)
+				failed++
+				continue
+			}
+			freed += e.Current
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		if failed > 0 {
+			return errors.New("some logs could not be modified")
+		}
+
+		formatter.Success("Logs done! Freed up to %s", domain.HumanSize(freed))
+		return nil
+	},
+}
+
+func logsAction() string {
+	if logsRotate {
+		return "rotate"
+	}
+	return "truncate"
+}
+
+func init() {
+	logsCmd.Flags().Var(&logsThreshold, "threshold", "Flag logs larger than this (e.g. 500MB)")
+	logsCmd.Flags().StringVar(&logsRoot, "logs-root", "", "Directory the engine host's / is mounted at, prepended to log paths")
+	logsCmd.Flags().BoolVar(&logsTruncate, "truncate", false, "Truncate logs over --threshold")
+	logsCmd.Flags().BoolVar(&logsRotate, "rotate", false, "Compress logs over --threshold next to the original, then truncate them")
+	rootCmd.AddCommand(logsCmd)
+}
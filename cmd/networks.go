@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/ipam"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/docker/docker/api/types/network"
+	"github.com/spf13/cobra"
+)
+
+var (
+	networkPools        []string
+	networkDaemonConfig string
+	networkPrune        bool
+)
+
+var networksCmd = &cobra.Command{
+	Use:   "networks",
+	Short: "Show network subnets, their overlaps and the remaining address pool capacity",
+	Long: `Lists the subnets of all networks with the default address pool each was
+allocated from, overlaps with other networks and with the host's routes, and
+how many subnets every pool has left. Pools are read from --address-pool,
+else from default-address-pools in --daemon-config, else the engine's
+built-in defaults are assumed.
+
+With --prune, the unused networks holding pool address space are removed,
+the ones holding the most first.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+		if err != nil {
+			return err
+		}
+
+		pools, err := addressPools()
+		if err != nil {
+			return err
+		}
+
+		policy, err := volumePolicy(cfg)
+		if err != nil {
+			return err
+		}
+
+		rulePolicy, err := dockr.CompilePolicy(cfg.Policy)
+		if err != nil {
+			return err
+		}
+
+		analyzers, err := dockr.LoadAnalyzers(cfg.Analyzers)
+		if err != nil {
+			return err
+		}
+
+		client, err := dockr.New(ctx, dockr.Options{Engine: eng, ExcludeTags: excludeTags, Volumes: policy, Analyzers: analyzers, Policy: rulePolicy, OnEvent: printEvent})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		report, err := client.Analyze(ctx)
+		if err != nil {
+			return err
+		}
+
+		space, err := client.AddressSpace(ctx, report, pools)
+		if err != nil {
+			return err
+		}
+
+		formatter.PrintAddressSpace(space)
+
+		if !networkPrune {
+			return nil
+		}
+
+		resources := reclaimableNetworks(report.Resources, space)
+		if resources.IsEmpty() {
+			formatter.Info("No unused networks hold address pool space.")
+			return nil
+		}
+
+		formatter.PrintReport(resources, dryRun)
+
+		if dryRun {
+			return nil
+		}
+
+		run, err := applyPlan(ctx, cmd, client, cfg, &dockr.Plan{Resources: resources})
+		if run == nil {
+			if errors.Is(err, domain.ErrSkipped) {
+				formatter.Info("Operation cancelled: %v", err)
+				return nil
+			}
+			return err
+		}
+
+		if err != nil {
+			return fmt.Errorf("cleanup error: %w", err)
+		}
+
+		formatter.Success("Removed %d networks.", run.Removed())
+		return nil
+	},
+}
+
+// addressPools returns the pools given with --address-pool or configured for
+// dockerd. Nil means the engine's defaults.
+func addressPools() ([]dockr.AddressPool, error) {
+	if len(networkPools) == 0 {
+		return ipam.LoadDaemonPools(networkDaemonConfig)
+	}
+
+	pools := make([]dockr.AddressPool, 0, len(networkPools))
+	for _, s := range networkPools {
+		pool, err := ipam.ParsePool(s)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
+
+// reclaimableNetworks returns the unused networks holding pool address space,
+// in the order they should be removed.
+func reclaimableNetworks(resources *domain.UnusedResources, space *dockr.AddressSpace) *domain.UnusedResources {
+	order := space.Reclaimable()
+
+	selected := resources.Select(func(res domain.Resource) bool {
+		return res.Kind == domain.KindNetwork && slices.Contains(order, res.ID)
+	})
+	slices.SortStableFunc(selected.Networks, func(a, b *network.Summary) int {
+		return slices.Index(order, a.ID) - slices.Index(order, b.ID)
+	})
+
+	return selected
+}
+
+func init() {
+	networksCmd.Flags().StringSliceVar(&networkPools, "address-pool", nil, "Default address pool as base/size, e.g. 10.10.0.0/16/24; repeat for several")
+	networksCmd.Flags().StringVar(&networkDaemonConfig, "daemon-config", ipam.DefaultDaemonConfig, "dockerd configuration file to read default-address-pools from")
+	networksCmd.Flags().BoolVar(&networkPrune, "prune", false, "Remove unused networks that hold address pool space")
+	rootCmd.AddCommand(networksCmd)
+}
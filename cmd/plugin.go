@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+var pluginConfigDir string
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage dockr as a Docker CLI plugin",
+}
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install dockr as the 'docker dockr' CLI plugin",
+	Long: `Copies this binary into the Docker CLI plugin directory
+($DOCKER_CONFIG/cli-plugins or ~/.docker/cli-plugins), so that it runs as
+'docker dockr' with the CLI's current context.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the dockr executable: %w", err)
+		}
+
+		path, err := plugin.Install(exe, pluginConfigDir)
+		if err != nil {
+			return err
+		}
+
+		formatter.Success("Installed %s. Try: docker %s clean --dry-run", path, plugin.Name)
+		return nil
+	},
+}
+
+// pluginMetadataCmd answers the Docker CLI's plugin discovery.
+var pluginMetadataCmd = &cobra.Command{
+	Use:    plugin.MetadataSubcommand,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeJSON(plugin.NewMetadata("v" + appVersion))
+	},
+}
+
+// pluginArgs prepares a run as a Docker CLI plugin: it connects to the
+// endpoint of the CLI's global options and current context, and returns the
+// arguments meant for dockr. Commands that do not talk to the engine skip the
+// endpoint, so a broken context does not make the plugin invalid.
+func pluginArgs(args []string) ([]string, error) {
+	inv, err := plugin.ParseInvocation(args)
+	if err != nil {
+		return nil, err
+	}
+	if !inv.NeedsEndpoint() {
+		return inv.Args, nil
+	}
+
+	endpoint, err := plugin.Resolve(inv, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range endpoint.Env() {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return inv.Args, nil
+}
+
+func init() {
+	pluginInstallCmd.Flags().StringVar(&pluginConfigDir, "docker-config", plugin.DefaultConfigDir(os.Getenv), "Docker CLI configuration directory")
+	pluginCmd.AddCommand(pluginInstallCmd)
+	rootCmd.AddCommand(pluginCmd, pluginMetadataCmd)
+}
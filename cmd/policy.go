@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/fixture"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var policyJSON bool
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Work with the policy rules of the configuration file",
+}
+
+var policyTestCmd = &cobra.Command{
+	Use:   "test <fixture.yaml|dir>...",
+	Short: "Check the policy against fixture hosts with expected verdicts",
+	Long: `Loads YAML fixtures describing a host (images, containers, volumes and networks
+with labels and timestamps) and the verdict expected for its resources, runs
+the full analysis with the policy rules of the configuration file against
+each host, and prints every expectation that failed with the reasons behind
+the verdict. Directories are searched for *.yaml and *.yml files. Exits with
+an error when an expectation fails, so the policy can be checked in CI.
+
+  name: keep release images
+  options:
+    volumes: named           # also exclude_tags and engine
+  host:
+    images:
+      - tags: [registry.x.io/app:1]
+        created: 40d          # or a date
+    containers:
+      - name: job-1
+        image: registry.x.io/app:1
+        state: exited
+        finished: 3h
+        labels: {ci.job: "1"}
+        volumes: [cache]
+    volumes:
+      - name: cache
+  expect:
+    - {resource: registry.x.io/app:1, verdict: delete}
+    - {resource: cache, kind: volume, verdict: keep}`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+		if err != nil {
+			return err
+		}
+
+		volumes, err := volumePolicy(cfg)
+		if err != nil {
+			return err
+		}
+
+		rules, err := policy.Compile(cfg.Policy)
+		if err != nil {
+			return err
+		}
+
+		fixtures, err := fixture.Load(args)
+		if err != nil {
+			return err
+		}
+		if len(fixtures) == 0 {
+			return fmt.Errorf("no fixtures found in %v", args)
+		}
+
+		base := fixture.Base{ExcludeTags: excludeTags, Volumes: volumes, Policy: rules}
+
+		var (
+			results []fixture.Result
+			failed  int
+		)
+		for _, f := range fixtures {
+			res, err := fixture.Run(ctx, f, base, time.Now())
+			if err != nil {
+				return err
+			}
+			for _, r := range res {
+				if !r.Passed() {
+					failed++
+				}
+			}
+			results = append(results, res...)
+		}
+
+		if policyJSON {
+			if err := writeJSON(results); err != nil {
+				return err
+			}
+		} else {
+			formatter.PrintPolicyResults(results)
+		}
+
+		if failed > 0 {
+			// A failed expectation is not a usage error.
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d policy expectation(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	policyTestCmd.Flags().BoolVar(&policyJSON, "json", false, "Print the results as JSON")
+	policyCmd.AddCommand(policyTestCmd)
+	rootCmd.AddCommand(policyCmd)
+}
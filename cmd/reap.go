@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/reaper"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reapLabels []string
+	reapWatch  bool
+	reapListen string
+	reapGrace  time.Duration
+)
+
+var reapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "Remove all resources of a test or CI session",
+	Long: `Removes every container, network, volume and image carrying all the
+--session-label labels, whether in use or not: running containers are killed.
+
+With --watch, dockr runs as a watchdog instead. Processes connect to --listen
+and send the labels of their session, one line per session (labels joined by
+'&', Ryuk's "label=key=value" form is accepted). When the last connection of
+a session drops, its resources are removed after --grace. Connections that
+send nothing belong to the --session-label session.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var labels []string
+		if len(reapLabels) > 0 {
+			var err error
+			if labels, err = reaper.ParseFilter(strings.Join(reapLabels, "&")); err != nil {
+				return err
+			}
+		}
+
+		if !reapWatch && len(labels) == 0 {
+			return errors.New("--session-label is required unless --watch is set")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+		if err != nil {
+			return err
+		}
+
+		client, err := dockr.New(ctx, dockr.Options{Engine: eng, OnEvent: printEvent})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if !reapWatch {
+			return reapSession(ctx, cmd, client, cfg, labels)
+		}
+
+		ln, err := reaper.Listen(reapListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", reapListen, err)
+		}
+
+		watchdog := &reaper.Watchdog{
+			Grace:   reapGrace,
+			Default: labels,
+			Reap: func(ctx context.Context, labels []string) error {
+				return reapSession(ctx, cmd, client, cfg, labels)
+			},
+			Logf: formatter.Info,
+		}
+
+		formatter.Info("Watching sessions on %s (grace period %s)", reapListen, reapGrace)
+
+		return watchdog.Serve(ctx, ln)
+	},
+}
+
+// reapSession removes the resources of one session, going through the same
+// hooks, audit log and notifications as a cleanup.
+func reapSession(ctx context.Context, cmd *cobra.Command, client *dockr.Client, cfg *config.Config, labels []string) error {
+	session := strings.Join(labels, "&")
+
+	report, err := client.Session(ctx, labels)
+	if err != nil {
+		return err
+	}
+
+	if report.Resources.IsEmpty() {
+		formatter.Info("No resources found for session %s.", session)
+		return nil
+	}
+
+	formatter.PrintReport(report.Resources, dryRun)
+
+	if dryRun {
+		return nil
+	}
+
+	run, err := applyPlan(ctx, cmd, client, cfg, &dockr.Plan{Resources: report.Resources, Force: true})
+	if run == nil {
+		if errors.Is(err, domain.ErrSkipped) {
+			formatter.Info("Reaping session %s cancelled: %v", session, err)
+			return nil
+		}
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to reap session %s: %w", session, err)
+	}
+
+	formatter.Success("Session %s reaped! Reclaimed: %.2f MB", session, float64(run.Reclaimed())/mb)
+
+	return nil
+}
+
+func init() {
+	reapCmd.Flags().StringSliceVar(&reapLabels, "session-label", nil, "Label (key=value) identifying the session; repeat to require several")
+	reapCmd.Flags().BoolVar(&reapWatch, "watch", false, "Run as a watchdog that reaps sessions whose connections dropped")
+	reapCmd.Flags().StringVar(&reapListen, "listen", "tcp://127.0.0.1:8090", "Watchdog address: tcp://host:port or unix:///path/to.sock")
+	reapCmd.Flags().DurationVar(&reapGrace, "grace", 10*time.Second, "How long a session may stay disconnected before it is reaped")
+	rootCmd.AddCommand(reapCmd)
+}
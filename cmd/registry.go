@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/registry"
+)
+
+var (
+	checkRegistry   string
+	registryOffline bool
+)
+
+// checkRepullable checks that the tagged images about to be removed can be
+// pulled again from their registries. Images that cannot are listed and, in
+// protect mode, dropped from the resources.
+func checkRepullable(ctx context.Context, cfg *config.Config, resources *domain.UnusedResources) (*domain.UnusedResources, error) {
+	mode := checkRegistry
+	if mode == "" {
+		mode = cfg.Registry.Check
+	}
+
+	m, err := registry.ParseMode(mode)
+	if err != nil || m == registry.ModeOff || len(resources.Images) == 0 {
+		return resources, err
+	}
+
+	checker, err := newRegistryChecker(cfg.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := checker.CheckImages(ctx, resources.Images)
+	if err := checker.Cache.Save(); err != nil {
+		formatter.Error("%v", err)
+	}
+
+	formatter.PrintRepullability(checks, m == registry.ModeProtect)
+
+	if m != registry.ModeProtect {
+		return resources, nil
+	}
+
+	return registry.Protect(resources, checks), nil
+}
+
+func newRegistryChecker(cfg config.Registry) (*registry.Checker, error) {
+	dockerConfig := cfg.DockerConfig
+	if dockerConfig == "" {
+		dockerConfig = registry.DefaultConfigPath()
+	}
+
+	creds, err := registry.LoadCredentials(dockerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := cfg.Cache
+	if cachePath == "" {
+		cachePath = registry.DefaultCachePath()
+	}
+
+	ttl := cfg.CacheTTL
+	if ttl == 0 {
+		ttl = registry.DefaultCacheTTL
+	}
+
+	cache, err := registry.LoadCache(cachePath, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &registry.Checker{
+		Registries:  cfg.Registries,
+		Insecure:    cfg.Insecure,
+		Credentials: creds,
+		Cache:       cache,
+		Offline:     registryOffline || cfg.Offline,
+		Client:      &http.Client{Timeout: timeout},
+	}, nil
+}
@@ -2,18 +2,27 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
-	"github.com/DobryySoul/dockr/internal/cleaner"
-	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
 	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/plugin"
+	"github.com/DobryySoul/dockr/internal/tui"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
 	mb         = 1024 * 1024
-	versionApp = "dockr version v1.0.0"
+	appVersion = "1.0.0"
+	versionApp = "dockr version v" + appVersion
 )
 
 var (
@@ -22,6 +31,12 @@ var (
 	excludeTags []string
 	all         bool
 	version     bool
+	engine      string
+	auditLog    string
+	configPath  string
+	reclaim     domain.ByteSize
+	volumes     string
+	withVolumes bool
 )
 
 var rootCmd = &cobra.Command{
@@ -33,52 +48,216 @@ var rootCmd = &cobra.Command{
 - Volumes
 - Networks`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
 		if version {
 			fmt.Println(versionApp)
 		}
 
-		dockerClient, err := docker.NewDockerClient(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to connect to Docker: %w", err)
-		}
+		return runCleanup(cmd, interactive && isTerminal())
+	},
+}
+
+// runCleanup analyzes the host and removes unused resources. With useTUI the
+// resources to delete are picked in the terminal UI instead of the plain report.
+func runCleanup(cmd *cobra.Command, useTUI bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eng, err := domain.ParseEngine(engine)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+	if err != nil {
+		return err
+	}
+
+	policy, err := volumePolicy(cfg)
+	if err != nil {
+		return err
+	}
+
+	rulePolicy, err := dockr.CompilePolicy(cfg.Policy)
+	if err != nil {
+		return err
+	}
+
+	analyzers, err := dockr.LoadAnalyzers(cfg.Analyzers)
+	if err != nil {
+		return err
+	}
+
+	client, err := dockr.New(ctx, dockr.Options{
+		Engine:      eng,
+		ExcludeTags: excludeTags,
+		Volumes:     policy,
+		Analyzers:   analyzers,
+		Policy:      rulePolicy,
+		OnEvent:     printEvent,
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
 
-		resources, err := dockerClient.FindUnusedResourcer(ctx, excludeTags)
+	report, err := client.Analyze(ctx)
+	if err != nil {
+		return err
+	}
+
+	resources := report.Resources
+	if resources.IsEmpty() {
+		formatter.Info("No unused resources found.")
+		return nil
+	}
+
+	if resources, err = checkRepullable(ctx, cfg, resources); err != nil {
+		return err
+	}
+
+	var budget *dockr.Budget
+	if reclaim > 0 {
+		// Plan over what the registry check left, not the whole report.
+		resources, budget, err = planBudget(ctx, client, resources)
 		if err != nil {
-			return fmt.Errorf("analysis error: %w", err)
+			return err
 		}
 
 		if resources.IsEmpty() {
-			formatter.Info("No unused resources found.")
+			formatter.Info("Nothing to reclaim.")
 			return nil
 		}
+	}
 
-		formatter.PrintReport(resources, dryRun)
+	if useTUI {
+		resources, err = tui.Run(resources, func(res domain.Resource) (any, error) {
+			return client.Inspect(ctx, res)
+		})
+		if err != nil {
+			return err
+		}
 
-		if dryRun {
+		if resources == nil {
+			formatter.Info("Operation cancelled")
 			return nil
 		}
+	}
 
-		if interactive && !formatter.Confirm("Proceed with deletion?", resources) {
-			formatter.Info("Operation cancelled")
+	if budget == nil {
+		formatter.PrintReport(resources, dryRun)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if interactive && !useTUI && !formatter.Confirm("Proceed with deletion?", resources) {
+		formatter.Info("Operation cancelled")
+		return nil
+	}
+
+	var footprint int64
+	if budget != nil {
+		if footprint, err = client.Footprint(ctx); err != nil {
+			return err
 		}
+	}
 
-		if err := cleaner.CleanAll(ctx, dockerClient, resources, all); err != nil {
-			return fmt.Errorf("cleanup error: %w", err)
+	plan := &dockr.Plan{Resources: resources, RemoveVolumes: withVolumes || cfg.Volumes.WithContainers}
+
+	run, cleanErr := applyPlan(ctx, cmd, client, cfg, plan)
+	if run == nil {
+		if errors.Is(cleanErr, domain.ErrSkipped) {
+			formatter.Info("Operation cancelled: %v", cleanErr)
+			return nil
 		}
+		return cleanErr
+	}
 
-		formatter.Success("Cleanup completed! Reclaimed: %.2f MB",
-			float64(resources.TotalSize()/mb))
+	if cleanErr != nil {
+		return fmt.Errorf("cleanup error: %w", cleanErr)
+	}
 
-		return nil
-	},
+	formatter.Success("Cleanup completed! Reclaimed: %.2f MB",
+		float64(run.Reclaimed())/mb)
+
+	if budget != nil {
+		return verifyBudget(ctx, client, budget, footprint)
+	}
+
+	return nil
+}
+
+// volumePolicy combines the --volumes scope with the per-class retention of the configuration.
+func volumePolicy(cfg *config.Config) (dockr.VolumePolicy, error) {
+	scope, err := domain.ParseVolumeScope(volumes)
+	if err != nil {
+		return dockr.VolumePolicy{}, err
+	}
+
+	return dockr.VolumePolicy{
+		Scope:              scope,
+		AnonymousRetention: cfg.Volumes.Anonymous.Retention,
+		NamedRetention:     cfg.Volumes.Named.Retention,
+	}, nil
+}
+
+// newAuditRun starts the audit record of a cleanup with the flags and policy in effect.
+func newAuditRun(cmd *cobra.Command, client *dockr.Client) *audit.Run {
+	flags := make(map[string]string)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+
+	policy := audit.Policy{
+		Engine:      string(client.Engine()),
+		ExcludeTags: excludeTags,
+		All:         all,
+		Volumes:     volumes,
+	}
+
+	return audit.NewRun(os.Args[1:], flags, policy, client.Host())
+}
+
+// printEvent prints the outcome of every single removal.
+func printEvent(e dockr.Event) {
+	switch e.Type {
+	case dockr.EventRemoved:
+		if change, ok := e.Resource.Object.(dockr.TagChange); ok {
+			if change.Target != "" {
+				fmt.Printf("Moved tag %s to %s\n", change.Ref, formatter.TruncateID(strings.TrimPrefix(change.Target, "sha256:")))
+			} else {
+				fmt.Printf("Untagged %s\n", change.Ref)
+			}
+			return
+		}
+		fmt.Printf("Deleted %s: %s\n", e.Resource.Kind, e.Resource.ID)
+	case dockr.EventSkipped:
+		fmt.Printf("Skipped %s: %s (%v)\n", e.Resource.Kind, e.Resource.ID, e.Err)
+	}
+}
+
+// isTerminal reports whether both stdin and stdout are attached to a terminal,
+// which the full-screen UI needs.
+func isTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// When started by the Docker CLI as a plugin, the CLI's global options are
+// consumed first and the Docker connection follows its context.
 func Execute() {
+	if plugin.IsPlugin(os.Args[0], os.Getenv) {
+		args, err := pluginArgs(os.Args[1:])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		rootCmd.SetArgs(args)
+		rootCmd.Annotations = map[string]string{cobra.CommandDisplayNameAnnotation: "docker " + plugin.Name}
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -87,8 +266,17 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().BoolVarP(&version, "version", "v", false, "Show version")
-	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Simulate deletion without actually removing resources")
-	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Prompt for confirmation before removing resources")
-	rootCmd.Flags().StringSliceVarP(&excludeTags, "exclude-tags", "e", []string{}, "List of image tags to exclude from deletion")
+	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false, "Simulate deletion without actually removing resources")
+	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Pick resources to remove in a terminal UI (plain confirmation when not on a terminal)")
+	rootCmd.PersistentFlags().Var(&reclaim, "reclaim", "Free this much space (e.g. 20GB) by removing the least disruptive unused resources")
+	rootCmd.PersistentFlags().StringSliceVarP(&excludeTags, "exclude-tags", "e", []string{}, "List of image tags to exclude from deletion")
 	rootCmd.Flags().BoolVarP(&all, "all", "a", false, "Remove ALL unused resources (including important ones)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", config.DefaultPath(), "Path to the configuration file (hooks, notifications)")
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", audit.DefaultPath(), "Path to the append-only audit log of removals")
+	rootCmd.PersistentFlags().StringVar(&volumes, "volumes", string(domain.VolumeScopeAnonymous), "Unused volumes to remove: anonymous, or named to include named volumes")
+	rootCmd.Flags().BoolVar(&withVolumes, "with-volumes", false, "Remove the anonymous volumes of removed containers with them")
+	rootCmd.Flags().StringVar(&checkRegistry, "check-registry", "", "Check that tagged images can be pulled again before removing them: off, flag or protect")
+	rootCmd.Flags().BoolVar(&registryOffline, "registry-offline", false, "Answer the registry check from its cache only")
+	rootCmd.PersistentFlags().StringVar(&archiveTo, "archive-to", "", "Push images to this repository (e.g. registry.example.com/archive) and verify them before removing them")
+	rootCmd.PersistentFlags().StringVar(&engine, "engine", string(domain.EngineAuto), "Container engine to connect to: docker, podman or auto")
 }
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/config"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/internal/server"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+// tokenEnv is read when --token is not given, to keep the token out of the process list.
+const tokenEnv = "DOCKR_API_TOKEN"
+
+var (
+	serveListen   string
+	serveToken    string
+	serveReadOnly bool
+	serveOpenAPI  bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve analysis and cleanup over an HTTP API",
+	Long: `Starts an HTTP API to get the current report, create and apply removal plans,
+stream their progress as server-sent events and read the history.
+Every API request needs the bearer token from --token or $` + tokenEnv + `.
+The OpenAPI document is served at /openapi.json.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serveOpenAPI {
+			return writeJSON(server.Spec())
+		}
+
+		token := serveToken
+		if token == "" {
+			token = os.Getenv(tokenEnv)
+		}
+		if token == "" {
+			return fmt.Errorf("an API token is required: set --token or $%s", tokenEnv)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(configPath, cmd.Flags().Changed("config"))
+		if err != nil {
+			return err
+		}
+
+		policy, err := volumePolicy(cfg)
+		if err != nil {
+			return err
+		}
+
+		rulePolicy, err := dockr.CompilePolicy(cfg.Policy)
+		if err != nil {
+			return err
+		}
+
+		analyzers, err := dockr.LoadAnalyzers(cfg.Analyzers)
+		if err != nil {
+			return err
+		}
+
+		var srv *server.Server
+
+		client, err := dockr.New(ctx, dockr.Options{
+			Engine:      eng,
+			ExcludeTags: excludeTags,
+			Volumes:     policy,
+			Analyzers:   analyzers,
+			Policy:      rulePolicy,
+			OnEvent:     func(e dockr.Event) { srv.Publish(e) },
+		})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		srv = server.New(server.Options{
+			Token:    token,
+			ReadOnly: serveReadOnly,
+			AuditLog: auditLog,
+			Analyze:  client.Analyze,
+			Apply: func(ctx context.Context, plan *dockr.Plan) (*audit.Run, error) {
+				resources, err := checkRepullable(ctx, cfg, plan.Resources)
+				if err != nil {
+					return nil, err
+				}
+
+				// The stored plan is still served by GET /plans/{id}.
+				p := *plan
+				p.Resources = resources
+				p.RemoveVolumes = plan.RemoveVolumes || cfg.Volumes.WithContainers
+
+				return applyPlan(ctx, cmd, client, cfg, &p)
+			},
+		})
+
+		httpServer := &http.Server{
+			Addr:              serveListen,
+			Handler:           srv.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- httpServer.ListenAndServe()
+		}()
+
+		mode := "read-write"
+		if serveReadOnly {
+			mode = "read-only"
+		}
+		formatter.Info("Serving the dockr API on http://%s (%s)", serveListen, mode)
+
+		select {
+		case err := <-errCh:
+			return fmt.Errorf("failed to serve: %w", err)
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to shut down: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Bearer token required by every API request (default $"+tokenEnv+")")
+	serveCmd.Flags().BoolVar(&serveReadOnly, "read-only", false, "Allow reports, plans and history but never apply a plan")
+	serveCmd.Flags().BoolVar(&serveOpenAPI, "openapi", false, "Print the OpenAPI document and exit")
+	rootCmd.AddCommand(serveCmd)
+}
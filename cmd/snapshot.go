@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotOutput       string
+	snapshotRedactEnv    bool
+	snapshotRedactLabels bool
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Capture the state of the host into a file for offline analysis",
+	Long: `Captures the containers, images, volumes and networks of the host with their
+inspect data, the disk usage and the daemon info as JSON, to stdout or
+--output. analyze, report and explain read it back with --snapshot, without
+access to the daemon:
+
+  dockr snapshot --redact-env > host.json
+  dockr analyze --snapshot host.json
+
+--redact-env replaces the values of environment variables, which often hold
+secrets. --redact-labels replaces label values with salted hashes: equal
+values stay equal, so grouping by labels still works.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		eng, err := domain.ParseEngine(engine)
+		if err != nil {
+			return err
+		}
+
+		client, err := dockr.New(ctx, dockr.Options{Engine: eng})
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		s, err := client.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		s.Redact(dockr.Redaction{Env: snapshotRedactEnv, Labels: snapshotRedactLabels})
+
+		var w io.Writer = os.Stdout
+		if snapshotOutput != "" && snapshotOutput != "-" {
+			f, err := os.OpenFile(snapshotOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+			if err != nil {
+				return fmt.Errorf("failed to create snapshot file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := s.Write(w); err != nil {
+			return err
+		}
+
+		if w != os.Stdout {
+			formatter.Success("Snapshot of %s written to %s: %d containers, %d images, %d volumes, %d networks",
+				s.Host, snapshotOutput, len(s.Containers), len(s.Images), len(s.Volumes), len(s.Networks))
+		}
+		return nil
+	},
+}
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "Write the snapshot to this file instead of stdout")
+	snapshotCmd.Flags().BoolVar(&snapshotRedactEnv, "redact-env", false, "Replace the values of environment variables")
+	snapshotCmd.Flags().BoolVar(&snapshotRedactLabels, "redact-labels", false, "Replace label values with salted hashes")
+	rootCmd.AddCommand(snapshotCmd)
+}
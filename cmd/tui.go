@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Pick unused resources to remove in a full-screen terminal UI",
+	Long: `Opens a full-screen list of unused resources grouped by type.
+Toggle items with space, sort with 's', filter with '/', inspect with 'i'
+and press enter to remove only the selected resources.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isTerminal() {
+			return errors.New("tui requires an interactive terminal")
+		}
+
+		return runCleanup(cmd, true)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -1,6 +1,11 @@
 package analyzer
 
-import "github.com/docker/docker/api/types/container"
+import (
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
 
 // IsContainerUnused checks if the container is unused (stopped).
 // Containers with "exited", "created", or "dead" states can be safely removed.
@@ -8,3 +13,38 @@ func IsContainerUnused(c *container.Summary) bool {
 	// Valid states for deletion
 	return c.State == "exited" || c.State == "created" || c.State == "dead"
 }
+
+// infraName is the name Podman gives the infra container of a pod: the first
+// 12 characters of the pod ID followed by "-infra".
+var infraName = regexp.MustCompile(`^/?[0-9a-f]{12}-infra$`)
+
+// IsPodInfraContainer reports whether the container is the infra (pause) container
+// of a Podman pod. Such containers are removed together with their pod.
+// The Docker-compatible API has no IsInfra field, so the pause image the infra
+// container runs decides; the generated name is only used when the image is
+// not reported, so that a container merely named "*-infra" is not protected.
+func IsPodInfraContainer(c *container.Summary) bool {
+	if c.Image != "" {
+		return isPauseImage(c.Image)
+	}
+
+	for _, name := range c.Names {
+		if infraName.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPauseImage reports whether ref is Podman's built-in pause image or a
+// pause repository such as registry.k8s.io/pause.
+func isPauseImage(ref string) bool {
+	repo, _, _ := strings.Cut(ref, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	name := repo[strings.LastIndex(repo, "/")+1:]
+
+	return name == "podman-pause" || name == "pause"
+}
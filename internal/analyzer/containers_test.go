@@ -51,3 +51,75 @@ func TestIsContainerUnused(t *testing.T) {
 		})
 	}
 }
+
+func TestIsPodInfraContainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		cont     *container.Summary
+		expected bool
+	}{
+		{
+			name: "podman pause image",
+			cont: &container.Summary{
+				Names: []string{"/3f2a1b4c5d6e-infra"},
+				Image: "localhost/podman-pause:4.9.3-1700000000",
+			},
+			expected: true,
+		},
+		{
+			name: "kubernetes pause image",
+			cont: &container.Summary{
+				Names: []string{"/web-pod-infra"},
+				Image: "k8s.gcr.io/pause:3.5",
+			},
+			expected: true,
+		},
+		{
+			name: "infra name suffix with a regular image",
+			cont: &container.Summary{
+				Names: []string{"/db-infra"},
+				Image: "postgres:16",
+			},
+			expected: false,
+		},
+		{
+			name: "generated infra name without an image",
+			cont: &container.Summary{
+				Names: []string{"/3f2a1b4c5d6e-infra"},
+			},
+			expected: true,
+		},
+		{
+			name: "custom infra name without an image",
+			cont: &container.Summary{
+				Names: []string{"/web-pod-infra"},
+			},
+			expected: false,
+		},
+		{
+			name: "pause in the registry host",
+			cont: &container.Summary{
+				Names: []string{"/app"},
+				Image: "pause.example.com:5000/app:1",
+			},
+			expected: false,
+		},
+		{
+			name: "regular container",
+			cont: &container.Summary{
+				Names: []string{"/web"},
+				Image: "nginx:latest",
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsPodInfraContainer(tt.cont)
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -1,15 +1,42 @@
 package analyzer
 
-import "github.com/docker/docker/api/types/network"
+import (
+	"slices"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/network"
+)
+
+// defaultNetworks — сети, которые движок создаёт сам и которые нельзя удалять.
+var defaultNetworks = map[domain.Engine][]string{
+	domain.EngineDocker: {"bridge", "host", "none"},
+	// Podman создаёт сеть "podman", а через совместимый API может отдавать и "bridge".
+	domain.EnginePodman: {"podman", "bridge", "host", "none"},
+}
 
 // IsNetworkUnused проверяет, является ли сеть неиспользуемой.
 // В Docker сеть считается неиспользуемой, если к ней не подключен ни один контейнер.
-// Базовые сети Docker (bridge, host, none) обычно не следует удалять.
-func IsNetworkUnused(net *network.Summary) bool {
-	// Игнорируем стандартные сети Docker
-	if net.Name == "bridge" || net.Name == "host" || net.Name == "none" {
+// Базовые сети движка (bridge, host, none, а для Podman ещё и podman) не следует удалять.
+// usedNetworks — ID и имена сетей, на которые ссылаются оставляемые контейнеры,
+// в том числе остановленные: без своей сети такой контейнер не запустится.
+func IsNetworkUnused(net *network.Summary, engine domain.Engine, usedNetworks map[string]bool) bool {
+	// Игнорируем стандартные сети движка
+	if IsDefaultNetwork(net.Name, engine) {
+		return false
+	}
+	// Сеть нужна остановленному контейнеру, который не удаляется
+	if usedNetworks[net.ID] || usedNetworks[net.Name] {
 		return false
 	}
 	// Если к сети не подключено ни одного контейнера (Containers map пустая)
 	return len(net.Containers) == 0
 }
+
+// IsDefaultNetwork проверяет, является ли сеть базовой сетью движка.
+func IsDefaultNetwork(name string, engine domain.Engine) bool {
+	defaults, ok := defaultNetworks[engine]
+	if !ok {
+		defaults = defaultNetworks[domain.EngineDocker]
+	}
+	return slices.Contains(defaults, name)
+}
@@ -3,6 +3,7 @@ package analyzer
 import (
 	"testing"
 
+	"github.com/DobryySoul/dockr/internal/domain"
 	"github.com/docker/docker/api/types/network"
 )
 
@@ -10,6 +11,8 @@ func TestIsNetworkUnused(t *testing.T) {
 	tests := []struct {
 		name     string
 		net      *network.Summary
+		engine   domain.Engine
+		used     map[string]bool
 		expected bool
 	}{
 		{
@@ -51,11 +54,45 @@ func TestIsNetworkUnused(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "network referenced by a kept stopped container",
+			net: &network.Summary{
+				ID:   "net1",
+				Name: "app-net",
+			},
+			used:     map[string]bool{"net1": true},
+			expected: false,
+		},
+		{
+			name: "network referenced by name",
+			net: &network.Summary{
+				ID:   "net2",
+				Name: "legacy-net",
+			},
+			used:     map[string]bool{"legacy-net": true},
+			expected: false,
+		},
+		{
+			name: "podman default network",
+			net: &network.Summary{
+				Name: "podman",
+			},
+			engine:   domain.EnginePodman,
+			expected: false,
+		},
+		{
+			name: "network named podman on docker",
+			net: &network.Summary{
+				Name: "podman",
+			},
+			engine:   domain.EngineDocker,
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := IsNetworkUnused(tt.net)
+			result := IsNetworkUnused(tt.net, tt.engine, tt.used)
 			if result != tt.expected {
 				t.Errorf("expected %v for network %s, got %v", tt.expected, tt.net.Name, result)
 			}
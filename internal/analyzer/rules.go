@@ -0,0 +1,22 @@
+package analyzer
+
+import "github.com/DobryySoul/dockr/internal/domain"
+
+// ApplyRules combines the built-in verdict with custom rules. Rules are
+// evaluated in order and the first one that does not defer wins; if all of
+// them defer, the built-in verdict stands. A resource in use (a running
+// container, an image of a container, a mounted volume, a network still
+// needed) cannot be removed without force, so a delete verdict is ignored
+// for it.
+func ApplyRules(res domain.Resource, unused, inUse bool, rules []domain.Rule) bool {
+	for _, rule := range rules {
+		switch rule(res).Verdict {
+		case domain.VerdictKeep:
+			return false
+		case domain.VerdictDelete:
+			return !inUse
+		}
+	}
+
+	return unused
+}
@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+func TestApplyRules(t *testing.T) {
+	keepProd := func(res domain.Resource) domain.Decision {
+		if strings.HasSuffix(res.Name, ":prod") {
+			return domain.Decision{Verdict: domain.VerdictKeep, Reason: "production tag"}
+		}
+		return domain.Decision{}
+	}
+	deleteCI := func(res domain.Resource) domain.Decision {
+		if res.Labels["ci"] == "true" {
+			return domain.Decision{Verdict: domain.VerdictDelete, Reason: "CI leftover"}
+		}
+		return domain.Decision{}
+	}
+
+	tests := []struct {
+		name     string
+		res      domain.Resource
+		unused   bool
+		inUse    bool
+		rules    []domain.Rule
+		expected bool
+	}{
+		{
+			name:     "no rules keeps built-in verdict",
+			res:      domain.Resource{Name: "app:prod"},
+			unused:   true,
+			expected: true,
+		},
+		{
+			name:     "keep overrides unused",
+			res:      domain.Resource{Name: "app:prod"},
+			unused:   true,
+			rules:    []domain.Rule{keepProd},
+			expected: false,
+		},
+		{
+			name:     "delete overrides the built-in verdict",
+			res:      domain.Resource{Name: "runner", Labels: map[string]string{"ci": "true"}},
+			unused:   false,
+			rules:    []domain.Rule{keepProd, deleteCI},
+			expected: true,
+		},
+		{
+			name:     "delete ignored for resources in use",
+			res:      domain.Resource{Name: "runner", Labels: map[string]string{"ci": "true"}},
+			unused:   false,
+			inUse:    true,
+			rules:    []domain.Rule{keepProd, deleteCI},
+			expected: false,
+		},
+		{
+			name:     "first decisive rule wins",
+			res:      domain.Resource{Name: "app:prod", Labels: map[string]string{"ci": "true"}},
+			unused:   false,
+			rules:    []domain.Rule{keepProd, deleteCI},
+			expected: false,
+		},
+		{
+			name:     "all rules defer",
+			res:      domain.Resource{Name: "app:dev"},
+			unused:   false,
+			rules:    []domain.Rule{keepProd, deleteCI},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyRules(tt.res, tt.unused, tt.inUse, tt.rules); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,9 +1,50 @@
 package analyzer
 
-import "github.com/docker/docker/api/types/volume"
+import (
+	"strings"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// anonymousVolumeLabel is set by Docker 23+ on the volumes it creates for a container.
+const anonymousVolumeLabel = "com.docker.volume.anonymous"
 
 // IsVolumeUnused checks if a volume is unused (orphaned).
 // Volumes that are not attached to any container are considered unused.
 func IsVolumeUnused(vol *volume.Volume, usedVolumes map[string]bool) bool {
 	return !usedVolumes[vol.Name]
 }
+
+// ClassifyVolume tells anonymous volumes from named ones. A volume is anonymous
+// when the engine labeled it so, or when its name is 64 hex characters and it
+// carries no Compose labels (Compose names its volumes after the project).
+func ClassifyVolume(vol *volume.Volume) domain.VolumeClass {
+	if _, ok := vol.Labels[anonymousVolumeLabel]; ok {
+		return domain.VolumeAnonymous
+	}
+
+	for key := range vol.Labels {
+		if strings.HasPrefix(key, "com.docker.compose.") {
+			return domain.VolumeNamed
+		}
+	}
+
+	if isHexID(vol.Name) {
+		return domain.VolumeAnonymous
+	}
+
+	return domain.VolumeNamed
+}
+
+func isHexID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
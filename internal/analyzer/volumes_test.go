@@ -1,8 +1,10 @@
 package analyzer
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/DobryySoul/dockr/internal/domain"
 	"github.com/docker/docker/api/types/volume"
 )
 
@@ -44,3 +46,35 @@ func TestIsVolumeUnused(t *testing.T) {
 		})
 	}
 }
+
+func TestClassifyVolume(t *testing.T) {
+	hexName := strings.Repeat("ab12", 16)
+
+	tests := []struct {
+		name string
+		vol  *volume.Volume
+		want domain.VolumeClass
+	}{
+		{name: "hex name", vol: &volume.Volume{Name: hexName}, want: domain.VolumeAnonymous},
+		{name: "plain name", vol: &volume.Volume{Name: "postgres-data"}, want: domain.VolumeNamed},
+		{name: "uppercase hex", vol: &volume.Volume{Name: strings.ToUpper(hexName)}, want: domain.VolumeNamed},
+		{
+			name: "engine label",
+			vol:  &volume.Volume{Name: "data", Labels: map[string]string{"com.docker.volume.anonymous": ""}},
+			want: domain.VolumeAnonymous,
+		},
+		{
+			name: "compose volume with hex name",
+			vol:  &volume.Volume{Name: hexName, Labels: map[string]string{"com.docker.compose.project": "shop"}},
+			want: domain.VolumeNamed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyVolume(tt.vol); got != tt.want {
+				t.Errorf("ClassifyVolume() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
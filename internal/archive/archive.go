@@ -0,0 +1,306 @@
+// Package archive pushes release images to an archive registry before they
+// are deleted, so they can be pulled back later.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/registry"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+	registrytypes "github.com/docker/docker/api/types/registry"
+)
+
+// Config is the archive section of the configuration file.
+type Config struct {
+	// Repository is the prefix images are pushed under: with
+	// "archive.example.com/releases", ghcr.io/team/app:1.2 is archived as
+	// archive.example.com/releases/team/app:1.2.
+	Repository string `yaml:"repository"`
+	// Tags selects the images to archive by globs over their tags, where *
+	// also matches slashes ("team/app:v*", "*:release-*"); empty archives
+	// every tagged image.
+	Tags []string `yaml:"tags"`
+	// Concurrency limits the parallel pushes; it defaults to 2.
+	Concurrency int `yaml:"concurrency"`
+	// Retries is the number of extra attempts per image; it defaults to 3.
+	Retries int `yaml:"retries"`
+	// Timeout limits a single push and its verification; it defaults to 10m.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Enabled reports whether an archive repository is configured.
+func (c Config) Enabled() bool {
+	return c.Repository != ""
+}
+
+// Pusher pushes an image under another reference and returns the manifest
+// digest the registry reported.
+type Pusher interface {
+	PushImage(ctx context.Context, source, target, registryAuth string) (string, error)
+}
+
+// Resolver returns the digest a tagged reference points to in its registry.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Result is the archival of one tag, as recorded in the audit log.
+type Result struct {
+	ImageID  string `json:"image_id"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Digest   string `json:"digest,omitempty"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Archiver pushes images to the archive repository.
+type Archiver struct {
+	cfg      Config
+	pusher   Pusher
+	resolver Resolver
+	creds    *registry.Credentials
+	match    []*regexp.Regexp
+	// backoff is the delay before the first retry; it doubles after each.
+	backoff time.Duration
+}
+
+// New validates cfg and creates an archiver that pushes with pusher and
+// verifies the pushed digests with the registry checker, whose credentials
+// are also used for the pushes.
+func New(cfg Config, pusher Pusher, checker *registry.Checker) (*Archiver, error) {
+	named, err := reference.ParseNormalizedNamed(cfg.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive repository %q: %w", cfg.Repository, err)
+	}
+	if _, ok := named.(reference.Tagged); ok {
+		return nil, fmt.Errorf("archive repository %q must not have a tag", cfg.Repository)
+	}
+	cfg.Repository = strings.TrimSuffix(cfg.Repository, "/")
+
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 2
+	}
+	if cfg.Retries <= 0 {
+		cfg.Retries = 3
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Minute
+	}
+
+	a := &Archiver{cfg: cfg, pusher: pusher, resolver: checker, creds: checker.Credentials, backoff: 2 * time.Second}
+	for _, pattern := range cfg.Tags {
+		a.match = append(a.match, globRegexp(pattern))
+	}
+
+	return a, nil
+}
+
+// globRegexp turns a tag glob into an anchored regular expression.
+func globRegexp(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// Plan returns the tags of the images that would be archived, with their
+// archive references.
+func (a *Archiver) Plan(images []*image.Summary) []Result {
+	var plan []Result
+	for _, img := range images {
+		for _, tag := range domain.ImageTags(img) {
+			if !a.selects(tag) {
+				continue
+			}
+
+			r := Result{ImageID: img.ID, Source: tag}
+			target, err := a.target(tag)
+			if err != nil {
+				r.Error = err.Error()
+			}
+			r.Target = target
+
+			plan = append(plan, r)
+		}
+	}
+	return plan
+}
+
+func (a *Archiver) selects(tag string) bool {
+	if len(a.match) == 0 {
+		return true
+	}
+
+	familiar := ""
+	if named, err := reference.ParseNormalizedNamed(tag); err == nil {
+		familiar = reference.FamiliarString(named)
+	}
+
+	for _, re := range a.match {
+		if re.MatchString(tag) || re.MatchString(familiar) {
+			return true
+		}
+	}
+	return false
+}
+
+// target maps a tag to its reference in the archive repository.
+func (a *Archiver) target(tag string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(tag)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag %q: %w", tag, err)
+	}
+
+	tagged, ok := reference.TagNameOnly(named).(reference.Tagged)
+	if !ok {
+		return "", fmt.Errorf("invalid tag %q", tag)
+	}
+
+	return a.cfg.Repository + "/" + reference.Path(named) + ":" + tagged.Tag(), nil
+}
+
+// Archive pushes the selected tags of the images in parallel and verifies
+// that the archive registry serves the pushed digests. The results veto the
+// removal of every image with a tag that could not be archived.
+func (a *Archiver) Archive(ctx context.Context, images []*image.Summary) *Results {
+	results := &Results{Results: a.Plan(images), failed: make(map[string]string)}
+
+	sem := make(chan struct{}, a.cfg.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := range results.Results {
+		r := &results.Results[i]
+		if r.Error != "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := a.archive(ctx, r); err != nil {
+				r.Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results.Results {
+		if r.Error != "" {
+			results.failed[r.ImageID] = fmt.Sprintf("%s: %s", r.Source, r.Error)
+		}
+	}
+
+	return results
+}
+
+// archive pushes one tag, retrying with exponential backoff.
+func (a *Archiver) archive(ctx context.Context, r *Result) error {
+	auth, err := a.registryAuth(r.Target)
+	if err != nil {
+		return err
+	}
+
+	delay := a.backoff
+	for {
+		r.Attempts++
+
+		err = a.push(ctx, r, auth)
+		if err == nil || r.Attempts > a.cfg.Retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (a *Archiver) push(ctx context.Context, r *Result, auth string) error {
+	ctx, cancel := context.WithTimeout(ctx, a.cfg.Timeout)
+	defer cancel()
+
+	digest, err := a.pusher.PushImage(ctx, r.Source, r.Target, auth)
+	if err != nil {
+		return err
+	}
+
+	served, err := a.resolver.Resolve(ctx, r.Target)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", r.Target, err)
+	}
+	if served != digest {
+		return fmt.Errorf("archive serves %s for %s, pushed %s", served, r.Target, digest)
+	}
+
+	r.Digest = digest
+	return nil
+}
+
+// registryAuth encodes the login of the archive registry for the engine.
+func (a *Archiver) registryAuth(target string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(target)
+	if err != nil {
+		return "", err
+	}
+
+	host := reference.Domain(named)
+	login, ok := a.creds.Lookup(host)
+	if !ok {
+		return "", nil
+	}
+
+	return registrytypes.EncodeAuthConfig(registrytypes.AuthConfig{
+		Username:      login.Username,
+		Password:      login.Password,
+		ServerAddress: host,
+	})
+}
+
+// Results is the outcome of Archive. It implements cleaner.Observer and
+// keeps images that were not archived.
+type Results struct {
+	Results []Result
+	failed  map[string]string
+}
+
+// Failed returns the number of tags that could not be archived.
+func (r *Results) Failed() int {
+	var n int
+	for _, res := range r.Results {
+		if res.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// BeforeRemove vetoes the removal of images whose archival failed.
+func (r *Results) BeforeRemove(_ context.Context, res domain.Resource) error {
+	if res.Kind != domain.KindImage {
+		return nil
+	}
+
+	if reason, ok := r.failed[res.ID]; ok {
+		return fmt.Errorf("%w: not archived (%s)", domain.ErrSkipped, reason)
+	}
+
+	return nil
+}
+
+// AfterRemove does nothing; the results are complete before any removal.
+func (r *Results) AfterRemove(context.Context, domain.Resource, error) {}
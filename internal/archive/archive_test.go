@@ -0,0 +1,143 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/registry"
+	"github.com/docker/docker/api/types/image"
+)
+
+// fakeArchive plays both the engine pushing images and the archive registry.
+type fakeArchive struct {
+	mu sync.Mutex
+	// failures is the number of pushes of a target that fail before one succeeds.
+	failures map[string]int
+	// served overrides the digest the registry serves for a target.
+	served map[string]string
+	pushed map[string]string
+	pushes map[string]int
+}
+
+func newFakeArchive() *fakeArchive {
+	return &fakeArchive{
+		failures: make(map[string]int),
+		served:   make(map[string]string),
+		pushed:   make(map[string]string),
+		pushes:   make(map[string]int),
+	}
+}
+
+func (f *fakeArchive) PushImage(_ context.Context, source, target, _ string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pushes[target]++
+	if f.pushes[target] <= f.failures[target] {
+		return "", errors.New("connection reset")
+	}
+
+	f.pushed[target] = "sha256:" + strings.ReplaceAll(source, "/", "-")
+	return f.pushed[target], nil
+}
+
+func (f *fakeArchive) Resolve(_ context.Context, ref string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if digest, ok := f.served[ref]; ok {
+		return digest, nil
+	}
+	if digest, ok := f.pushed[ref]; ok {
+		return digest, nil
+	}
+	return "", errors.New("not found")
+}
+
+func newTestArchiver(t *testing.T, cfg Config, fake *fakeArchive) *Archiver {
+	t.Helper()
+
+	a, err := New(cfg, fake, &registry.Checker{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.resolver = fake
+	a.backoff = 0
+	return a
+}
+
+func TestPlan(t *testing.T) {
+	a := newTestArchiver(t, Config{Repository: "archive.local/rel", Tags: []string{"*app:v*", "db:*"}}, newFakeArchive())
+
+	images := []*image.Summary{
+		{ID: "a", RepoTags: []string{"ghcr.io/team/app:v1", "ghcr.io/team/app:latest"}},
+		{ID: "b", RepoTags: []string{"db:16"}},
+		{ID: "c", RepoTags: []string{"<none>:<none>"}},
+	}
+
+	var got []string
+	for _, r := range a.Plan(images) {
+		got = append(got, r.Source+" -> "+r.Target)
+	}
+
+	want := []string{
+		"ghcr.io/team/app:v1 -> archive.local/rel/team/app:v1",
+		"db:16 -> archive.local/rel/library/db:16",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %v, want %v", got, want)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	fake := newFakeArchive()
+	fake.failures["archive.local/rel/team/app:1.0"] = 2
+	fake.failures["archive.local/rel/team/web:1.0"] = 10
+	fake.served["archive.local/rel/team/api:1.0"] = "sha256:other"
+
+	a := newTestArchiver(t, Config{Repository: "archive.local/rel", Retries: 3, Concurrency: 2}, fake)
+
+	images := []*image.Summary{
+		{ID: "app", RepoTags: []string{"team/app:1.0"}},
+		{ID: "web", RepoTags: []string{"team/web:1.0"}},
+		{ID: "api", RepoTags: []string{"team/api:1.0"}},
+	}
+
+	results := a.Archive(context.Background(), images)
+
+	byImage := make(map[string]Result)
+	for _, r := range results.Results {
+		byImage[r.ImageID] = r
+	}
+
+	if r := byImage["app"]; r.Error != "" || r.Attempts != 3 || r.Digest == "" {
+		t.Errorf("app = %+v, want archived on the third attempt", r)
+	}
+	if r := byImage["web"]; r.Error == "" || r.Attempts != 4 {
+		t.Errorf("web = %+v, want failed after 4 attempts", r)
+	}
+	if r := byImage["api"]; !strings.Contains(r.Error, "sha256:other") {
+		t.Errorf("api = %+v, want a digest mismatch", r)
+	}
+	if results.Failed() != 2 {
+		t.Errorf("Failed() = %d, want 2", results.Failed())
+	}
+
+	for id, wantSkip := range map[string]bool{"app": false, "web": true, "api": true} {
+		err := results.BeforeRemove(context.Background(), domain.Resource{Kind: domain.KindImage, ID: id})
+		if errors.Is(err, domain.ErrSkipped) != wantSkip {
+			t.Errorf("BeforeRemove(%s) = %v, want skipped %v", id, err, wantSkip)
+		}
+	}
+}
+
+func TestNewRejectsTaggedRepository(t *testing.T) {
+	if _, err := New(Config{Repository: "archive.local/rel:1"}, newFakeArchive(), &registry.Checker{}); err == nil {
+		t.Error("expected an error for a repository with a tag")
+	}
+}
@@ -0,0 +1,364 @@
+// Package audit keeps an append-only JSON lines log of every cleanup run
+// and the resources it removed.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/archive"
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+const (
+	ResultRemoved = "removed"
+	ResultSkipped = "skipped"
+	ResultFailed  = "failed"
+)
+
+// Entry records the removal of a single resource.
+type Entry struct {
+	Kind     domain.ResourceKind `json:"kind"`
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Size     int64               `json:"size"`
+	Result   string              `json:"result"`
+	Error    string              `json:"error,omitempty"`
+	Time     time.Time           `json:"time"`
+	Metadata json.RawMessage     `json:"metadata,omitempty"`
+}
+
+// Policy captures the selection rules that were in effect for a run.
+type Policy struct {
+	Engine      string   `json:"engine"`
+	ExcludeTags []string `json:"exclude_tags,omitempty"`
+	All         bool     `json:"all"`
+	Volumes     string   `json:"volumes,omitempty"`
+}
+
+// Run is one line of the audit log.
+type Run struct {
+	ID         string            `json:"id"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	User       string            `json:"user"`
+	Host       string            `json:"host"`
+	DockerHost string            `json:"docker_host"`
+	Args       []string          `json:"args"`
+	Flags      map[string]string `json:"flags"`
+	Policy     Policy            `json:"policy"`
+	Entries    []Entry           `json:"entries"`
+	// Archives maps the image tags pushed before removal to their archive references.
+	Archives []archive.Result `json:"archives,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// Removed returns the number of successfully removed resources.
+func (r *Run) Removed() int {
+	var n int
+	for _, e := range r.Entries {
+		if e.Result == ResultRemoved {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of resources that could not be removed.
+func (r *Run) Failed() int {
+	var n int
+	for _, e := range r.Entries {
+		if e.Result == ResultFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// Reclaimed returns the total size of the successfully removed resources in bytes.
+func (r *Run) Reclaimed() int64 {
+	var total int64
+	for _, e := range r.Entries {
+		if e.Result == ResultRemoved {
+			total += e.Size
+		}
+	}
+	return total
+}
+
+// DefaultPath returns the audit log location: $XDG_STATE_HOME/dockr/audit.jsonl,
+// falling back to ~/.local/state/dockr/audit.jsonl.
+func DefaultPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "dockr", "audit.jsonl")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "dockr-audit.jsonl")
+	}
+
+	return filepath.Join(home, ".local", "state", "dockr", "audit.jsonl")
+}
+
+// NewRun starts a run record for the current user and machine.
+func NewRun(args []string, flags map[string]string, policy Policy, dockerHost string) *Run {
+	host, _ := os.Hostname()
+
+	return &Run{
+		ID:         newRunID(),
+		StartedAt:  time.Now().UTC(),
+		User:       currentUser(),
+		Host:       host,
+		DockerHost: dockerHost,
+		Args:       args,
+		Flags:      flags,
+		Policy:     policy,
+	}
+}
+
+func newRunID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Append writes the run as a single line at the end of the log, creating the file if needed.
+func Append(path string, run *Run) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return f.Close()
+}
+
+// Load reads all runs from the log in the order they were written.
+// A missing log is not an error and yields no runs.
+func Load(path string) ([]*Run, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var runs []*Run
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", line, err)
+		}
+		runs = append(runs, &run)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return runs, nil
+}
+
+// Filter selects runs from the history. Zero values match everything.
+type Filter struct {
+	Since    time.Time
+	User     string
+	Host     string
+	Kind     domain.ResourceKind
+	Resource string
+	Failed   bool
+}
+
+// Match reports whether the run satisfies the filter.
+func (f Filter) Match(run *Run) bool {
+	if !f.Since.IsZero() && run.StartedAt.Before(f.Since) {
+		return false
+	}
+	if f.User != "" && run.User != f.User {
+		return false
+	}
+	if f.Host != "" && run.Host != f.Host && run.DockerHost != f.Host {
+		return false
+	}
+	if f.Failed && run.Failed() == 0 && run.Error == "" {
+		return false
+	}
+	if f.Kind == "" && f.Resource == "" {
+		return true
+	}
+
+	for _, e := range run.Entries {
+		if f.Kind != "" && e.Kind != f.Kind {
+			continue
+		}
+		if f.Resource != "" && !strings.HasPrefix(e.ID, f.Resource) &&
+			!strings.HasPrefix(strings.TrimPrefix(e.ID, "sha256:"), f.Resource) &&
+			!strings.Contains(e.Name, f.Resource) {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+// ParseSince parses a --since style value: a duration ("36h", "7d") back
+// from now or a date ("2024-05-01", RFC 3339).
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid since value %q (expected e.g. 24h, 7d or 2024-05-01)", s)
+}
+
+// Find returns the run whose ID starts with prefix.
+func Find(runs []*Run, prefix string) (*Run, error) {
+	var found *Run
+	for _, run := range runs {
+		if strings.HasPrefix(run.ID, prefix) {
+			if found != nil {
+				return nil, fmt.Errorf("run ID prefix %q is ambiguous", prefix)
+			}
+			found = run
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("run %q not found", prefix)
+	}
+
+	return found, nil
+}
+
+// Inspector returns the inspect object stored as metadata of an entry.
+type Inspector func(ctx context.Context, res domain.Resource) (any, error)
+
+// Recorder collects entries for a run. It implements cleaner.Observer: the resource
+// is inspected before removal (afterwards the metadata is gone) and the outcome
+// is recorded after.
+type Recorder struct {
+	Run     *Run
+	inspect Inspector
+
+	mu       sync.Mutex
+	metadata map[string]json.RawMessage
+}
+
+// NewRecorder creates a recorder for run. inspect may be nil to skip metadata.
+func NewRecorder(run *Run, inspect Inspector) *Recorder {
+	return &Recorder{
+		Run:      run,
+		inspect:  inspect,
+		metadata: make(map[string]json.RawMessage),
+	}
+}
+
+// BeforeRemove captures the inspect metadata of the resource.
+func (r *Recorder) BeforeRemove(ctx context.Context, res domain.Resource) error {
+	var obj any = res.Object
+	if r.inspect != nil {
+		if inspected, err := r.inspect(ctx, res); err == nil {
+			obj = inspected
+		}
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	r.metadata[res.Key()] = data
+	r.mu.Unlock()
+
+	return nil
+}
+
+// AfterRemove records the outcome of the removal.
+func (r *Recorder) AfterRemove(_ context.Context, res domain.Resource, err error) {
+	entry := Entry{
+		Kind:   res.Kind,
+		ID:     res.ID,
+		Name:   res.Name,
+		Size:   res.Size,
+		Result: ResultRemoved,
+		Time:   time.Now().UTC(),
+	}
+	switch {
+	case errors.Is(err, domain.ErrSkipped):
+		entry.Result = ResultSkipped
+		entry.Error = err.Error()
+	case err != nil:
+		entry.Result = ResultFailed
+		entry.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	entry.Metadata = r.metadata[res.Key()]
+	r.Run.Entries = append(r.Run.Entries, entry)
+	r.mu.Unlock()
+}
+
+// Finish stamps the end of the run and the overall error, if any.
+func (r *Recorder) Finish(err error) *Run {
+	r.Run.FinishedAt = time.Now().UTC()
+	if err != nil {
+		r.Run.Error = err.Error()
+	}
+	return r.Run
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/image"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.jsonl")
+
+	runs, err := Load(path)
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("expected empty history for a missing log, got %v, %v", runs, err)
+	}
+
+	first := NewRun([]string{"-d"}, map[string]string{"dry-run": "false"}, Policy{Engine: "docker"}, "unix:///var/run/docker.sock")
+	second := NewRun(nil, nil, Policy{Engine: "podman"}, "")
+
+	for _, run := range []*Run{first, second} {
+		if err := Append(path, run); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	runs, err = Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != first.ID || runs[1].Policy.Engine != "podman" {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	run := NewRun(nil, nil, Policy{}, "")
+	inspect := func(_ context.Context, res domain.Resource) (any, error) {
+		return map[string]string{"Id": res.ID}, nil
+	}
+	rec := NewRecorder(run, inspect)
+
+	ctx := context.Background()
+	ok := domain.ImageResource(&image.Summary{ID: "sha256:aaa", RepoTags: []string{"app:1"}, Size: 100})
+	bad := domain.ImageResource(&image.Summary{ID: "sha256:bbb", Size: 50})
+
+	for _, res := range []domain.Resource{ok, bad} {
+		if err := rec.BeforeRemove(ctx, res); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	rec.AfterRemove(ctx, ok, nil)
+	rec.AfterRemove(ctx, bad, errors.New("conflict"))
+	rec.Finish(nil)
+
+	if run.Removed() != 1 || run.Failed() != 1 || run.Reclaimed() != 100 {
+		t.Errorf("unexpected totals: removed=%d failed=%d reclaimed=%d", run.Removed(), run.Failed(), run.Reclaimed())
+	}
+	if string(run.Entries[0].Metadata) != `{"Id":"sha256:aaa"}` {
+		t.Errorf("unexpected metadata: %s", run.Entries[0].Metadata)
+	}
+	if run.Entries[1].Error != "conflict" || run.FinishedAt.IsZero() {
+		t.Errorf("unexpected failed entry: %+v", run.Entries[1])
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	run := &Run{
+		ID:        "20240501T100000-abcd",
+		StartedAt: now.Add(-2 * time.Hour),
+		User:      "alice",
+		Host:      "build-01",
+		Entries: []Entry{
+			{Kind: domain.KindImage, ID: "sha256:0123456789ab", Name: "app:1", Result: ResultRemoved},
+			{Kind: domain.KindVolume, ID: "pgdata", Name: "pgdata", Result: ResultFailed},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"since before run", Filter{Since: now.Add(-3 * time.Hour)}, true},
+		{"since after run", Filter{Since: now.Add(-time.Hour)}, false},
+		{"other user", Filter{User: "bob"}, false},
+		{"host", Filter{Host: "build-01"}, true},
+		{"kind present", Filter{Kind: domain.KindVolume}, true},
+		{"kind absent", Filter{Kind: domain.KindNetwork}, false},
+		{"image ID prefix", Filter{Resource: "0123"}, true},
+		{"resource of other kind", Filter{Kind: domain.KindImage, Resource: "pgdata"}, false},
+		{"failed", Filter{Failed: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(run); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	runs := []*Run{{ID: "20240501T100000-aaaa"}, {ID: "20240501T100000-bbbb"}, {ID: "20240502T090000-cccc"}}
+
+	if run, err := Find(runs, "20240502"); err != nil || run.ID != "20240502T090000-cccc" {
+		t.Errorf("expected unique match, got %v, %v", run, err)
+	}
+	if _, err := Find(runs, "20240501"); err == nil {
+		t.Error("expected ambiguous prefix error")
+	}
+	if _, err := Find(runs, "2023"); err == nil {
+		t.Error("expected not found error")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "36h", want: now.Add(-36 * time.Hour)},
+		{in: "7d", want: now.AddDate(0, 0, -7)},
+		{in: "2024-05-01", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2024-05-01T10:00:00Z", want: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{in: "last week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -2,63 +2,221 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/DobryySoul/dockr/internal/docker"
 	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/planner"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 )
 
-// CleanAll is the main function that triggers the deletion process for all provided unused resources.
-// It sequentially calls the cleanup methods for images, containers, volumes, and networks.
-func CleanAll(ctx context.Context, client *docker.DockerClient, resources *domain.UnusedResources, all bool) error {
-	force := true
+// Observer is notified around the removal of every single resource.
+// BeforeRemove is called while the resource still exists; AfterRemove receives
+// the result of the removal (nil on success).
+type Observer interface {
+	BeforeRemove(ctx context.Context, res domain.Resource) error
+	AfterRemove(ctx context.Context, res domain.Resource, err error)
+}
 
-	if err := CleanImages(ctx, client, resources.Images); err != nil {
-		return err
+// Observers fans a notification out to several observers in order.
+type Observers []Observer
+
+func (o Observers) BeforeRemove(ctx context.Context, res domain.Resource) error {
+	for _, obs := range o {
+		if err := obs.BeforeRemove(ctx, res); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	if err := CleanContainers(ctx, client, resources.Containers); err != nil {
-		return err
+func (o Observers) AfterRemove(ctx context.Context, res domain.Resource, err error) {
+	for _, obs := range o {
+		obs.AfterRemove(ctx, res, err)
 	}
+}
 
-	if err := CleanVolumes(ctx, client, resources.Volumes, force); err != nil {
-		return err
+// remove wraps a single removal call with the observer notifications.
+// Progress is reported only through observers; the cleaner itself prints nothing.
+// If an observer refuses the removal with domain.ErrSkipped, the resource is
+// kept and the error is returned so the caller can move on to the next one.
+func remove(ctx context.Context, obs Observer, res domain.Resource, fn func() error) error {
+	if obs == nil {
+		return fn()
 	}
 
-	if err := CleanNetworks(ctx, client, resources.Networks); err != nil {
+	if err := obs.BeforeRemove(ctx, res); err != nil {
+		if errors.Is(err, domain.ErrSkipped) {
+			obs.AfterRemove(ctx, res, err)
+		}
 		return err
 	}
 
+	err := fn()
+	obs.AfterRemove(ctx, res, err)
+
+	return err
+}
+
+// skip reports a resource the cleaner itself decided to keep.
+func skip(ctx context.Context, obs Observer, res domain.Resource, reason error) {
+	if obs != nil {
+		obs.AfterRemove(ctx, res, reason)
+	}
+}
+
+// Options tune how resources are removed.
+type Options struct {
+	// Force removes resources even while they are in use: running containers
+	// are killed together with their anonymous volumes, and images are
+	// removed with all their tags. It is meant for resources that belong to
+	// a finished session, not for the results of the unused analysis.
+	Force bool
+	// RemoveVolumes removes the anonymous volumes of containers with them.
+	RemoveVolumes bool
+}
+
+// CleanAll is the main function that triggers the deletion process for all provided unused resources.
+// Resource kinds are removed in planner.RemovalOrder. obs may be nil.
+func CleanAll(ctx context.Context, client *docker.DockerClient, resources *domain.UnusedResources, all bool, obs Observer) error {
+	return Clean(ctx, client, resources, Options{}, obs)
+}
+
+// ForceCleanAll removes the resources even while they are in use (see Options.Force).
+func ForceCleanAll(ctx context.Context, client *docker.DockerClient, resources *domain.UnusedResources, obs Observer) error {
+	return Clean(ctx, client, resources, Options{Force: true}, obs)
+}
+
+// Clean removes the resources in planner.RemovalOrder with the given options. obs may be nil.
+func Clean(ctx context.Context, client *docker.DockerClient, resources *domain.UnusedResources, opts Options, obs Observer) error {
+	for _, kind := range planner.RemovalOrder {
+		var err error
+
+		switch kind {
+		case domain.KindContainer:
+			err = cleanContainers(ctx, client, resources.Containers, opts, obs)
+		case domain.KindNetwork:
+			err = CleanNetworks(ctx, client, resources.Networks, obs)
+		case domain.KindVolume:
+			err = CleanVolumes(ctx, client, resources.Volumes, true, obs)
+		case domain.KindImage:
+			err = cleanImages(ctx, client, resources.Images, opts.Force, obs)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// CleanImages removes unused (dangling) images.
-func CleanImages(ctx context.Context, client *docker.DockerClient, images []*image.Summary) error {
+// CleanImages removes unused (dangling) images. It deletes them with all
+// their layers; to drop a redundant tag and keep the image, use ChangeTags.
+func CleanImages(ctx context.Context, client *docker.DockerClient, images []*image.Summary, obs Observer) error {
+	return cleanImages(ctx, client, images, false, obs)
+}
+
+func cleanImages(ctx context.Context, client *docker.DockerClient, images []*image.Summary, force bool, obs Observer) error {
 	for _, img := range images {
-		_, err := client.Cli.ImageRemove(ctx, img.ID, image.RemoveOptions{})
+		err := remove(ctx, obs, domain.ImageResource(img), func() error {
+			_, err := client.Cli.ImageRemove(ctx, img.ID, image.RemoveOptions{Force: force})
+			return err
+		})
+		if errors.Is(err, domain.ErrSkipped) {
+			continue
+		}
 		if err != nil {
 			return fmt.Errorf("failed to remove image with ID: %s, err: %w", img.ID, err)
 		}
-
-		fmt.Printf("Deleted image: %s\n", img.ID)
 	}
 
 	return nil
 }
 
+// ChangeTags untags images or moves tags to other images without deleting
+// any image. An untag is skipped when the reference is the image's last tag,
+// since removing it would delete the image, or when the reference meanwhile
+// points to another image. It returns the IDs of the images whose changes
+// were not all applied, so the caller can keep them.
+func ChangeTags(ctx context.Context, client *docker.DockerClient, changes []planner.TagChange, obs Observer) (map[string]bool, error) {
+	incomplete := make(map[string]bool)
+
+	for _, change := range changes {
+		res := change.Resource()
+
+		var err error
+		if change.Target != "" {
+			err = remove(ctx, obs, res, func() error {
+				return client.Cli.ImageTag(ctx, change.Target, change.Ref)
+			})
+		} else {
+			err = untag(ctx, client, change, obs)
+		}
+
+		if errors.Is(err, domain.ErrSkipped) {
+			incomplete[change.ImageID] = true
+			continue
+		}
+		if err != nil {
+			incomplete[change.ImageID] = true
+			return incomplete, fmt.Errorf("failed to change tag %s of image %s, err: %w", change.Ref, change.ImageID, err)
+		}
+	}
+
+	return incomplete, nil
+}
+
+func untag(ctx context.Context, client *docker.DockerClient, change planner.TagChange, obs Observer) error {
+	res := change.Resource()
+
+	info, err := client.Cli.ImageInspect(ctx, change.Ref)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.ID != change.ImageID:
+		err = fmt.Errorf("%w: %s now points to another image", domain.ErrSkipped, change.Ref)
+	case len(info.RepoTags) < 2:
+		err = fmt.Errorf("%w: %s is the last tag of the image", domain.ErrSkipped, change.Ref)
+	}
+	if err != nil {
+		skip(ctx, obs, res, err)
+		return err
+	}
+
+	return remove(ctx, obs, res, func() error {
+		// Removing one of several references only untags the image.
+		_, err := client.Cli.ImageRemove(ctx, change.Ref, image.RemoveOptions{})
+		return err
+	})
+}
+
 // CleanContainers removes stopped or dead containers.
-func CleanContainers(ctx context.Context, client *docker.DockerClient, containers []*container.Summary) error {
-	for _, cont := range containers {
+func CleanContainers(ctx context.Context, client *docker.DockerClient, containers []*container.Summary, obs Observer) error {
+	return cleanContainers(ctx, client, containers, Options{}, obs)
+}
 
-		if err := client.Cli.ContainerRemove(ctx, cont.ID, container.RemoveOptions{}); err != nil {
+func cleanContainers(ctx context.Context, client *docker.DockerClient, containers []*container.Summary, opts Options, obs Observer) error {
+	for _, cont := range containers {
+		err := remove(ctx, obs, domain.ContainerResource(cont), func() error {
+			return client.Cli.ContainerRemove(ctx, cont.ID, container.RemoveOptions{
+				Force:         opts.Force,
+				RemoveVolumes: opts.Force || opts.RemoveVolumes,
+			})
+		})
+		if errors.Is(err, domain.ErrSkipped) {
+			continue
+		}
+		if err != nil {
 			return fmt.Errorf("failed to remove container with ID: %s, err: %w", cont.ID, err)
 		}
-
-		fmt.Println("Deleted container:", cont.ID)
 	}
 
 	return nil
@@ -66,13 +224,40 @@ func CleanContainers(ctx context.Context, client *docker.DockerClient, container
 
 // CleanNetworks removes unused networks.
 // Ignores system networks and deletes only those not attached to any containers.
-func CleanNetworks(ctx context.Context, client *docker.DockerClient, networks []*network.Summary) error {
+// A network that a remaining container still references, even a stopped one,
+// is skipped: the container would fail to start without it. Containers are
+// removed first, so only the ones left out of the plan count.
+func CleanNetworks(ctx context.Context, client *docker.DockerClient, networks []*network.Summary, obs Observer) error {
+	if len(networks) == 0 {
+		return nil
+	}
+
+	users, err := client.NetworkUsers(ctx)
+	if err != nil {
+		return err
+	}
+
 	for _, net := range networks {
-		if err := client.Cli.NetworkRemove(ctx, net.ID); err != nil {
-			return fmt.Errorf("failed to remove network with ID: %s, err: %w", net.ID, err)
+		res := domain.NetworkResource(net)
+
+		if conts := users.Of(net); len(conts) > 0 {
+			names := make([]string, 0, len(conts))
+			for _, cont := range conts {
+				names = append(names, domain.ContainerResource(cont).Name)
+			}
+			skip(ctx, obs, res, fmt.Errorf("%w: still referenced by container %s", domain.ErrSkipped, strings.Join(names, ", ")))
+			continue
 		}
 
-		fmt.Println("Deleted network:", net.ID)
+		err := remove(ctx, obs, res, func() error {
+			return client.Cli.NetworkRemove(ctx, net.ID)
+		})
+		if errors.Is(err, domain.ErrSkipped) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove network with ID: %s, err: %w", net.ID, err)
+		}
 	}
 
 	return nil
@@ -80,13 +265,17 @@ func CleanNetworks(ctx context.Context, client *docker.DockerClient, networks []
 
 // CleanVolumes removes orphaned (unused) data volumes.
 // force - forcefully removes the volume (might be needed if Docker still thinks it's busy).
-func CleanVolumes(ctx context.Context, client *docker.DockerClient, volumes []*volume.Volume, force bool) error {
+func CleanVolumes(ctx context.Context, client *docker.DockerClient, volumes []*volume.Volume, force bool, obs Observer) error {
 	for _, v := range volumes {
-		if err := client.Cli.VolumeRemove(ctx, v.Name, force); err != nil {
+		err := remove(ctx, obs, domain.VolumeResource(v), func() error {
+			return client.Cli.VolumeRemove(ctx, v.Name, force)
+		})
+		if errors.Is(err, domain.ErrSkipped) {
+			continue
+		}
+		if err != nil {
 			return fmt.Errorf("failed to remove volume with name: %s, err: %w", v.Name, err)
 		}
-
-		fmt.Println("Deleted volume:", v.Name)
 	}
 
 	return nil
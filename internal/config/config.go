@@ -0,0 +1,95 @@
+// Package config loads the optional dockr configuration file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/archive"
+	"github.com/DobryySoul/dockr/internal/extern"
+	"github.com/DobryySoul/dockr/internal/hooks"
+	"github.com/DobryySoul/dockr/internal/notify"
+	"github.com/DobryySoul/dockr/internal/policy"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the dockr configuration file.
+type Config struct {
+	Hooks         []hooks.Config  `yaml:"hooks"`
+	Notifications []notify.Config `yaml:"notifications"`
+	Volumes       Volumes         `yaml:"volumes"`
+	Registry      Registry        `yaml:"registry"`
+	Archive       archive.Config  `yaml:"archive"`
+	Policy        policy.Config   `yaml:"policy"`
+	Analyzers     extern.Config   `yaml:"analyzers"`
+}
+
+// Volumes holds the per-class volume settings.
+type Volumes struct {
+	Anonymous VolumeClass `yaml:"anonymous"`
+	Named     VolumeClass `yaml:"named"`
+	// WithContainers removes the anonymous volumes of removed containers with them.
+	WithContainers bool `yaml:"with_containers"`
+}
+
+// VolumeClass holds the settings of one class of volumes.
+type VolumeClass struct {
+	// Retention keeps unused volumes of the class younger than this.
+	Retention time.Duration `yaml:"retention"`
+}
+
+// Registry holds the settings of the re-pullability check.
+type Registry struct {
+	// Check is off, flag or protect; --check-registry overrides it.
+	Check string `yaml:"check"`
+	// Registries limits the check to these hosts; empty checks all.
+	Registries []string `yaml:"registries"`
+	// Insecure hosts are reached over plain HTTP.
+	Insecure []string `yaml:"insecure"`
+	// DockerConfig is the Docker client configuration holding the logins.
+	DockerConfig string `yaml:"docker_config"`
+	// Cache is the file answers are kept in between runs.
+	Cache    string        `yaml:"cache"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Offline answers from the cache only.
+	Offline bool          `yaml:"offline"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// DefaultPath returns dockr/config.yaml in the user's config directory
+// ($XDG_CONFIG_HOME or ~/.config on Linux).
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "dockr.yaml"
+	}
+
+	return filepath.Join(dir, "dockr", "config.yaml")
+}
+
+// Load reads the configuration file at path. A missing file is only an error
+// when the path was given explicitly; otherwise an empty configuration is returned.
+func Load(path string, explicit bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
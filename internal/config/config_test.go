@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/hooks"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+hooks:
+  - name: owner
+    events: [before_remove]
+    kinds: [volume]
+    command: ["/bin/notify-owner"]
+    timeout: 2m
+notifications:
+  - type: slack
+    url: https://hooks.slack.com/services/T/B/X
+    when:
+      min_reclaimed: 5GB
+      on_error: true
+volumes:
+  anonymous:
+    retention: 24h
+  named:
+    retention: 720h
+  with_containers: true
+registry:
+  check: protect
+  registries: [localhost:5000]
+  cache_ttl: 12h
+archive:
+  repository: archive.example.com/releases
+  tags: ["team/app:v*"]
+  concurrency: 4
+policy:
+  rules:
+    - name: old-ci-images
+      kind: image
+      expr: 'age > days(30) && any(tags, # startsWith "ci/") ? delete : default'
+analyzers:
+  dir: /etc/dockr/analyzers
+  timeout: 10s
+  batch_size: 50
+  on_failure: ignore
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cfg.Hooks) != 1 || cfg.Hooks[0].Timeout != 2*time.Minute || cfg.Hooks[0].Events[0] != hooks.BeforeRemove {
+		t.Errorf("unexpected hooks: %+v", cfg.Hooks)
+	}
+	if len(cfg.Notifications) != 1 || cfg.Notifications[0].When.MinReclaimed != 5*1024*1024*1024 {
+		t.Errorf("unexpected notifications: %+v", cfg.Notifications)
+	}
+	if cfg.Volumes.Anonymous.Retention != 24*time.Hour || cfg.Volumes.Named.Retention != 720*time.Hour || !cfg.Volumes.WithContainers {
+		t.Errorf("unexpected volumes: %+v", cfg.Volumes)
+	}
+	if cfg.Registry.Check != "protect" || cfg.Registry.CacheTTL != 12*time.Hour || len(cfg.Registry.Registries) != 1 {
+		t.Errorf("unexpected registry: %+v", cfg.Registry)
+	}
+	if !cfg.Archive.Enabled() || cfg.Archive.Concurrency != 4 || len(cfg.Archive.Tags) != 1 {
+		t.Errorf("unexpected archive: %+v", cfg.Archive)
+	}
+	if len(cfg.Policy.Rules) != 1 || cfg.Policy.Rules[0].Kind != "image" || cfg.Policy.Rules[0].Expr == "" {
+		t.Errorf("unexpected policy: %+v", cfg.Policy)
+	}
+	if cfg.Analyzers.Dir != "/etc/dockr/analyzers" || cfg.Analyzers.Timeout != 10*time.Second || cfg.Analyzers.BatchSize != 50 || cfg.Analyzers.OnFailure != "ignore" {
+		t.Errorf("unexpected analyzers: %+v", cfg.Analyzers)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+
+	if cfg, err := Load(path, false); err != nil || cfg == nil {
+		t.Errorf("expected empty config for a missing default file, got %v, %v", cfg, err)
+	}
+	if _, err := Load(path, true); err == nil {
+		t.Error("expected error for a missing explicit file")
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("hookz: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path, true); err == nil {
+		t.Error("expected error for an unknown field")
+	}
+}
@@ -3,6 +3,8 @@ package docker
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/DobryySoul/dockr/internal/analyzer"
 	"github.com/DobryySoul/dockr/internal/domain"
@@ -14,39 +16,60 @@ import (
 )
 
 type DockerClient struct {
-	Cli *client.Client
+	Cli API
+	// Engine is the detected engine behind the API (Docker or Podman).
+	Engine domain.Engine
 }
 
 // NewDockerClient creates a new client to interact with the Docker API.
-// The client automatically negotiates the API version for compatibility with the host.
-func NewDockerClient(ctx context.Context) (*DockerClient, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+// The socket is discovered according to engine (see DiscoverHost), and in auto mode
+// the engine is detected from the server version whatever socket was found. The client automatically
+// negotiates the API version for compatibility with the host.
+func NewDockerClient(ctx context.Context, engine domain.Engine) (*DockerClient, error) {
+	host, _, err := DiscoverHost(engine, os.Getenv, isSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
-	return &DockerClient{Cli: cli}, nil
+	engine, err = resolveEngine(ctx, cli, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DockerClient{Cli: cli, Engine: engine}, nil
 }
 
 // FindUnusedResourcer collects all unused Docker resources (images, containers, volumes, networks)
-// that can be safely removed. Returns a domain.UnusedResources structure.
-func (c *DockerClient) FindUnusedResourcer(ctx context.Context, excludeTags []string) (*domain.UnusedResources, error) {
-	images, err := c.FindUnusedImages(ctx, excludeTags)
+// that can be safely removed. Volumes are limited by the volume policy. Custom rules may
+// override the built-in verdict for any resource not in use (see analyzer.ApplyRules).
+// Returns a domain.UnusedResources structure.
+func (c *DockerClient) FindUnusedResourcer(ctx context.Context, excludeTags []string, volumes domain.VolumePolicy, rules []domain.Rule) (*domain.UnusedResources, error) {
+	images, err := c.FindUnusedImages(ctx, excludeTags, rules)
 	if err != nil {
 		return nil, err
 	}
 
-	containers, err := c.FindUnusedContainers(ctx)
+	containers, err := c.FindUnusedContainers(ctx, rules)
 	if err != nil {
 		return nil, err
 	}
 
-	volumes, err := c.FindUnusedVolumes(ctx, false)
+	unusedVolumes, err := c.FindUnusedVolumes(ctx, false, volumes, rules)
 	if err != nil {
 		return nil, err
 	}
 
-	networks, err := c.FindUnusedNetworks(ctx)
+	networks, err := c.FindUnusedNetworks(ctx, containers, rules)
 	if err != nil {
 		return nil, err
 	}
@@ -54,16 +77,26 @@ func (c *DockerClient) FindUnusedResourcer(ctx context.Context, excludeTags []st
 	resources := &domain.UnusedResources{
 		Images:     images,
 		Containers: containers,
-		Volumes:    volumes,
+		Volumes:    unusedVolumes,
 		Networks:   networks,
 	}
 
 	return resources, nil
 }
 
+// Candidates returns the resources a rule can decide about: everything not in
+// use, whatever the built-in verdict (see analyzer.ApplyRules).
+func (c *DockerClient) Candidates(ctx context.Context, volumes domain.VolumePolicy) (*domain.UnusedResources, error) {
+	deleteAll := func(domain.Resource) domain.Decision {
+		return domain.Decision{Verdict: domain.VerdictDelete}
+	}
+
+	return c.FindUnusedResourcer(ctx, nil, volumes, []domain.Rule{deleteAll})
+}
+
 // FindUnusedImages finds unused (dangling) images. An image is considered unused
 // if no container is attached to it and its tag is not in the excludeTags list.
-func (c *DockerClient) FindUnusedImages(ctx context.Context, excludeTags []string) ([]*image.Summary, error) {
+func (c *DockerClient) FindUnusedImages(ctx context.Context, excludeTags []string, rules []domain.Rule) ([]*image.Summary, error) {
 	containers, err := c.Cli.ContainerList(context.Background(), container.ListOptions{All: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
@@ -82,7 +115,8 @@ func (c *DockerClient) FindUnusedImages(ctx context.Context, excludeTags []strin
 	var unusedImages []*image.Summary
 
 	for _, img := range images {
-		if analyzer.IsImageUnused(img, excludeTags, usedImages) {
+		unused := analyzer.IsImageUnused(img, excludeTags, usedImages)
+		if analyzer.ApplyRules(domain.ImageResource(&img), unused, usedImages[img.ID], rules) {
 			unusedImages = append(unusedImages, &img)
 		}
 	}
@@ -92,7 +126,7 @@ func (c *DockerClient) FindUnusedImages(ctx context.Context, excludeTags []strin
 
 // FindUnusedContainers finds stopped, created, or "dead" containers
 // that are no longer performing any work and are just consuming disk space.
-func (c *DockerClient) FindUnusedContainers(ctx context.Context) ([]*container.Summary, error) {
+func (c *DockerClient) FindUnusedContainers(ctx context.Context, rules []domain.Rule) ([]*container.Summary, error) {
 	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true, Size: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
@@ -101,35 +135,105 @@ func (c *DockerClient) FindUnusedContainers(ctx context.Context) ([]*container.S
 	var unusedContainers []*container.Summary
 	for _, cont := range containers {
 		contCopy := cont
-		if analyzer.IsContainerUnused(&contCopy) {
-			unusedContainers = append(unusedContainers, &contCopy)
+
+		// Pod infra containers are owned by their pod and cannot be removed on their own.
+		if c.Engine == domain.EnginePodman && analyzer.IsPodInfraContainer(&contCopy) {
+			continue
+		}
+
+		unused := analyzer.IsContainerUnused(&contCopy)
+		if !analyzer.ApplyRules(domain.ContainerResource(&contCopy), unused, !unused, rules) {
+			continue
+		}
+
+		if c.Engine == domain.EnginePodman {
+			// Podman's compat API does not always fill SizeRw in the list response.
+			if contCopy.SizeRw == 0 {
+				if err := c.fillContainerSize(ctx, &contCopy); err != nil {
+					return nil, err
+				}
+			}
 		}
+
+		unusedContainers = append(unusedContainers, &contCopy)
 	}
 
 	return unusedContainers, nil
 }
 
-// FindUnusedNetworks finds unused networks.
-func (c *DockerClient) FindUnusedNetworks(ctx context.Context) ([]*network.Summary, error) {
+// fillContainerSize inspects the container with size calculation enabled
+// and copies the writable layer size into the summary.
+func (c *DockerClient) fillContainerSize(ctx context.Context, cont *container.Summary) error {
+	info, _, err := c.Cli.ContainerInspectWithRaw(ctx, cont.ID, true)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container with ID: %s, err: %w", cont.ID, err)
+	}
+
+	if info.SizeRw != nil {
+		cont.SizeRw = *info.SizeRw
+	}
+
+	return nil
+}
+
+// FindUnusedNetworks finds unused networks. A network stays while any container
+// that is not in removed still references it, even a stopped one: the list API
+// does not report stopped containers as attached, but they cannot start without
+// their networks. Unused networks get the removed containers referencing them
+// filled in as their Containers, so the report shows what goes with them.
+func (c *DockerClient) FindUnusedNetworks(ctx context.Context, removed []*container.Summary, rules []domain.Rule) ([]*network.Summary, error) {
+	users, err := c.NetworkUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	networks, err := c.Cli.NetworkList(ctx, network.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
 	}
 
+	isRemoved := make(map[string]bool, len(removed))
+	for _, cont := range removed {
+		isRemoved[cont.ID] = true
+	}
+
+	usedNetworks := make(map[string]bool)
+	for key, conts := range users {
+		for _, cont := range conts {
+			if !isRemoved[cont.ID] {
+				usedNetworks[key] = true
+				break
+			}
+		}
+	}
+
 	var unusedNetworks []*network.Summary
 	for _, net := range networks {
 		netCopy := net
-		if analyzer.IsNetworkUnused(&netCopy) {
-			unusedNetworks = append(unusedNetworks, &netCopy)
+		unused := analyzer.IsNetworkUnused(&netCopy, c.Engine, usedNetworks)
+		if !analyzer.ApplyRules(domain.NetworkResource(&netCopy), unused, !unused, rules) {
+			continue
 		}
+
+		if len(netCopy.Containers) == 0 {
+			for _, cont := range users.Of(&netCopy) {
+				if netCopy.Containers == nil {
+					netCopy.Containers = make(map[string]network.EndpointResource)
+				}
+				netCopy.Containers[cont.ID] = network.EndpointResource{Name: domain.ContainerResource(cont).Name}
+			}
+		}
+
+		unusedNetworks = append(unusedNetworks, &netCopy)
 	}
 
 	return unusedNetworks, nil
 }
 
 // FindUnusedVolumes finds "orphaned" (dangling) volumes.
-// A volume is considered unused if it is not mounted to any existing containers.
-func (c *DockerClient) FindUnusedVolumes(ctx context.Context, force bool) ([]*volume.Volume, error) {
+// A volume is considered unused if it is not mounted to any existing containers
+// and the policy allows removing volumes of its class at its age.
+func (c *DockerClient) FindUnusedVolumes(ctx context.Context, force bool, policy domain.VolumePolicy, rules []domain.Rule) ([]*volume.Volume, error) {
 	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
@@ -149,13 +253,45 @@ func (c *DockerClient) FindUnusedVolumes(ctx context.Context, force bool) ([]*vo
 		return nil, fmt.Errorf("failed to list Docker volumes: %w", err)
 	}
 
+	now := time.Now()
+
 	var unusedVolumes []*volume.Volume
 	for _, v := range volumesList.Volumes {
 		vCopy := v
-		if analyzer.IsVolumeUnused(vCopy, usedVolumes) {
+		res := domain.VolumeResource(vCopy)
+		unused := analyzer.IsVolumeUnused(vCopy, usedVolumes) &&
+			policy.Allows(analyzer.ClassifyVolume(vCopy), res.Created, now)
+		if analyzer.ApplyRules(res, unused, usedVolumes[vCopy.Name], rules) {
 			unusedVolumes = append(unusedVolumes, vCopy)
 		}
 	}
 
 	return unusedVolumes, nil
 }
+
+// Inspect returns the full inspect object of the resource as reported by the daemon.
+func (c *DockerClient) Inspect(ctx context.Context, res domain.Resource) (any, error) {
+	var (
+		obj any
+		err error
+	)
+
+	switch res.Kind {
+	case domain.KindImage, domain.KindTag:
+		obj, err = c.Cli.ImageInspect(ctx, res.ID)
+	case domain.KindContainer:
+		obj, err = c.Cli.ContainerInspect(ctx, res.ID)
+	case domain.KindVolume:
+		obj, err = c.Cli.VolumeInspect(ctx, res.ID)
+	case domain.KindNetwork:
+		obj, err = c.Cli.NetworkInspect(ctx, res.ID, network.InspectOptions{})
+	default:
+		return nil, fmt.Errorf("unknown resource kind: %s", res.Kind)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s %s: %w", res.Kind, res.ID, err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types"
+)
+
+// ErrNoSocket is returned when --engine=podman is requested but no Podman API socket could be found.
+var ErrNoSocket = errors.New("no Podman socket found (enable podman.socket or set CONTAINER_HOST)")
+
+type socketCandidate struct {
+	path   string
+	engine domain.Engine
+}
+
+// socketCandidates lists the well-known API sockets for the requested engine
+// in the order they should be probed: rootful Docker first, then rootless
+// Docker, then the Podman user and system sockets.
+func socketCandidates(engine domain.Engine, getenv func(string) string) []socketCandidate {
+	var candidates []socketCandidate
+
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+
+	if engine == domain.EngineAuto || engine == domain.EngineDocker {
+		candidates = append(candidates, socketCandidate{"/var/run/docker.sock", domain.EngineDocker})
+		if runtimeDir != "" {
+			candidates = append(candidates, socketCandidate{filepath.Join(runtimeDir, "docker.sock"), domain.EngineDocker})
+		}
+		if home := getenv("HOME"); home != "" {
+			// Docker Desktop on macOS and Linux keeps its socket in the home directory.
+			candidates = append(candidates, socketCandidate{filepath.Join(home, ".docker", "run", "docker.sock"), domain.EngineDocker})
+		}
+	}
+
+	if engine == domain.EngineAuto || engine == domain.EnginePodman {
+		if runtimeDir != "" {
+			candidates = append(candidates, socketCandidate{filepath.Join(runtimeDir, "podman", "podman.sock"), domain.EnginePodman})
+		}
+		candidates = append(candidates, socketCandidate{"/run/podman/podman.sock", domain.EnginePodman})
+	}
+
+	return candidates
+}
+
+// DiscoverHost resolves the API endpoint for the requested engine.
+// An explicit DOCKER_HOST (or CONTAINER_HOST for Podman) always wins; otherwise
+// the first existing socket from socketCandidates is used. The returned host is
+// empty when the endpoint should be taken from the environment or the client's
+// platform default. The returned engine is the one the socket is named after,
+// which is only a guess in auto mode (see resolveEngine).
+func DiscoverHost(engine domain.Engine, getenv func(string) string, isSocket func(string) bool) (string, domain.Engine, error) {
+	if getenv("DOCKER_HOST") != "" {
+		return "", engine, nil
+	}
+
+	if engine == domain.EnginePodman {
+		if host := getenv("CONTAINER_HOST"); strings.HasPrefix(host, "unix://") {
+			return host, domain.EnginePodman, nil
+		}
+	}
+
+	for _, c := range socketCandidates(engine, getenv) {
+		if isSocket(c.path) {
+			return "unix://" + c.path, c.engine, nil
+		}
+	}
+
+	if engine == domain.EnginePodman {
+		return "", engine, ErrNoSocket
+	}
+
+	// Fall back to the client's platform default (e.g. the named pipe on Windows).
+	return "", engine, nil
+}
+
+// resolveEngine returns the engine behind the API. An explicitly requested
+// engine is trusted; in auto mode the version endpoint decides, as the socket
+// path says little (podman-docker serves Podman on /var/run/docker.sock).
+func resolveEngine(ctx context.Context, cli interface {
+	ServerVersion(ctx context.Context) (types.Version, error)
+}, requested domain.Engine) (domain.Engine, error) {
+	if requested != domain.EngineAuto {
+		return requested, nil
+	}
+
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to query engine version: %w", err)
+	}
+
+	return detectEngine(version), nil
+}
+
+// detectEngine tells Podman's Docker-compatible API apart from the Docker Engine
+// by looking at the components reported by the version endpoint.
+func detectEngine(v types.Version) domain.Engine {
+	for _, c := range v.Components {
+		if strings.Contains(strings.ToLower(c.Name), "podman") {
+			return domain.EnginePodman
+		}
+	}
+
+	return domain.EngineDocker
+}
+
+func isSocket(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeSocket != 0
+}
@@ -0,0 +1,150 @@
+package docker
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types"
+)
+
+func TestDiscoverHost(t *testing.T) {
+	env := map[string]string{
+		"XDG_RUNTIME_DIR": "/run/user/1000",
+		"HOME":            "/home/dev",
+	}
+
+	tests := []struct {
+		name         string
+		engine       domain.Engine
+		env          map[string]string
+		sockets      []string
+		expectedHost string
+		expectedEng  domain.Engine
+		expectedErr  error
+	}{
+		{
+			name:         "DOCKER_HOST wins",
+			engine:       domain.EngineAuto,
+			env:          map[string]string{"DOCKER_HOST": "tcp://10.0.0.1:2375"},
+			sockets:      []string{"/var/run/docker.sock"},
+			expectedHost: "",
+			expectedEng:  domain.EngineAuto,
+		},
+		{
+			name:         "rootful docker",
+			engine:       domain.EngineAuto,
+			env:          env,
+			sockets:      []string{"/var/run/docker.sock", "/run/user/1000/podman/podman.sock"},
+			expectedHost: "unix:///var/run/docker.sock",
+			expectedEng:  domain.EngineDocker,
+		},
+		{
+			name:         "rootless docker",
+			engine:       domain.EngineAuto,
+			env:          env,
+			sockets:      []string{"/run/user/1000/docker.sock"},
+			expectedHost: "unix:///run/user/1000/docker.sock",
+			expectedEng:  domain.EngineDocker,
+		},
+		{
+			name:         "podman user socket",
+			engine:       domain.EngineAuto,
+			env:          env,
+			sockets:      []string{"/run/user/1000/podman/podman.sock", "/run/podman/podman.sock"},
+			expectedHost: "unix:///run/user/1000/podman/podman.sock",
+			expectedEng:  domain.EnginePodman,
+		},
+		{
+			name:         "podman requested skips docker socket",
+			engine:       domain.EnginePodman,
+			env:          env,
+			sockets:      []string{"/var/run/docker.sock", "/run/podman/podman.sock"},
+			expectedHost: "unix:///run/podman/podman.sock",
+			expectedEng:  domain.EnginePodman,
+		},
+		{
+			name:         "podman CONTAINER_HOST",
+			engine:       domain.EnginePodman,
+			env:          map[string]string{"CONTAINER_HOST": "unix:///tmp/podman.sock"},
+			expectedHost: "unix:///tmp/podman.sock",
+			expectedEng:  domain.EnginePodman,
+		},
+		{
+			name:        "podman requested but missing",
+			engine:      domain.EnginePodman,
+			env:         env,
+			sockets:     []string{"/var/run/docker.sock"},
+			expectedEng: domain.EnginePodman,
+			expectedErr: ErrNoSocket,
+		},
+		{
+			name:        "nothing found falls back to default",
+			engine:      domain.EngineAuto,
+			env:         env,
+			expectedEng: domain.EngineAuto,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			isSocket := func(path string) bool { return slices.Contains(tt.sockets, path) }
+
+			host, engine, err := DiscoverHost(tt.engine, getenv, isSocket)
+			if err != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if host != tt.expectedHost {
+				t.Errorf("expected host %q, got %q", tt.expectedHost, host)
+			}
+			if engine != tt.expectedEng {
+				t.Errorf("expected engine %q, got %q", tt.expectedEng, engine)
+			}
+		})
+	}
+}
+
+func TestDetectEngine(t *testing.T) {
+	podman := types.Version{Components: []types.ComponentVersion{{Name: "Podman Engine", Version: "4.9.3"}}}
+	if got := detectEngine(podman); got != domain.EnginePodman {
+		t.Errorf("expected podman, got %q", got)
+	}
+
+	docker := types.Version{Components: []types.ComponentVersion{{Name: "Engine"}, {Name: "containerd"}}}
+	if got := detectEngine(docker); got != domain.EngineDocker {
+		t.Errorf("expected docker, got %q", got)
+	}
+}
+
+type versionAPI struct {
+	version types.Version
+	calls   int
+}
+
+func (a *versionAPI) ServerVersion(context.Context) (types.Version, error) {
+	a.calls++
+	return a.version, nil
+}
+
+func TestResolveEngine(t *testing.T) {
+	// podman-docker: Podman answering on /var/run/docker.sock.
+	podman := &versionAPI{version: types.Version{Components: []types.ComponentVersion{{Name: "Podman Engine"}}}}
+
+	got, err := resolveEngine(context.Background(), podman, domain.EngineAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != domain.EnginePodman {
+		t.Errorf("expected podman, got %q", got)
+	}
+
+	got, err = resolveEngine(context.Background(), podman, domain.EngineDocker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != domain.EngineDocker || podman.calls != 1 {
+		t.Errorf("expected the requested docker without a version query, got %q after %d queries", got, podman.calls)
+	}
+}
@@ -0,0 +1,245 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/analyzer"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// Explanation is why the analysis does or does not consider a resource unused.
+type Explanation struct {
+	Resource domain.Resource `json:"-"`
+	Kind     string          `json:"kind"`
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Unused   bool            `json:"unused"`
+	Reasons  []string        `json:"reasons"`
+}
+
+// Explain finds the resources matching query by ID prefix, name or image tag
+// and explains the verdict of the same analysis FindUnusedResourcer runs.
+func (c *DockerClient) Explain(ctx context.Context, query string, excludeTags []string, volumes domain.VolumePolicy, rules []domain.Rule) ([]Explanation, error) {
+	unused, err := c.FindUnusedResourcer(ctx, excludeTags, volumes, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	isUnused := make(map[string]bool, unused.TotalCount())
+	for _, res := range unused.Items() {
+		isUnused[res.Key()] = true
+	}
+
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true, Size: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	images, err := c.Cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker images: %w", err)
+	}
+
+	volumeList, err := c.Cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker volumes: %w", err)
+	}
+
+	networks, err := c.Cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
+	}
+
+	var explanations []Explanation
+	explain := func(res domain.Resource, names []string, reasons []string) {
+		if !matches(query, res.ID, names) {
+			return
+		}
+
+		verdict := isUnused[res.Key()]
+		reasons = append(reasons, ruleReasons(res, rules)...)
+		if !verdict && firstVerdict(res, rules) == domain.VerdictDelete {
+			reasons = append(reasons, "in use: rules cannot delete it")
+		}
+		if verdict {
+			reasons = append(reasons, "reported as unused: dockr would remove it")
+		} else {
+			reasons = append(reasons, "not reported as unused: dockr keeps it")
+		}
+
+		explanations = append(explanations, Explanation{
+			Resource: res,
+			Kind:     string(res.Kind),
+			ID:       res.ID,
+			Name:     res.Name,
+			Unused:   verdict,
+			Reasons:  reasons,
+		})
+	}
+
+	for i := range images {
+		img := &images[i]
+		explain(domain.ImageResource(img), img.RepoTags, c.imageReasons(img, containers, excludeTags))
+	}
+	for i := range containers {
+		cont := &containers[i]
+		names := make([]string, 0, len(cont.Names))
+		for _, name := range cont.Names {
+			names = append(names, strings.TrimPrefix(name, "/"))
+		}
+		explain(domain.ContainerResource(cont), names, c.containerReasons(cont))
+	}
+	for _, v := range volumeList.Volumes {
+		explain(domain.VolumeResource(v), []string{v.Name}, volumeReasons(v, containers, volumes))
+	}
+	users := NewNetworkUsers(containers)
+	for i := range networks {
+		net := &networks[i]
+		explain(domain.NetworkResource(net), []string{net.Name}, c.networkReasons(net, users))
+	}
+
+	if len(explanations) == 0 {
+		return nil, fmt.Errorf("no image, container, volume or network matches %q", query)
+	}
+
+	return explanations, nil
+}
+
+// matches reports whether query is a prefix of the ID, with or without the
+// "sha256:" algorithm, or one of the names.
+func matches(query, id string, names []string) bool {
+	if query == "" {
+		return false
+	}
+	if strings.HasPrefix(id, query) || strings.HasPrefix(strings.TrimPrefix(id, "sha256:"), query) {
+		return true
+	}
+	return slices.Contains(names, query)
+}
+
+// ruleReasons describes the rules that decide about the resource: the first
+// one wins, the ones after it are reported as overridden.
+func ruleReasons(res domain.Resource, rules []domain.Rule) []string {
+	var reasons []string
+	for _, rule := range rules {
+		d := rule(res)
+		if d.Verdict == domain.VerdictDefault {
+			continue
+		}
+
+		reason := fmt.Sprintf("a rule says %s", d.Verdict)
+		if d.Reason != "" {
+			reason += ": " + d.Reason
+		}
+		if len(reasons) > 0 {
+			reason = "overridden: " + reason
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// firstVerdict returns the verdict of the first rule that does not defer.
+func firstVerdict(res domain.Resource, rules []domain.Rule) domain.Verdict {
+	for _, rule := range rules {
+		if d := rule(res); d.Verdict != domain.VerdictDefault {
+			return d.Verdict
+		}
+	}
+	return domain.VerdictDefault
+}
+
+func (c *DockerClient) imageReasons(img *image.Summary, containers []container.Summary, excludeTags []string) []string {
+	var reasons []string
+
+	var users []string
+	for _, cont := range containers {
+		if cont.ImageID == img.ID {
+			users = append(users, domain.ContainerResource(&cont).Name)
+		}
+	}
+	if len(users) > 0 {
+		reasons = append(reasons, fmt.Sprintf("used by %d container(s): %s", len(users), strings.Join(users, ", ")))
+	}
+
+	if len(domain.ImageTags(img)) == 0 {
+		reasons = append(reasons, "dangling: it has no tags")
+	}
+
+	for _, tag := range img.RepoTags {
+		for _, excluded := range excludeTags {
+			if strings.Contains(tag, excluded) {
+				reasons = append(reasons, fmt.Sprintf("tag %s is excluded by %q", tag, excluded))
+			}
+		}
+	}
+
+	return reasons
+}
+
+func (c *DockerClient) containerReasons(cont *container.Summary) []string {
+	reasons := []string{fmt.Sprintf("state is %s", cont.State)}
+
+	if c.Engine == domain.EnginePodman && analyzer.IsPodInfraContainer(cont) {
+		reasons = append(reasons, "it is the infra container of a pod and goes with the pod")
+	}
+
+	return reasons
+}
+
+func volumeReasons(v *volume.Volume, containers []container.Summary, policy domain.VolumePolicy) []string {
+	var reasons []string
+
+	var users []string
+	for _, cont := range containers {
+		for _, mount := range cont.Mounts {
+			if mount.Type == "volume" && mount.Name == v.Name {
+				users = append(users, domain.ContainerResource(&cont).Name)
+			}
+		}
+	}
+	if len(users) > 0 {
+		reasons = append(reasons, fmt.Sprintf("mounted by %d container(s): %s", len(users), strings.Join(users, ", ")))
+	}
+
+	class := analyzer.ClassifyVolume(v)
+	reasons = append(reasons, fmt.Sprintf("%s volume", class))
+
+	if len(users) == 0 {
+		created := domain.VolumeResource(v).Created
+		switch {
+		case class == domain.VolumeNamed && policy.Scope != domain.VolumeScopeNamed:
+			reasons = append(reasons, "named volumes are only removed with --volumes named")
+		case !policy.Allows(class, created, time.Now()):
+			reasons = append(reasons, fmt.Sprintf("the volume policy keeps unused %s volumes of this age", class))
+		}
+	}
+
+	return reasons
+}
+
+func (c *DockerClient) networkReasons(net *network.Summary, users NetworkUsers) []string {
+	var reasons []string
+
+	if analyzer.IsDefaultNetwork(net.Name, c.Engine) {
+		reasons = append(reasons, "it is a default network of the engine")
+	}
+
+	if conts := users.Of(net); len(conts) > 0 {
+		names := make([]string, 0, len(conts))
+		for _, cont := range conts {
+			names = append(names, domain.ContainerResource(cont).Name)
+		}
+		reasons = append(reasons, fmt.Sprintf("referenced by %d container(s): %s", len(names), strings.Join(names, ", ")))
+	}
+
+	return reasons
+}
@@ -0,0 +1,123 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// ListImages lists all tagged and dangling images, without intermediate layers.
+func (c *DockerClient) ListImages(ctx context.Context) ([]*image.Summary, error) {
+	images, err := c.Cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker images: %w", err)
+	}
+
+	result := make([]*image.Summary, 0, len(images))
+	for i := range images {
+		result = append(result, &images[i])
+	}
+
+	return result, nil
+}
+
+// ContentDigests returns a digest of the content of every image: its layers,
+// platform and configuration, healthcheck, shell and ONBUILD triggers
+// included. Images rebuilt from identical content share the digest even
+// though their IDs differ by creation time and history.
+func (c *DockerClient) ContentDigests(ctx context.Context, images []*image.Summary) (map[string]string, error) {
+	digests := make(map[string]string, len(images))
+
+	for _, img := range images {
+		info, err := c.Cli.ImageInspect(ctx, img.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image with ID: %s, err: %w", img.ID, err)
+		}
+
+		// The image configuration holds nothing but what containers run
+		// with, unlike the container configuration of the build step.
+		content := struct {
+			Layers       []string
+			Architecture string
+			Os           string
+			Variant      string
+			Config       any
+		}{
+			Layers:       info.RootFS.Layers,
+			Architecture: info.Architecture,
+			Os:           info.Os,
+			Variant:      info.Variant,
+			Config:       info.Config,
+		}
+
+		data, err := json.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode content of image %s: %w", img.ID, err)
+		}
+
+		sum := sha256.Sum256(data)
+		digests[img.ID] = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return digests, nil
+}
+
+// PushImage tags the image source as target, pushes target with the encoded
+// registry auth and removes the temporary tag again. It returns the
+// manifest digest the registry reported.
+func (c *DockerClient) PushImage(ctx context.Context, source, target, registryAuth string) (string, error) {
+	if source != target {
+		if err := c.Cli.ImageTag(ctx, source, target); err != nil {
+			return "", fmt.Errorf("failed to tag %s as %s: %w", source, target, err)
+		}
+		defer func() {
+			// The image keeps its source tag, so this only untags.
+			_, _ = c.Cli.ImageRemove(context.WithoutCancel(ctx), target, image.RemoveOptions{})
+		}()
+	}
+
+	stream, err := c.Cli.ImagePush(ctx, target, image.PushOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return "", fmt.Errorf("failed to push %s: %w", target, err)
+	}
+	defer stream.Close()
+
+	var digest string
+
+	dec := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", fmt.Errorf("failed to read push progress of %s: %w", target, err)
+		}
+
+		if msg.Error != nil {
+			return "", fmt.Errorf("failed to push %s: %s", target, msg.Error.Message)
+		}
+
+		if msg.Aux != nil {
+			var aux struct {
+				Digest string
+			}
+			if err := json.Unmarshal(*msg.Aux, &aux); err == nil && aux.Digest != "" {
+				digest = aux.Digest
+			}
+		}
+	}
+
+	if digest == "" {
+		return "", fmt.Errorf("push of %s reported no digest", target)
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type inspectAPI struct {
+	API
+	images map[string]image.InspectResponse
+}
+
+func (a inspectAPI) ImageInspect(_ context.Context, id string, _ ...client.ImageInspectOption) (image.InspectResponse, error) {
+	return a.images[id], nil
+}
+
+func TestContentDigests(t *testing.T) {
+	build := func(id string, health *dockerspec.HealthcheckConfig, shell []string) image.InspectResponse {
+		return image.InspectResponse{
+			ID:     id,
+			Os:     "linux",
+			RootFS: image.RootFS{Layers: []string{"sha256:layer"}},
+			Config: &dockerspec.DockerOCIImageConfig{
+				ImageConfig:             ocispec.ImageConfig{Cmd: []string{"app"}},
+				DockerOCIImageConfigExt: dockerspec.DockerOCIImageConfigExt{Healthcheck: health, Shell: shell},
+			},
+		}
+	}
+	check := &dockerspec.HealthcheckConfig{Test: []string{"CMD", "check"}, Interval: 10 * time.Second}
+
+	c := &DockerClient{Cli: inspectAPI{images: map[string]image.InspectResponse{
+		"rebuilt-1": build("rebuilt-1", check, nil),
+		"rebuilt-2": build("rebuilt-2", check, nil),
+		"no-health": build("no-health", nil, nil),
+		"slower":    build("slower", &dockerspec.HealthcheckConfig{Test: check.Test, Interval: time.Minute}, nil),
+		"bash":      build("bash", check, []string{"/bin/bash", "-c"}),
+	}}}
+
+	var images []*image.Summary
+	for _, id := range []string{"rebuilt-1", "rebuilt-2", "no-health", "slower", "bash"} {
+		images = append(images, &image.Summary{ID: id})
+	}
+
+	digests, err := c.ContentDigests(context.Background(), images)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digests["rebuilt-1"] != digests["rebuilt-2"] {
+		t.Error("expected identical content to share the digest")
+	}
+	for _, id := range []string{"no-health", "slower", "bash"} {
+		if digests[id] == digests["rebuilt-1"] {
+			t.Errorf("%s: expected a different digest", id)
+		}
+	}
+}
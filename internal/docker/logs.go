@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/logs"
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerLogs inspects every container for its log path and log driver configuration.
+func (c *DockerClient) ContainerLogs(ctx context.Context) ([]logs.Container, error) {
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	result := make([]logs.Container, 0, len(containers))
+	for _, cont := range containers {
+		info, err := c.Cli.ContainerInspect(ctx, cont.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container with ID: %s, err: %w", cont.ID, err)
+		}
+
+		lc := logs.Container{
+			ID:      cont.ID,
+			Name:    domain.ContainerResource(&cont).Name,
+			State:   cont.State,
+			LogPath: info.LogPath,
+		}
+		if info.HostConfig != nil {
+			lc.Driver = info.HostConfig.LogConfig.Type
+			lc.Options = info.HostConfig.LogConfig.Config
+		}
+
+		result = append(result, lc)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/DobryySoul/dockr/internal/ipam"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// NetworkSubnets lists every network with the subnets of its IPAM config.
+// Networks in unused are marked as unused.
+func (c *DockerClient) NetworkSubnets(ctx context.Context, unused []*network.Summary) ([]ipam.Network, error) {
+	networks, err := c.Cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
+	}
+
+	isUnused := make(map[string]bool, len(unused))
+	for _, net := range unused {
+		isUnused[net.ID] = true
+	}
+
+	result := make([]ipam.Network, 0, len(networks))
+	for _, net := range networks {
+		n := ipam.Network{ID: net.ID, Name: net.Name, Unused: isUnused[net.ID]}
+		for _, cfg := range net.IPAM.Config {
+			// Engines may report an empty or malformed subnet for networks
+			// without IPAM (host, none); those hold no address space.
+			if prefix, err := netip.ParsePrefix(cfg.Subnet); err == nil {
+				n.Subnets = append(n.Subnets, prefix)
+			}
+		}
+		result = append(result, n)
+	}
+
+	return result, nil
+}
+
+// NetworkUsers maps networks to the containers that reference them in their
+// network settings, stopped containers included. Keys are network IDs, or
+// names where the engine does not report the ID.
+type NetworkUsers map[string][]*container.Summary
+
+// NewNetworkUsers indexes the networks referenced by the containers.
+func NewNetworkUsers(containers []container.Summary) NetworkUsers {
+	users := make(NetworkUsers)
+	for i := range containers {
+		cont := &containers[i]
+		if cont.NetworkSettings == nil {
+			continue
+		}
+		for name, endpoint := range cont.NetworkSettings.Networks {
+			key := name
+			if endpoint != nil && endpoint.NetworkID != "" {
+				key = endpoint.NetworkID
+			}
+			users[key] = append(users[key], cont)
+		}
+	}
+	return users
+}
+
+// Of returns the containers referencing the network.
+func (u NetworkUsers) Of(net *network.Summary) []*container.Summary {
+	users := u[net.ID]
+	if net.Name != net.ID {
+		users = append(slices.Clip(users), u[net.Name]...)
+	}
+	return users
+}
+
+// NetworkUsers lists all containers and indexes the networks they reference.
+func (c *DockerClient) NetworkUsers(ctx context.Context) (NetworkUsers, error) {
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	return NewNetworkUsers(containers), nil
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestNetworkUsers(t *testing.T) {
+	withNetworks := func(id string, networks map[string]*network.EndpointSettings) container.Summary {
+		return container.Summary{
+			ID:              id,
+			State:           "exited",
+			NetworkSettings: &container.NetworkSettingsSummary{Networks: networks},
+		}
+	}
+
+	users := NewNetworkUsers([]container.Summary{
+		withNetworks("c1", map[string]*network.EndpointSettings{"app": {NetworkID: "net-app"}}),
+		withNetworks("c2", map[string]*network.EndpointSettings{"app": {NetworkID: "net-app"}, "db": {}}),
+		{ID: "c3"},
+	})
+
+	tests := []struct {
+		net  *network.Summary
+		want []string
+	}{
+		{net: &network.Summary{ID: "net-app", Name: "app"}, want: []string{"c1", "c2"}},
+		// Without a network ID in the endpoint, the name is matched.
+		{net: &network.Summary{ID: "net-db", Name: "db"}, want: []string{"c2"}},
+		{net: &network.Summary{ID: "net-other", Name: "other"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, cont := range users.Of(tt.net) {
+			got = append(got, cont.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("users of %s = %v, want %v", tt.net.Name, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("users of %s = %v, want %v", tt.net.Name, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/policy"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// PolicyFacts collects the containers referencing every image, volume and
+// network for policy rules and, with lastUsed, when every resource was last
+// used, which inspects all containers and images.
+func (c *DockerClient) PolicyFacts(ctx context.Context, lastUsed bool) (*policy.Facts, error) {
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	networks, err := c.Cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
+	}
+
+	facts := &policy.Facts{Users: make(map[string][]string)}
+
+	for i := range containers {
+		cont := &containers[i]
+		name := domain.ContainerResource(cont).Name
+
+		imageKey := domain.Resource{Kind: domain.KindImage, ID: cont.ImageID}.Key()
+		facts.Users[imageKey] = append(facts.Users[imageKey], name)
+
+		for _, mount := range cont.Mounts {
+			if mount.Type == "volume" {
+				key := domain.Resource{Kind: domain.KindVolume, ID: mount.Name}.Key()
+				facts.Users[key] = append(facts.Users[key], name)
+			}
+		}
+	}
+
+	users := NewNetworkUsers(containers)
+	for i := range networks {
+		net := &networks[i]
+		key := domain.NetworkResource(net).Key()
+		for _, cont := range users.Of(net) {
+			facts.Users[key] = append(facts.Users[key], domain.ContainerResource(cont).Name)
+		}
+	}
+
+	if !lastUsed {
+		return facts, nil
+	}
+
+	all, err := c.AllResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if facts.LastUsed, err = c.LastUsed(ctx, all); err != nil {
+		return nil, err
+	}
+
+	return facts, nil
+}
+
+// AllResources lists every image, container, volume and network of the host.
+func (c *DockerClient) AllResources(ctx context.Context) (*domain.UnusedResources, error) {
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	all := &domain.UnusedResources{}
+	for i := range containers {
+		all.Containers = append(all.Containers, &containers[i])
+	}
+
+	images, err := c.Cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker images: %w", err)
+	}
+	for i := range images {
+		all.Images = append(all.Images, &images[i])
+	}
+
+	volumes, err := c.Cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker volumes: %w", err)
+	}
+	all.Volumes = volumes.Volumes
+
+	networks, err := c.Cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
+	}
+	for i := range networks {
+		all.Networks = append(all.Networks, &networks[i])
+	}
+
+	return all, nil
+}
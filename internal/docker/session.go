@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// FindLabeled collects every resource that carries all the given labels
+// ("key=value" or just "key"), running containers included. Unlike the
+// unused analysis it makes no judgement: the labels alone decide.
+func (c *DockerClient) FindLabeled(ctx context.Context, labels []string) (*domain.UnusedResources, error) {
+	args := filters.NewArgs()
+	for _, label := range labels {
+		args.Add("label", label)
+	}
+
+	resources := &domain.UnusedResources{}
+
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true, Size: true, Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+	for _, cont := range containers {
+		resources.Containers = append(resources.Containers, &cont)
+	}
+
+	networks, err := c.Cli.NetworkList(ctx, network.ListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
+	}
+	for _, net := range networks {
+		resources.Networks = append(resources.Networks, &net)
+	}
+
+	volumes, err := c.Cli.VolumeList(ctx, volume.ListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker volumes: %w", err)
+	}
+	resources.Volumes = volumes.Volumes
+
+	images, err := c.Cli.ImageList(ctx, image.ListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker images: %w", err)
+	}
+	for _, img := range images {
+		resources.Images = append(resources.Images, &img)
+	}
+
+	return resources, nil
+}
+
+// FindLabeledAny collects every resource that carries at least one of the
+// label keys, whatever its value.
+func (c *DockerClient) FindLabeledAny(ctx context.Context, keys []string) (*domain.UnusedResources, error) {
+	all := &domain.UnusedResources{}
+	seen := make(map[string]bool)
+
+	for _, key := range keys {
+		found, err := c.FindLabeled(ctx, []string{key})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, img := range found.Images {
+			if k := domain.ImageResource(img).Key(); !seen[k] {
+				seen[k] = true
+				all.Images = append(all.Images, img)
+			}
+		}
+		for _, cont := range found.Containers {
+			if k := domain.ContainerResource(cont).Key(); !seen[k] {
+				seen[k] = true
+				all.Containers = append(all.Containers, cont)
+			}
+		}
+		for _, v := range found.Volumes {
+			if k := domain.VolumeResource(v).Key(); !seen[k] {
+				seen[k] = true
+				all.Volumes = append(all.Volumes, v)
+			}
+		}
+		for _, net := range found.Networks {
+			if k := domain.NetworkResource(net).Key(); !seen[k] {
+				seen[k] = true
+				all.Networks = append(all.Networks, net)
+			}
+		}
+	}
+
+	return all, nil
+}
+
+// ImageUsers maps image IDs to the IDs of all containers created from them.
+func (c *DockerClient) ImageUsers(ctx context.Context) (map[string][]string, error) {
+	containers, err := c.Cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	users := make(map[string][]string)
+	for _, cont := range containers {
+		users[cont.ImageID] = append(users[cont.ImageID], cont.ID)
+	}
+
+	return users, nil
+}
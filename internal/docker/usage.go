@@ -0,0 +1,215 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// FillUsage fills in the sizes that the list endpoints leave out: the shared
+// size of images and the disk usage of volumes. It uses the daemon's disk
+// usage endpoint, which can be slow on hosts with many volumes.
+func (c *DockerClient) FillUsage(ctx context.Context, resources *domain.UnusedResources) error {
+	du, err := c.Cli.DiskUsage(ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	sharedSize := make(map[string]int64, len(du.Images))
+	for _, img := range du.Images {
+		sharedSize[img.ID] = img.SharedSize
+	}
+	for _, img := range resources.Images {
+		if shared, ok := sharedSize[img.ID]; ok {
+			img.SharedSize = shared
+		}
+	}
+
+	volumeUsage := make(map[string]*volume.UsageData, len(du.Volumes))
+	for _, v := range du.Volumes {
+		if v.UsageData != nil {
+			volumeUsage[v.Name] = v.UsageData
+		}
+	}
+	for _, v := range resources.Volumes {
+		if usage, ok := volumeUsage[v.Name]; ok {
+			v.UsageData = usage
+		}
+	}
+
+	return nil
+}
+
+// Footprint returns the disk space used by image layers, container writable
+// layers and volumes, as reported by the daemon.
+func (c *DockerClient) Footprint(ctx context.Context) (int64, error) {
+	du, err := c.Cli.DiskUsage(ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	total := du.LayersSize
+	for _, cont := range du.Containers {
+		total += cont.SizeRw
+	}
+	for _, v := range du.Volumes {
+		if v.UsageData != nil && v.UsageData.Size > 0 {
+			total += v.UsageData.Size
+		}
+	}
+
+	return total, nil
+}
+
+// LastUsed estimates when each resource was last used, keyed by domain.Resource.Key:
+// containers by the time they stopped, images by the time they were last tagged
+// or last run by one of the given containers. Resources without better
+// information fall back to their creation time.
+func (c *DockerClient) LastUsed(ctx context.Context, resources *domain.UnusedResources) (map[string]time.Time, error) {
+	lastUsed := make(map[string]time.Time, resources.TotalCount())
+	for _, res := range resources.Items() {
+		lastUsed[res.Key()] = res.Created
+	}
+
+	for _, cont := range resources.Containers {
+		info, err := c.Cli.ContainerInspect(ctx, cont.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container with ID: %s, err: %w", cont.ID, err)
+		}
+
+		if info.State == nil {
+			continue
+		}
+
+		finished, err := time.Parse(time.RFC3339Nano, info.State.FinishedAt)
+		if err != nil || finished.Unix() <= 0 {
+			continue
+		}
+
+		res := domain.ContainerResource(cont)
+		lastUsed[res.Key()] = latest(lastUsed[res.Key()], finished)
+
+		imageKey := domain.Resource{Kind: domain.KindImage, ID: cont.ImageID}.Key()
+		if t, ok := lastUsed[imageKey]; ok {
+			lastUsed[imageKey] = latest(t, finished)
+		}
+	}
+
+	for _, img := range resources.Images {
+		info, err := c.Cli.ImageInspect(ctx, img.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image with ID: %s, err: %w", img.ID, err)
+		}
+
+		res := domain.ImageResource(img)
+		lastUsed[res.Key()] = latest(lastUsed[res.Key()], info.Metadata.LastTagTime)
+	}
+
+	return lastUsed, nil
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// HostReport is an overview of a host: the engine and the disk usage by
+// resource type, as `docker system df` shows it.
+type HostReport struct {
+	Host          string      `json:"host"`
+	Engine        string      `json:"engine"`
+	Version       string      `json:"version"`
+	OS            string      `json:"os"`
+	Kernel        string      `json:"kernel"`
+	StorageDriver string      `json:"storage_driver"`
+	RootDir       string      `json:"root_dir"`
+	Usage         []TypeUsage `json:"usage"`
+}
+
+// TypeUsage is the disk usage of one resource type. Reclaimable is the space
+// used only by resources that are not active.
+type TypeUsage struct {
+	Type        string `json:"type"`
+	Total       int    `json:"total"`
+	Active      int    `json:"active"`
+	Size        int64  `json:"size"`
+	Reclaimable int64  `json:"reclaimable"`
+}
+
+// HostReport describes the engine and its disk usage.
+func (c *DockerClient) HostReport(ctx context.Context) (*HostReport, error) {
+	info, err := c.Cli.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daemon info: %w", err)
+	}
+
+	du, err := c.Cli.DiskUsage(ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	report := &HostReport{
+		Host:          c.Cli.DaemonHost(),
+		Engine:        string(c.Engine),
+		Version:       info.ServerVersion,
+		OS:            info.OperatingSystem,
+		Kernel:        info.KernelVersion,
+		StorageDriver: info.Driver,
+		RootDir:       info.DockerRootDir,
+	}
+
+	images := TypeUsage{Type: "Images", Total: len(du.Images), Size: du.LayersSize}
+	for _, img := range du.Images {
+		if img.Containers > 0 {
+			images.Active++
+		} else if img.SharedSize >= 0 {
+			images.Reclaimable += img.Size - img.SharedSize
+		} else {
+			images.Reclaimable += img.Size
+		}
+	}
+
+	containers := TypeUsage{Type: "Containers", Total: len(du.Containers)}
+	for _, cont := range du.Containers {
+		containers.Size += cont.SizeRw
+		if cont.State == "running" {
+			containers.Active++
+		} else {
+			containers.Reclaimable += cont.SizeRw
+		}
+	}
+
+	volumes := TypeUsage{Type: "Volumes", Total: len(du.Volumes)}
+	for _, v := range du.Volumes {
+		if v.UsageData == nil {
+			continue
+		}
+		size := max(v.UsageData.Size, 0)
+		volumes.Size += size
+		if v.UsageData.RefCount > 0 {
+			volumes.Active++
+		} else {
+			volumes.Reclaimable += size
+		}
+	}
+
+	buildCache := TypeUsage{Type: "Build Cache", Total: len(du.BuildCache)}
+	for _, bc := range du.BuildCache {
+		buildCache.Size += bc.Size
+		if bc.InUse {
+			buildCache.Active++
+		} else if !bc.Shared {
+			buildCache.Reclaimable += bc.Size
+		}
+	}
+
+	report.Usage = []TypeUsage{images, containers, volumes, buildCache}
+
+	return report, nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "fmt"
+
+// Engine identifies the container engine that serves the Docker-compatible API.
+type Engine string
+
+const (
+	EngineAuto   Engine = "auto"
+	EngineDocker Engine = "docker"
+	EnginePodman Engine = "podman"
+)
+
+// ParseEngine validates the value of the --engine flag.
+func ParseEngine(s string) (Engine, error) {
+	switch e := Engine(s); e {
+	case EngineAuto, EngineDocker, EnginePodman:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown engine %q (expected docker, podman or auto)", s)
+	}
+}
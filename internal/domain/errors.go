@@ -0,0 +1,7 @@
+package domain
+
+import "errors"
+
+// ErrSkipped marks a resource that was deliberately left in place (for example
+// vetoed by a hook) rather than failing to be removed.
+var ErrSkipped = errors.New("removal skipped")
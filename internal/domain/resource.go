@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// ResourceKind is the type of a Docker resource.
+type ResourceKind string
+
+const (
+	KindImage     ResourceKind = "image"
+	KindContainer ResourceKind = "container"
+	KindVolume    ResourceKind = "volume"
+	KindNetwork   ResourceKind = "network"
+	// KindTag is an image reference removed or moved without deleting the image.
+	KindTag ResourceKind = "tag"
+)
+
+// Kinds lists resource kinds in the order they are reported.
+var Kinds = []ResourceKind{KindImage, KindContainer, KindVolume, KindNetwork}
+
+// Resource is a flat, type-independent view of a single Docker resource.
+// Object holds the original API object (*image.Summary, *container.Summary,
+// *volume.Volume or *network.Summary).
+type Resource struct {
+	Kind    ResourceKind
+	ID      string
+	Name    string
+	Size    int64
+	Created time.Time
+	Labels  map[string]string
+	Object  any
+}
+
+// Key identifies the resource uniquely across kinds.
+func (r Resource) Key() string {
+	return string(r.Kind) + "/" + r.ID
+}
+
+// ImageResource builds the resource view of an image.
+func ImageResource(img *image.Summary) Resource {
+	name := "<none>"
+	if tags := ImageTags(img); len(tags) > 0 {
+		name = strings.Join(tags, ", ")
+	}
+
+	return Resource{
+		Kind:    KindImage,
+		ID:      img.ID,
+		Name:    name,
+		Size:    img.Size,
+		Created: time.Unix(img.Created, 0),
+		Labels:  img.Labels,
+		Object:  img,
+	}
+}
+
+// ImageTags returns the tags of an image without the "<none>:<none>"
+// placeholder some engines list for untagged images.
+func ImageTags(img *image.Summary) []string {
+	var tags []string
+	for _, ref := range img.RepoTags {
+		if ref != "<none>:<none>" {
+			tags = append(tags, ref)
+		}
+	}
+	return tags
+}
+
+// ContainerResource builds the resource view of a container.
+func ContainerResource(c *container.Summary) Resource {
+	var name string
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	return Resource{
+		Kind:    KindContainer,
+		ID:      c.ID,
+		Name:    name,
+		Size:    c.SizeRw,
+		Created: time.Unix(c.Created, 0),
+		Labels:  c.Labels,
+		Object:  c,
+	}
+}
+
+// VolumeResource builds the resource view of a volume. Volumes have no ID,
+// so the name is used for both.
+func VolumeResource(v *volume.Volume) Resource {
+	var size int64
+	if v.UsageData != nil && v.UsageData.Size > 0 {
+		size = v.UsageData.Size
+	}
+
+	created, _ := time.Parse(time.RFC3339, v.CreatedAt)
+
+	return Resource{
+		Kind:    KindVolume,
+		ID:      v.Name,
+		Name:    v.Name,
+		Size:    size,
+		Created: created,
+		Labels:  v.Labels,
+		Object:  v,
+	}
+}
+
+// NetworkResource builds the resource view of a network.
+func NetworkResource(n *network.Summary) Resource {
+	return Resource{
+		Kind:    KindNetwork,
+		ID:      n.ID,
+		Name:    n.Name,
+		Created: n.Created,
+		Labels:  n.Labels,
+		Object:  n,
+	}
+}
+
+// Items returns all resources as a flat list, grouped by kind in report order.
+func (ur *UnusedResources) Items() []Resource {
+	items := make([]Resource, 0, ur.TotalCount())
+	for _, img := range ur.Images {
+		items = append(items, ImageResource(img))
+	}
+	for _, c := range ur.Containers {
+		items = append(items, ContainerResource(c))
+	}
+	for _, v := range ur.Volumes {
+		items = append(items, VolumeResource(v))
+	}
+	for _, n := range ur.Networks {
+		items = append(items, NetworkResource(n))
+	}
+	return items
+}
+
+// Select returns a new UnusedResources holding only the resources for which keep returns true.
+func (ur *UnusedResources) Select(keep func(Resource) bool) *UnusedResources {
+	selected := &UnusedResources{}
+	for _, img := range ur.Images {
+		if keep(ImageResource(img)) {
+			selected.Images = append(selected.Images, img)
+		}
+	}
+	for _, c := range ur.Containers {
+		if keep(ContainerResource(c)) {
+			selected.Containers = append(selected.Containers, c)
+		}
+	}
+	for _, v := range ur.Volumes {
+		if keep(VolumeResource(v)) {
+			selected.Volumes = append(selected.Volumes, v)
+		}
+	}
+	for _, n := range ur.Networks {
+		if keep(NetworkResource(n)) {
+			selected.Networks = append(selected.Networks, n)
+		}
+	}
+	return selected
+}
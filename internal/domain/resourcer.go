@@ -8,10 +8,10 @@ import (
 )
 
 type UnusedResources struct {
-	Images     []*image.Summary
-	Containers []*container.Summary
-	Volumes    []*volume.Volume
-	Networks   []*network.Summary
+	Images     []*image.Summary     `json:"images"`
+	Containers []*container.Summary `json:"containers"`
+	Volumes    []*volume.Volume     `json:"volumes"`
+	Networks   []*network.Summary   `json:"networks"`
 }
 
 func (ur *UnusedResources) ContainersSize() float64 {
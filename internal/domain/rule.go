@@ -0,0 +1,34 @@
+package domain
+
+// Verdict is the outcome of a rule for a single resource.
+type Verdict int
+
+const (
+	// VerdictDefault defers to the built-in analyzer logic.
+	VerdictDefault Verdict = iota
+	// VerdictKeep protects the resource even if the analyzer considers it unused.
+	VerdictKeep
+	// VerdictDelete marks the resource for removal even if the analyzer would keep it.
+	VerdictDelete
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case VerdictKeep:
+		return "keep"
+	case VerdictDelete:
+		return "delete"
+	default:
+		return "default"
+	}
+}
+
+// Decision is a rule's verdict together with a human-readable reason.
+type Decision struct {
+	Verdict Verdict
+	Reason  string
+}
+
+// Rule decides about a single resource. Rules see every resource on the host,
+// not only the ones the analyzer considers unused.
+type Rule func(res Resource) Decision
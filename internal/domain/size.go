@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+)
+
+// ByteSize is a size in bytes that can be written in human form ("512MB", "20GB")
+// in flags and configuration. Units are binary, matching the MB figures in reports.
+type ByteSize int64
+
+// ParseByteSize parses a human-readable size such as "20GB", "1.5g" or "1048576".
+func ParseByteSize(s string) (ByteSize, error) {
+	n, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return ByteSize(n), nil
+}
+
+// String renders the size with two decimals in the largest fitting unit, e.g. "1.50 GB".
+func (b ByteSize) String() string {
+	return HumanSize(int64(b))
+}
+
+// Set implements pflag.Value so ByteSize can be used directly as a flag.
+func (b *ByteSize) Set(s string) error {
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// Type implements pflag.Value.
+func (b *ByteSize) Type() string {
+	return "size"
+}
+
+// UnmarshalText lets ByteSize be decoded from YAML and JSON strings.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	return b.Set(string(text))
+}
+
+// HumanSize renders a size in bytes with two decimals in the largest fitting binary unit.
+func HumanSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+
+	v := float64(size)
+	i := 0
+	for ; (v >= 1024 || v <= -1024) && i < len(units)-1; i++ {
+		v /= 1024
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.2f %s", v, units[i])
+}
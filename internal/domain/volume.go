@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// VolumeClass tells anonymous volumes, which the engine creates with a random
+// name for a container's VOLUME or -v /path, from named ones.
+type VolumeClass string
+
+const (
+	VolumeAnonymous VolumeClass = "anonymous"
+	VolumeNamed     VolumeClass = "named"
+)
+
+// VolumeScope is the value of the --volumes flag: the classes of unused
+// volumes that may be removed.
+type VolumeScope string
+
+const (
+	// VolumeScopeAnonymous removes anonymous volumes only. It is the default.
+	VolumeScopeAnonymous VolumeScope = "anonymous"
+	// VolumeScopeNamed removes named volumes too.
+	VolumeScopeNamed VolumeScope = "named"
+)
+
+// ParseVolumeScope validates the value of the --volumes flag.
+func ParseVolumeScope(s string) (VolumeScope, error) {
+	switch v := VolumeScope(s); v {
+	case VolumeScopeAnonymous, VolumeScopeNamed:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown volume scope %q (expected anonymous or named)", s)
+	}
+}
+
+// VolumePolicy decides which unused volumes may be removed.
+type VolumePolicy struct {
+	// Scope selects the classes to remove; empty means anonymous only.
+	Scope VolumeScope
+	// Retention keeps unused volumes of a class younger than this.
+	AnonymousRetention time.Duration
+	NamedRetention     time.Duration
+}
+
+// Allows reports whether the policy allows removing an unused volume of the
+// class created at the given time. Volumes with an unknown creation time are
+// treated as old enough.
+func (p VolumePolicy) Allows(class VolumeClass, created, now time.Time) bool {
+	retention := p.AnonymousRetention
+	if class == VolumeNamed {
+		if p.Scope != VolumeScopeNamed {
+			return false
+		}
+		retention = p.NamedRetention
+	}
+
+	return created.IsZero() || now.Sub(created) >= retention
+}
@@ -0,0 +1,67 @@
+package extern
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/jsoncache"
+)
+
+// Cache keeps the verdicts of plugins in a JSON file, so unchanged resources
+// are not sent to them again on every run.
+type Cache struct {
+	entries *jsoncache.Cache[cacheEntry]
+}
+
+type cacheEntry struct {
+	Verdict   string    `json:"verdict"`
+	Reason    string    `json:"reason,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// DefaultCachePath returns dockr/analyzers.json in the user's cache directory.
+func DefaultCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "dockr-analyzers.json"
+	}
+
+	return filepath.Join(dir, "dockr", "analyzers.json")
+}
+
+// LoadCache reads the cache at path; a missing file is an empty cache.
+// Verdicts older than ttl are dropped.
+func LoadCache(path string, ttl time.Duration) (*Cache, error) {
+	entries, err := jsoncache.Load(path, jsoncache.Options[cacheEntry]{
+		Name:  "analyzers",
+		TTL:   ttl,
+		Stamp: func(e cacheEntry) time.Time { return e.CheckedAt },
+		Prune: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{entries: entries}, nil
+}
+
+// Get returns the cached verdict unless it is older than the TTL.
+func (c *Cache) Get(key string, now time.Time) (Verdict, bool) {
+	e, ok := c.entries.Get(key, now)
+	if !ok {
+		return Verdict{}, false
+	}
+
+	return Verdict{Verdict: e.Verdict, Reason: e.Reason}, true
+}
+
+// Put stores a verdict.
+func (c *Cache) Put(key string, v Verdict, now time.Time) {
+	c.entries.Put(key, cacheEntry{Verdict: v.Verdict, Reason: v.Reason, CheckedAt: now})
+}
+
+// Save writes the cache back if it changed, without the expired verdicts.
+func (c *Cache) Save() error {
+	return c.entries.Save()
+}
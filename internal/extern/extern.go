@@ -0,0 +1,399 @@
+// Package extern runs analyzer plugins: executables in a directory that
+// receive the candidate resources of the host as JSON and answer with keep or delete
+// verdicts, for protection logic that lives outside dockr.
+package extern
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/hooks"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+)
+
+// Verdicts a plugin may return.
+const (
+	Delete  = "delete"
+	Keep    = "keep"
+	Default = "default"
+)
+
+// What to do with the resources of a batch a plugin fails on.
+const (
+	FailKeep   = "keep"   // keep them, the safe choice
+	FailIgnore = "ignore" // leave them to the other rules
+)
+
+// Version is the version of the protocol sent in every request.
+const Version = 1
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultBatchSize = 100
+	// DefaultCacheTTL is how long a verdict is reused for an unchanged resource.
+	DefaultCacheTTL = time.Hour
+)
+
+// Config is the analyzers section of the configuration file.
+type Config struct {
+	// Dir holds the plugins; every executable file in it is one. Empty uses
+	// DefaultDir.
+	Dir string `yaml:"dir"`
+	// Timeout limits a plugin's answer to one batch.
+	Timeout time.Duration `yaml:"timeout"`
+	// BatchSize is the number of resources per request.
+	BatchSize int `yaml:"batch_size"`
+	// Cache is the file verdicts are kept in between runs; a negative
+	// CacheTTL disables it.
+	Cache     string        `yaml:"cache"`
+	CacheTTL  time.Duration `yaml:"cache_ttl"`
+	OnFailure string        `yaml:"on_failure"`
+}
+
+// Request is the JSON document a plugin receives on stdin.
+type Request struct {
+	Version   int                     `json:"version"`
+	Analyzer  string                  `json:"analyzer"`
+	Resources []hooks.ResourcePayload `json:"resources"`
+}
+
+// Response is the JSON document a plugin writes to stdout. Resources it
+// leaves out are left to the other rules.
+type Response struct {
+	Verdicts []Verdict `json:"verdicts"`
+}
+
+// Verdict is a plugin's decision about one resource.
+type Verdict struct {
+	Kind    domain.ResourceKind `json:"kind"`
+	ID      string              `json:"id"`
+	Verdict string              `json:"verdict"`
+	Reason  string              `json:"reason,omitempty"`
+}
+
+// Plugin is an executable found in the plugins directory.
+type Plugin struct {
+	Name string
+	Path string
+	// version changes when the executable does and invalidates its cache.
+	version string
+}
+
+// DefaultDir returns dockr/analyzers in the user's configuration directory.
+func DefaultDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "analyzers"
+	}
+
+	return filepath.Join(dir, "dockr", "analyzers")
+}
+
+// Discover returns the executable files in dir sorted by name; a missing
+// directory has none.
+func Discover(dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read analyzers directory: %w", err)
+	}
+
+	var plugins []Plugin
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+
+		plugins = append(plugins, Plugin{
+			Name:    strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())),
+			Path:    path,
+			version: fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()),
+		})
+	}
+
+	return plugins, nil
+}
+
+// Runner asks the plugins about resources.
+type Runner struct {
+	plugins   []Plugin
+	timeout   time.Duration
+	batchSize int
+	onFailure string
+	cache     *Cache
+	now       func() time.Time
+}
+
+// New discovers the plugins of the configuration and loads their cache.
+func New(cfg Config) (*Runner, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = DefaultDir()
+	}
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Runner{
+		plugins:   plugins,
+		timeout:   cfg.Timeout,
+		batchSize: cfg.BatchSize,
+		onFailure: cfg.OnFailure,
+		now:       time.Now,
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultTimeout
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultBatchSize
+	}
+
+	switch r.onFailure {
+	case "":
+		r.onFailure = FailKeep
+	case FailKeep, FailIgnore:
+	default:
+		return nil, fmt.Errorf("analyzers: on_failure must be keep or ignore, got %q", cfg.OnFailure)
+	}
+
+	if len(plugins) > 0 && cfg.CacheTTL >= 0 {
+		path := cfg.Cache
+		if path == "" {
+			path = DefaultCachePath()
+		}
+		ttl := cfg.CacheTTL
+		if ttl == 0 {
+			ttl = DefaultCacheTTL
+		}
+		if r.cache, err = LoadCache(path, ttl); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Empty reports whether there are no plugins.
+func (r *Runner) Empty() bool {
+	return r == nil || len(r.plugins) == 0
+}
+
+// Plugins returns the discovered plugins.
+func (r *Runner) Plugins() []Plugin {
+	if r == nil {
+		return nil
+	}
+	return r.plugins
+}
+
+// Rules asks every plugin about the resources and returns one rule per
+// plugin, in the order of their names. A plugin that fails, times out or
+// answers with an unknown verdict keeps the resources concerned unless
+// on_failure is ignore.
+func (r *Runner) Rules(ctx context.Context, resources []domain.Resource) ([]domain.Rule, error) {
+	if r.Empty() {
+		return nil, nil
+	}
+
+	rules := make([]domain.Rule, 0, len(r.plugins))
+	for _, p := range r.plugins {
+		decisions, err := r.evaluate(ctx, p, resources)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, func(res domain.Resource) domain.Decision {
+			return decisions[res.Key()]
+		})
+	}
+
+	if r.cache != nil {
+		if err := r.cache.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+// evaluate returns the decisions of one plugin keyed by domain.Resource.Key,
+// from the cache where possible and in batches otherwise.
+func (r *Runner) evaluate(ctx context.Context, p Plugin, resources []domain.Resource) (map[string]domain.Decision, error) {
+	decisions := make(map[string]domain.Decision)
+	now := r.now()
+
+	var pending []domain.Resource
+	for _, res := range resources {
+		if r.cache != nil {
+			if v, ok := r.cache.Get(cacheKey(p, res), now); ok {
+				decisions[res.Key()] = r.decision(p, v)
+				continue
+			}
+		}
+		pending = append(pending, res)
+	}
+
+	for batch := range slices.Chunk(pending, r.batchSize) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		verdicts, err := r.call(ctx, p, batch)
+		if err != nil {
+			if r.onFailure == FailKeep {
+				for _, res := range batch {
+					decisions[res.Key()] = domain.Decision{
+						Verdict: domain.VerdictKeep,
+						Reason:  fmt.Sprintf("analyzer %s failed: %v", p.Name, err),
+					}
+				}
+			}
+			continue
+		}
+
+		for _, res := range batch {
+			v, ok := verdicts[res.Key()]
+			if !ok {
+				v = Verdict{Kind: res.Kind, ID: res.ID, Verdict: Default}
+			}
+			decisions[res.Key()] = r.decision(p, v)
+			if r.cache != nil && validVerdict(v.Verdict) {
+				r.cache.Put(cacheKey(p, res), v, now)
+			}
+		}
+	}
+
+	return decisions, nil
+}
+
+// decision turns a plugin's verdict into the decision of its rule.
+func (r *Runner) decision(p Plugin, v Verdict) domain.Decision {
+	reason := "analyzer " + p.Name
+	if v.Reason != "" {
+		reason += ": " + v.Reason
+	}
+
+	switch v.Verdict {
+	case Delete:
+		return domain.Decision{Verdict: domain.VerdictDelete, Reason: reason}
+	case Keep:
+		return domain.Decision{Verdict: domain.VerdictKeep, Reason: reason}
+	case Default, "":
+		return domain.Decision{}
+	}
+
+	if r.onFailure == FailIgnore {
+		return domain.Decision{}
+	}
+	return domain.Decision{
+		Verdict: domain.VerdictKeep,
+		Reason:  fmt.Sprintf("analyzer %s returned %q, want delete, keep or default", p.Name, v.Verdict),
+	}
+}
+
+// call runs the plugin on one batch with the request on stdin and returns
+// its verdicts keyed by domain.Resource.Key.
+func (r *Runner) call(ctx context.Context, p Plugin, batch []domain.Resource) (map[string]Verdict, error) {
+	req := Request{Version: Version, Analyzer: p.Name, Resources: make([]hooks.ResourcePayload, 0, len(batch))}
+	for _, res := range batch {
+		req.Resources = append(req.Resources, hooks.NewResourcePayload(res))
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	//nolint:gosec // Running the plugins of the analyzers directory is the whole point.
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(body)
+	// Don't wait for grandchildren holding the output pipes after a timeout.
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("timed out after %s", r.timeout)
+	}
+	if err != nil {
+		if msg := firstLine(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	verdicts := make(map[string]Verdict, len(resp.Verdicts))
+	for _, v := range resp.Verdicts {
+		verdicts[domain.Resource{Kind: v.Kind, ID: v.ID}.Key()] = v
+	}
+
+	return verdicts, nil
+}
+
+func validVerdict(v string) bool {
+	return v == Delete || v == Keep || v == Default
+}
+
+// cacheKey identifies a verdict: the plugin and its version, the resource
+// and what a plugin may decide on: its name, labels, creation time and the
+// state of a container or the tags of an image.
+func cacheKey(p Plugin, res domain.Resource) string {
+	var state any
+	switch obj := res.Object.(type) {
+	case *container.Summary:
+		state = obj.State
+	case *image.Summary:
+		state = obj.RepoTags
+	}
+
+	data, _ := json.Marshal(struct {
+		Name    string            `json:"name"`
+		Created time.Time         `json:"created"`
+		Labels  map[string]string `json:"labels"`
+		State   any               `json:"state,omitempty"`
+	}{res.Name, res.Created, res.Labels, state})
+	sum := sha256.Sum256(data)
+
+	return p.Name + "@" + p.version + "/" + res.Key() + "#" + hex.EncodeToString(sum[:8])
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,243 @@
+package extern
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// plugin writes an analyzer script into dir.
+func plugin(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell analyzers are not tested on windows")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testVolume(name string, labels map[string]string) domain.Resource {
+	return domain.VolumeResource(&volume.Volume{Name: name, Labels: labels})
+}
+
+func TestRules(t *testing.T) {
+	keepPgdata := `cat >/dev/null; echo '{"verdicts":[{"kind":"volume","id":"pgdata","verdict":"keep","reason":"holds production data"}]}'`
+
+	tests := []struct {
+		name      string
+		body      string
+		onFailure string
+		want      map[string]domain.Decision
+	}{
+		{
+			name: "verdicts",
+			body: keepPgdata,
+			want: map[string]domain.Decision{
+				"pgdata":  {Verdict: domain.VerdictKeep, Reason: "analyzer catalog: holds production data"},
+				"scratch": {},
+			},
+		},
+		{
+			name: "delete",
+			body: `cat >/dev/null; echo '{"verdicts":[{"kind":"volume","id":"scratch","verdict":"delete"}]}'`,
+			want: map[string]domain.Decision{
+				"pgdata":  {},
+				"scratch": {Verdict: domain.VerdictDelete, Reason: "analyzer catalog"},
+			},
+		},
+		{
+			name: "failure keeps",
+			body: "echo 'catalog unreachable' >&2; exit 2",
+			want: map[string]domain.Decision{
+				"pgdata":  {Verdict: domain.VerdictKeep, Reason: "analyzer catalog failed: exit status 2: catalog unreachable"},
+				"scratch": {Verdict: domain.VerdictKeep, Reason: "analyzer catalog failed: exit status 2: catalog unreachable"},
+			},
+		},
+		{
+			name:      "failure ignored",
+			body:      "exit 1",
+			onFailure: FailIgnore,
+			want:      map[string]domain.Decision{"pgdata": {}, "scratch": {}},
+		},
+		{
+			name: "timeout keeps",
+			body: "exec sleep 5",
+			want: map[string]domain.Decision{
+				"pgdata": {Verdict: domain.VerdictKeep, Reason: "analyzer catalog failed: timed out after 200ms"},
+			},
+		},
+		{
+			name: "invalid response keeps",
+			body: "echo not json",
+			want: map[string]domain.Decision{"scratch": {Verdict: domain.VerdictKeep, Reason: "analyzer catalog failed: invalid response: invalid character 'o' in literal null (expecting 'u')"}},
+		},
+		{
+			name: "unknown verdict keeps",
+			body: `cat >/dev/null; echo '{"verdicts":[{"kind":"volume","id":"pgdata","verdict":"maybe"}]}'`,
+			want: map[string]domain.Decision{
+				"pgdata": {Verdict: domain.VerdictKeep, Reason: `analyzer catalog returned "maybe", want delete, keep or default`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			plugin(t, dir, "catalog.sh", tt.body)
+
+			r, err := New(Config{Dir: dir, Timeout: 200 * time.Millisecond, CacheTTL: -1, OnFailure: tt.onFailure})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := r.Rules(context.Background(), []domain.Resource{testVolume("pgdata", nil), testVolume("scratch", nil)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != 1 {
+				t.Fatalf("expected one rule, got %d", len(rules))
+			}
+
+			for name, want := range tt.want {
+				if got := rules[0](testVolume(name, nil)); got != want {
+					t.Errorf("%s: expected %+v, got %+v", name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRulesRequest(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "requests")
+	plugin(t, dir, "catalog", `cat >>`+out+`; echo '{"verdicts":[]}'`)
+
+	r, err := New(Config{Dir: dir, BatchSize: 2, CacheTTL: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := []domain.Resource{
+		testVolume("a", map[string]string{"owner": "team-a"}),
+		testVolume("b", nil),
+		testVolume("c", nil),
+	}
+	if _, err := r.Rules(context.Background(), resources); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []Request
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			t.Fatalf("invalid request: %v", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if len(requests) != 2 || len(requests[0].Resources) != 2 || len(requests[1].Resources) != 1 {
+		t.Fatalf("expected batches of 2 and 1, got %+v", requests)
+	}
+	if req := requests[0]; req.Version != Version || req.Analyzer != "catalog" || req.Resources[0].Labels["owner"] != "team-a" {
+		t.Errorf("unexpected request %+v", req)
+	}
+}
+
+func TestRulesCache(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	plugin(t, dir, "catalog", `cat >/dev/null; echo x >>`+calls+`; echo '{"verdicts":[{"kind":"volume","id":"pgdata","verdict":"keep"}]}'`)
+	cfg := Config{Dir: dir, Cache: filepath.Join(t.TempDir(), "analyzers.json")}
+
+	run := func(labels map[string]string) domain.Decision {
+		t.Helper()
+		r, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules, err := r.Rules(context.Background(), []domain.Resource{testVolume("pgdata", labels)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rules[0](testVolume("pgdata", labels))
+	}
+	count := func() int {
+		data, _ := os.ReadFile(calls)
+		return strings.Count(string(data), "x")
+	}
+
+	want := domain.Decision{Verdict: domain.VerdictKeep, Reason: "analyzer catalog"}
+	for i, labels := range []map[string]string{nil, nil, {"owner": "team-a"}} {
+		if got := run(labels); got != want {
+			t.Errorf("run %d: expected %+v, got %+v", i+1, want, got)
+		}
+	}
+
+	// The second run is answered from the cache; changed labels ask again.
+	if got := count(); got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+
+	// A container that stopped since is asked about again.
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range []string{"running", "running", "exited"} {
+		c := domain.ContainerResource(&container.Summary{ID: "c1", Names: []string{"/web"}, State: state})
+		if _, err := r.Rules(context.Background(), []domain.Resource{c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := count(); got != 4 {
+		t.Errorf("expected 4 calls, got %d", got)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	plugin(t, dir, "b-catalog.sh", "exit 0")
+	plugin(t, dir, "a-owners", "exit 0")
+	plugin(t, dir, ".hidden", "exit 0")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "a-owners,b-catalog" {
+		t.Errorf("expected a-owners,b-catalog, got %v", names)
+	}
+
+	if plugins, err := Discover(filepath.Join(dir, "missing")); err != nil || len(plugins) != 0 {
+		t.Errorf("expected no plugins for a missing directory, got %v, %v", plugins, err)
+	}
+}
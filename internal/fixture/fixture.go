@@ -0,0 +1,385 @@
+// Package fixture describes hosts in YAML with the verdicts expected for
+// their resources, and checks them against the analysis and policy rules.
+package fixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/snapshot"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is one scenario: a host and the verdicts expected for it.
+type Fixture struct {
+	Name string `yaml:"name"`
+	// Path is the file the fixture was loaded from.
+	Path    string        `yaml:"-"`
+	Options Options       `yaml:"options"`
+	Host    Host          `yaml:"host"`
+	Expect  []Expectation `yaml:"expect"`
+}
+
+// Options override the flags and configuration the analysis runs with.
+type Options struct {
+	Engine      domain.Engine      `yaml:"engine"`
+	ExcludeTags []string           `yaml:"exclude_tags"`
+	Volumes     domain.VolumeScope `yaml:"volumes"`
+}
+
+// Host is the resources of the scenario. Times are dates ("2024-05-01",
+// RFC 3339) or durations back from now ("36h", "7d"); resources without a
+// creation time were created now. IDs are derived from the names when left
+// out.
+type Host struct {
+	Images     []Image     `yaml:"images"`
+	Containers []Container `yaml:"containers"`
+	Volumes    []Volume    `yaml:"volumes"`
+	Networks   []Network   `yaml:"networks"`
+}
+
+// Image is an image of the host.
+type Image struct {
+	ID      string            `yaml:"id"`
+	Tags    []string          `yaml:"tags"`
+	Labels  map[string]string `yaml:"labels"`
+	Created string            `yaml:"created"`
+	Size    domain.ByteSize   `yaml:"size"`
+}
+
+// Container is a container of the host. Image is the tag or ID of one of
+// the images; State defaults to exited.
+type Container struct {
+	ID       string            `yaml:"id"`
+	Name     string            `yaml:"name"`
+	Image    string            `yaml:"image"`
+	State    string            `yaml:"state"`
+	Labels   map[string]string `yaml:"labels"`
+	Created  string            `yaml:"created"`
+	Finished string            `yaml:"finished"`
+	Size     domain.ByteSize   `yaml:"size"`
+	Volumes  []string          `yaml:"volumes"`
+	Networks []string          `yaml:"networks"`
+}
+
+// Volume is a volume of the host.
+type Volume struct {
+	Name    string            `yaml:"name"`
+	Labels  map[string]string `yaml:"labels"`
+	Created string            `yaml:"created"`
+	Size    domain.ByteSize   `yaml:"size"`
+}
+
+// Network is a network of the host.
+type Network struct {
+	ID      string            `yaml:"id"`
+	Name    string            `yaml:"name"`
+	Labels  map[string]string `yaml:"labels"`
+	Created string            `yaml:"created"`
+}
+
+// Expectation is the verdict expected for the resources matching Resource
+// by ID prefix, name or image tag, as dockr explain matches them.
+type Expectation struct {
+	Resource string              `yaml:"resource"`
+	Kind     domain.ResourceKind `yaml:"kind"`
+	// Verdict is delete (reported as unused) or keep.
+	Verdict string `yaml:"verdict"`
+}
+
+// Verdicts of an expectation.
+const (
+	Delete = "delete"
+	Keep   = "keep"
+)
+
+// Load reads the fixtures in the files and, recursively, the *.yaml and
+// *.yml files in the directories at paths.
+func Load(paths []string) ([]*Fixture, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := filepath.Ext(p); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures: %w", err)
+		}
+	}
+
+	fixtures := make([]*Fixture, 0, len(files))
+	for _, file := range files {
+		f, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, f)
+	}
+
+	return fixtures, nil
+}
+
+// LoadFile reads one fixture.
+func LoadFile(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	f := &Fixture{Path: path}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	for _, e := range f.Expect {
+		if e.Verdict != Delete && e.Verdict != Keep {
+			return nil, fmt.Errorf("fixture %s: verdict of %s must be delete or keep, got %q", path, e.Resource, e.Verdict)
+		}
+		if e.Kind != "" && !slices.Contains(domain.Kinds, e.Kind) {
+			return nil, fmt.Errorf("fixture %s: unknown kind %q", path, e.Kind)
+		}
+	}
+
+	return f, nil
+}
+
+// Snapshot builds the host as a snapshot, with relative times resolved
+// against now.
+func (f *Fixture) Snapshot(now time.Time) (*snapshot.Snapshot, error) {
+	engine := f.Options.Engine
+	if engine == "" || engine == domain.EngineAuto {
+		engine = domain.EngineDocker
+	}
+
+	s := &snapshot.Snapshot{
+		Version:    snapshot.Version,
+		CapturedAt: now,
+		Host:       "fixture://" + f.Name,
+		Engine:     engine,
+	}
+
+	imageIDs := make(map[string]string)
+	for i, img := range f.Host.Images {
+		created, err := parseCreated(img.Created, now)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+
+		id := img.ID
+		if id == "" {
+			id = "sha256:" + derivedID(append([]string{"image", strconv.Itoa(i)}, img.Tags...)...)
+		}
+		imageIDs[id] = id
+		for _, tag := range img.Tags {
+			imageIDs[tag] = id
+		}
+
+		summary := image.Summary{
+			ID:       id,
+			RepoTags: img.Tags,
+			Labels:   img.Labels,
+			Created:  created.Unix(),
+			Size:     int64(img.Size),
+		}
+		inspect := image.InspectResponse{
+			ID:       id,
+			RepoTags: img.Tags,
+			Created:  created.Format(time.RFC3339Nano),
+			Size:     int64(img.Size),
+			Metadata: image.Metadata{LastTagTime: created},
+		}
+		s.Images = append(s.Images, snapshot.Image{Summary: summary, Inspect: inspect})
+	}
+
+	networkIDs := make(map[string]string)
+	for _, n := range f.Host.Networks {
+		created, err := parseCreated(n.Created, now)
+		if err != nil {
+			return nil, fmt.Errorf("network %s: %w", n.Name, err)
+		}
+
+		id := n.ID
+		if id == "" {
+			id = derivedID("network", n.Name)
+		}
+		networkIDs[n.Name] = id
+
+		s.Networks = append(s.Networks, network.Inspect{
+			ID:      id,
+			Name:    n.Name,
+			Driver:  "bridge",
+			Scope:   "local",
+			Labels:  n.Labels,
+			Created: created,
+		})
+	}
+
+	for _, v := range f.Host.Volumes {
+		created, err := parseCreated(v.Created, now)
+		if err != nil {
+			return nil, fmt.Errorf("volume %s: %w", v.Name, err)
+		}
+
+		vol := volume.Volume{
+			Name:      v.Name,
+			Driver:    "local",
+			Scope:     "local",
+			Labels:    v.Labels,
+			UsageData: &volume.UsageData{Size: int64(v.Size), RefCount: 0},
+		}
+		vol.CreatedAt = created.Format(time.RFC3339)
+		s.Volumes = append(s.Volumes, vol)
+	}
+
+	for _, c := range f.Host.Containers {
+		created, err := parseCreated(c.Created, now)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", c.Name, err)
+		}
+		finished, err := parseTime(c.Finished, now)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", c.Name, err)
+		}
+
+		id := c.ID
+		if id == "" {
+			id = derivedID("container", c.Name)
+		}
+		state := c.State
+		if state == "" {
+			state = "exited"
+		}
+		imageID, ok := imageIDs[c.Image]
+		if !ok && c.Image != "" {
+			return nil, fmt.Errorf("container %s: no image %s in the fixture", c.Name, c.Image)
+		}
+
+		summary := container.Summary{
+			ID:      id,
+			Names:   []string{"/" + c.Name},
+			Image:   c.Image,
+			ImageID: imageID,
+			State:   state,
+			Status:  state,
+			Labels:  c.Labels,
+			Created: created.Unix(),
+			SizeRw:  int64(c.Size),
+		}
+
+		for _, name := range c.Volumes {
+			summary.Mounts = append(summary.Mounts, container.MountPoint{Type: "volume", Name: name})
+			for i := range s.Volumes {
+				if s.Volumes[i].Name == name {
+					s.Volumes[i].UsageData.RefCount++
+				}
+			}
+		}
+
+		if len(c.Networks) > 0 {
+			summary.NetworkSettings = &container.NetworkSettingsSummary{Networks: make(map[string]*network.EndpointSettings)}
+		}
+		for _, name := range c.Networks {
+			netID, ok := networkIDs[name]
+			if !ok {
+				return nil, fmt.Errorf("container %s: no network %s in the fixture", c.Name, name)
+			}
+			summary.NetworkSettings.Networks[name] = &network.EndpointSettings{NetworkID: netID}
+
+			// The engine lists only running containers as attached.
+			if state == "running" {
+				for i := range s.Networks {
+					if s.Networks[i].ID == netID {
+						if s.Networks[i].Containers == nil {
+							s.Networks[i].Containers = make(map[string]network.EndpointResource)
+						}
+						s.Networks[i].Containers[id] = network.EndpointResource{Name: c.Name}
+					}
+				}
+			}
+		}
+
+		for i := range s.Images {
+			if s.Images[i].Summary.ID == imageID {
+				s.Images[i].Summary.Containers++
+			}
+		}
+
+		inspect := container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{
+				ID:      id,
+				Name:    "/" + c.Name,
+				Created: created.Format(time.RFC3339Nano),
+				Image:   imageID,
+				State:   &container.State{Status: state, Running: state == "running"},
+			},
+			Config: &container.Config{Image: c.Image, Labels: c.Labels},
+		}
+		if !finished.IsZero() {
+			inspect.State.FinishedAt = finished.Format(time.RFC3339Nano)
+		}
+
+		s.Containers = append(s.Containers, snapshot.Container{Summary: summary, Inspect: inspect})
+	}
+
+	return s, nil
+}
+
+// parseCreated resolves a creation time; empty is now.
+func parseCreated(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return now, nil
+	}
+	return audit.ParseSince(s, now)
+}
+
+// parseTime resolves a fixture time; empty is the zero time.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return audit.ParseSince(s, now)
+}
+
+// derivedID returns a stable 64-hex ID for a resource without one.
+func derivedID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
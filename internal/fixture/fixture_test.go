@@ -0,0 +1,144 @@
+package fixture
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/policy"
+)
+
+const ciHost = `
+name: ci runner
+host:
+  images:
+    - tags: [registry.x.io/app:1]
+      created: 40d
+    - tags: [registry.x.io/app:release-1]
+      created: 40d
+    - tags: [registry.x.io/app:2]
+      created: 2d
+    - tags: [postgres:16]
+  containers:
+    - name: db
+      image: postgres:16
+      state: running
+      volumes: [pgdata]
+      networks: [backend]
+    - name: job-1
+      image: registry.x.io/app:2
+      finished: 3h
+      labels: {ci.job: "1"}
+  volumes:
+    - name: pgdata
+    - name: scratch
+      created: 2d
+  networks:
+    - name: backend
+    - name: job-net
+expect:
+  - {resource: "registry.x.io/app:1", verdict: delete}
+  - {resource: "registry.x.io/app:release-1", verdict: keep}
+  - {resource: "registry.x.io/app:2", verdict: keep}
+  - {resource: job-1, verdict: delete}
+  - {resource: pgdata, verdict: keep}
+  - {resource: backend, verdict: keep}
+  - {resource: job-net, verdict: delete}
+  - {resource: postgres:16, verdict: delete}
+  - {resource: missing, verdict: keep}
+`
+
+func writeFixture(t *testing.T, dir, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "ci.yaml", ciHost)
+	writeFixture(t, dir, "notes.txt", "not a fixture")
+
+	fixtures, err := Load([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 1 || fixtures[0].Name != "ci runner" {
+		t.Fatalf("loaded %+v", fixtures)
+	}
+
+	pol, err := policy.Compile(policy.Config{Rules: []policy.RuleConfig{{
+		Name: "registry-x",
+		Kind: domain.KindImage,
+		Expr: `"registry.x.io" in registries ? (age > days(30) && none(tags, # matches ":release-") ? delete : keep) : default`,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Run(context.Background(), fixtures[0], Base{Policy: pol}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failed []string
+	for _, r := range results {
+		if !r.Passed() {
+			failed = append(failed, r.Resource)
+		}
+	}
+	// postgres:16 is used by the running db container; missing does not exist.
+	if strings.Join(failed, ",") != "postgres:16,missing" {
+		t.Errorf("failed = %v, want postgres:16 and missing", failed)
+	}
+
+	for _, r := range results {
+		switch r.Resource {
+		case "postgres:16":
+			if r.Got != Keep || r.Explanation == nil || !strings.Contains(strings.Join(r.Explanation.Reasons, "; "), "used by 1 container(s): db") {
+				t.Errorf("postgres:16 = %+v", r)
+			}
+		case "registry.x.io/app:1":
+			if !strings.Contains(strings.Join(r.Explanation.Reasons, "; "), "policy registry-x") {
+				t.Errorf("app:1 reasons = %v, want the policy named", r.Explanation.Reasons)
+			}
+		case "missing":
+			if r.Error == "" {
+				t.Errorf("missing = %+v, want an error", r)
+			}
+		}
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"verdict":       "expect: [{resource: a, verdict: remove}]",
+		"unknown field": "host: {pods: []}",
+		"kind":          "expect: [{resource: a, kind: pod, verdict: keep}]",
+	}
+	for name, data := range tests {
+		path := writeFixture(t, dir, strings.ReplaceAll(name, " ", "-")+".yaml", data)
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSnapshotReferences(t *testing.T) {
+	f := &Fixture{Host: Host{
+		Containers: []Container{{Name: "web", Image: "nope:1"}},
+	}}
+	if _, err := f.Snapshot(time.Now()); err == nil {
+		t.Error("expected an error for a container of an unknown image")
+	}
+}
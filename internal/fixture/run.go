@@ -0,0 +1,110 @@
+package fixture
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/policy"
+	"github.com/DobryySoul/dockr/internal/snapshot"
+)
+
+// Base is what the fixtures run with unless their options override it: the
+// flags and configuration of a cleanup.
+type Base struct {
+	ExcludeTags []string
+	Volumes     domain.VolumePolicy
+	Policy      *policy.Policy
+}
+
+// Result is the outcome of one expectation.
+type Result struct {
+	Fixture  string `json:"fixture"`
+	Path     string `json:"path"`
+	Resource string `json:"resource"`
+	Kind     string `json:"kind,omitempty"`
+	Want     string `json:"want"`
+	Got      string `json:"got,omitempty"`
+	// Explanation is why the analysis decided as it did.
+	Explanation *docker.Explanation `json:"explanation,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// Passed reports whether the resource got the expected verdict.
+func (r Result) Passed() bool {
+	return r.Error == "" && r.Got == r.Want
+}
+
+// Run analyzes the host of the fixture as dockr analyzes a live one, with
+// the policy rules, and checks every expectation.
+func Run(ctx context.Context, f *Fixture, base Base, now time.Time) ([]Result, error) {
+	s, err := f.Snapshot(now)
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", f.Path, err)
+	}
+
+	dc := &docker.DockerClient{Cli: snapshot.NewAPI(s), Engine: s.Engine}
+
+	excludeTags := base.ExcludeTags
+	if f.Options.ExcludeTags != nil {
+		excludeTags = f.Options.ExcludeTags
+	}
+	volumes := base.Volumes
+	if f.Options.Volumes != "" {
+		if volumes.Scope, err = domain.ParseVolumeScope(string(f.Options.Volumes)); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", f.Path, err)
+		}
+	}
+
+	var rules []domain.Rule
+	if !base.Policy.Empty() {
+		facts, err := dc.PolicyFacts(ctx, base.Policy.NeedsLastUsed())
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", f.Path, err)
+		}
+		rules = append(rules, base.Policy.Rule(facts))
+	}
+
+	results := make([]Result, 0, len(f.Expect))
+	for _, e := range f.Expect {
+		r := Result{Fixture: f.Name, Path: f.Path, Resource: e.Resource, Kind: string(e.Kind), Want: e.Verdict}
+
+		explanations, err := dc.Explain(ctx, e.Resource, excludeTags, volumes, rules)
+		if err != nil {
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+
+		var matched []docker.Explanation
+		for _, ex := range explanations {
+			if e.Kind == "" || ex.Resource.Kind == e.Kind {
+				matched = append(matched, ex)
+			}
+		}
+
+		switch len(matched) {
+		case 0:
+			r.Error = fmt.Sprintf("no %s matches %q", e.Kind, e.Resource)
+		case 1:
+			r.Explanation = &matched[0]
+			r.Got = Keep
+			if matched[0].Unused {
+				r.Got = Delete
+			}
+		default:
+			var names []string
+			for _, ex := range matched {
+				names = append(names, fmt.Sprintf("%s %s", ex.Kind, ex.Name))
+			}
+			r.Error = fmt.Sprintf("%q is ambiguous (%s); set kind or use an ID", e.Resource, strings.Join(names, ", "))
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
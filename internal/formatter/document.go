@@ -0,0 +1,309 @@
+package formatter
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/analyzer"
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+// Output formats of a report.
+const (
+	OutputText     = "text"
+	OutputMarkdown = "markdown"
+	OutputHTML     = "html"
+)
+
+// Document is a report meant to be posted somewhere: the unused resources
+// PrintReport prints and, for dockr report, the disk usage of the host.
+type Document struct {
+	Host        string
+	Engine      string
+	GeneratedAt time.Time
+	// HostReport is only set by dockr report.
+	HostReport *docker.HostReport
+	Sections   []Section
+	Count      int
+	Size       int64
+}
+
+// Section is the table of one resource type, with the columns of PrintReport.
+type Section struct {
+	Title string
+	// Columns name the cells of the rows; the size comes after them.
+	Columns []string
+	// HasSize adds a size column, drawn as a bar relative to the largest row
+	// in HTML.
+	HasSize bool
+	Rows    []Row
+	Size    int64
+	// Percent is the share of the section in the reclaimable space.
+	Percent int
+}
+
+// Row is one resource of a section.
+type Row struct {
+	Cells   []string
+	Size    int64
+	Percent int
+}
+
+// NewDocument builds a report of the unused resources.
+func NewDocument(res *domain.UnusedResources, host, engine string, generatedAt time.Time, hostReport *docker.HostReport) *Document {
+	d := &Document{Host: host, Engine: engine, GeneratedAt: generatedAt, HostReport: hostReport}
+
+	images := Section{Title: "Images", Columns: []string{"ID", "TAG"}, HasSize: true}
+	for _, img := range res.Images {
+		tags := strings.Join(img.RepoTags, ", ")
+		if tags == "" {
+			tags = "<none>"
+		}
+		images.add(img.Size, TruncateID(img.ID), tags)
+	}
+
+	containers := Section{Title: "Containers", Columns: []string{"ID", "NAME", "STATE", "IMAGE"}, HasSize: true}
+	for _, c := range res.Containers {
+		containers.add(c.SizeRw, TruncateID(c.ID), domain.ContainerResource(c).Name, c.State, c.Image)
+	}
+
+	volumes := Section{Title: "Volumes", Columns: []string{"DRIVER", "NAME", "CLASS"}, HasSize: true}
+	for _, v := range res.Volumes {
+		var size int64
+		if v.UsageData != nil {
+			size = v.UsageData.Size
+		}
+		volumes.add(size, v.Driver, v.Name, string(analyzer.ClassifyVolume(v)))
+	}
+
+	networks := Section{Title: "Networks", Columns: []string{"ID", "NAME", "DRIVER", "CONTAINERS"}}
+	for _, n := range res.Networks {
+		users := "-"
+		if len(n.Containers) > 0 {
+			names := make([]string, 0, len(n.Containers))
+			for _, ep := range n.Containers {
+				names = append(names, ep.Name)
+			}
+			slices.Sort(names)
+			users = strings.Join(names, ", ")
+		}
+		networks.add(0, TruncateID(n.ID), n.Name, n.Driver, users)
+	}
+
+	for _, s := range []Section{images, containers, volumes, networks} {
+		if len(s.Rows) == 0 {
+			continue
+		}
+		s.scale()
+		d.Sections = append(d.Sections, s)
+		d.Count += len(s.Rows)
+		d.Size += s.Size
+	}
+	for i := range d.Sections {
+		d.Sections[i].Percent = percent(d.Sections[i].Size, d.Size)
+	}
+
+	return d
+}
+
+func (s *Section) add(size int64, cells ...string) {
+	s.Rows = append(s.Rows, Row{Cells: cells, Size: size})
+	s.Size += size
+}
+
+// scale sizes the bars of the rows relative to the largest one.
+func (s *Section) scale() {
+	var largest int64
+	for _, r := range s.Rows {
+		largest = max(largest, r.Size)
+	}
+	for i := range s.Rows {
+		s.Rows[i].Percent = percent(s.Rows[i].Size, largest)
+	}
+}
+
+func percent(n, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	return int(n * 100 / total)
+}
+
+// WriteDocument writes the document as markdown or as a self-contained HTML
+// page.
+func WriteDocument(w io.Writer, d *Document, output string) error {
+	var err error
+	switch output {
+	case OutputMarkdown:
+		err = markdownTemplate.Execute(w, d)
+	case OutputHTML:
+		err = htmlTemplate.Execute(w, d)
+	default:
+		return fmt.Errorf("unknown output %q, want %s, %s or %s", output, OutputText, OutputMarkdown, OutputHTML)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s report: %w", output, err)
+	}
+	return nil
+}
+
+var documentFuncs = map[string]any{
+	"human": domain.HumanSize,
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	// cell escapes a markdown table cell.
+	"cell": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ", "<", "&lt;").Replace(s)
+	},
+	"reclaimable": func(usage []docker.TypeUsage) int64 {
+		var n int64
+		for _, u := range usage {
+			n += u.Reclaimable
+		}
+		return n
+	},
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(documentFuncs).Parse(`# dockr report: {{.Host}}
+
+Generated {{date .GeneratedAt}} on {{.Engine}}{{with .HostReport}}{{with .Version}} {{.}}{{end}}{{with .OS}}, {{.}}{{end}}{{end}}.
+{{- with .HostReport}}
+
+## Disk usage
+
+| Type | Total | Active | Size | Reclaimable |
+|------|------:|-------:|-----:|------------:|
+{{- range .Usage}}
+| {{.Type}} | {{.Total}} | {{.Active}} | {{human .Size}} | {{human .Reclaimable}} |
+{{- end}}
+{{- end}}
+
+## Unused resources
+{{if .Sections}}
+| Type | Count | Size |
+|------|------:|-----:|
+{{- range .Sections}}
+| {{.Title}} | {{len .Rows}} | {{if .HasSize}}{{human .Size}}{{else}}-{{end}} |
+{{- end}}
+| **Total** | **{{.Count}}** | **{{human .Size}}** |
+{{- range .Sections}}
+
+### {{.Title}} ({{len .Rows}})
+
+|{{range .Columns}} {{.}} |{{end}}{{if .HasSize}} SIZE |{{end}}
+|{{range .Columns}}------|{{end}}{{if .HasSize}}-----:|{{end}}
+{{- $sized := .HasSize}}
+{{- range .Rows}}
+|{{range .Cells}} {{cell .}} |{{end}}{{if $sized}} {{human .Size}} |{{end}}
+{{- end}}
+{{- end}}
+{{else}}
+Nothing to delete - system is clean!
+{{end -}}
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(documentFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>dockr report: {{.Host}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 72rem; padding: 0 1rem; color: #1f2328; }
+h1 { margin-bottom: .25rem; }
+.meta { color: #656d76; margin-top: 0; }
+.summary { display: flex; gap: 1rem; flex-wrap: wrap; margin: 1.5rem 0; }
+.card { border: 1px solid #d0d7de; border-radius: 6px; padding: .75rem 1rem; min-width: 10rem; }
+.card .value { font-size: 1.5rem; font-weight: 600; }
+.card .label { color: #656d76; font-size: .875rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; font-size: .875rem; }
+th, td { border-bottom: 1px solid #d0d7de; padding: .4rem .6rem; text-align: left; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; white-space: nowrap; }
+th[data-order="asc"]::after { content: " ▲"; }
+th[data-order="desc"]::after { content: " ▼"; }
+td.num { text-align: right; white-space: nowrap; }
+td.bar { width: 30%; }
+.bar div { background: #54aeff; height: .75rem; border-radius: 2px; min-width: 1px; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+</style>
+</head>
+<body>
+<h1>dockr report: {{.Host}}</h1>
+<p class="meta">Generated {{date .GeneratedAt}} on {{.Engine}}{{with .HostReport}}{{with .Version}} {{.}}{{end}}{{with .OS}}, {{.}}{{end}}{{with .Kernel}} (kernel {{.}}){{end}}{{with .StorageDriver}}, storage driver {{.}}{{end}}{{end}}</p>
+
+<div class="summary">
+<div class="card"><div class="value">{{.Count}}</div><div class="label">unused resources</div></div>
+<div class="card"><div class="value">{{human .Size}}</div><div class="label">freed by a cleanup</div></div>
+{{- with .HostReport}}
+<div class="card"><div class="value">{{human (reclaimable .Usage)}}</div><div class="label">reclaimable on the host</div></div>
+{{- end}}
+{{- range .Sections}}
+<div class="card"><div class="value">{{len .Rows}}</div><div class="label">{{.Title}}{{if .HasSize}}, {{human .Size}}{{end}}</div></div>
+{{- end}}
+</div>
+{{- if .Sections}}
+
+<h2>Reclaimable space by type</h2>
+<table class="sortable">
+<thead><tr><th>Type</th><th>Count</th><th>Size</th><th></th></tr></thead>
+<tbody>
+{{- range .Sections}}{{if .HasSize}}
+<tr><td>{{.Title}}</td><td class="num" data-sort="{{len .Rows}}">{{len .Rows}}</td><td class="num" data-sort="{{.Size}}">{{human .Size}}</td><td class="bar" data-sort="{{.Size}}"><div style="width: {{.Percent}}%"></div></td></tr>
+{{- end}}{{end}}
+</tbody>
+</table>
+{{- end}}
+{{- with .HostReport}}
+
+<h2>Disk usage</h2>
+<table class="sortable">
+<thead><tr><th>Type</th><th>Total</th><th>Active</th><th>Size</th><th>Reclaimable</th></tr></thead>
+<tbody>
+{{- range .Usage}}
+<tr><td>{{.Type}}</td><td class="num" data-sort="{{.Total}}">{{.Total}}</td><td class="num" data-sort="{{.Active}}">{{.Active}}</td><td class="num" data-sort="{{.Size}}">{{human .Size}}</td><td class="num" data-sort="{{.Reclaimable}}">{{human .Reclaimable}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- range .Sections}}
+
+<h2>{{.Title}} ({{len .Rows}})</h2>
+<table class="sortable">
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}{{if .HasSize}}<th>SIZE</th><th></th>{{end}}</tr></thead>
+<tbody>
+{{- $sized := .HasSize}}
+{{- range .Rows}}
+<tr>{{range .Cells}}<td><code>{{.}}</code></td>{{end}}{{if $sized}}<td class="num" data-sort="{{.Size}}">{{human .Size}}</td><td class="bar" data-sort="{{.Size}}"><div style="width: {{.Percent}}%"></div></td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<p>Nothing to delete - system is clean!</p>
+{{- end}}
+
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0], i = th.cellIndex;
+    var asc = th.dataset.order !== "asc";
+    table.querySelectorAll("th").forEach(function (h) { delete h.dataset.order; });
+    th.dataset.order = asc ? "asc" : "desc";
+    var key = function (row) {
+      var cell = row.cells[i];
+      return cell.dataset.sort !== undefined ? parseFloat(cell.dataset.sort) : cell.textContent;
+    };
+    Array.from(body.rows).sort(function (a, b) {
+      var x = key(a), y = key(b);
+      var c = typeof x === "number" ? x - y : x.localeCompare(y);
+      return asc ? c : -c;
+    }).forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
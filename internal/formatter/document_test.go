@@ -0,0 +1,105 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+)
+
+func testDocument(hostReport *docker.HostReport) *Document {
+	res := &domain.UnusedResources{
+		Images: []*image.Summary{
+			{ID: "sha256:0123456789abcdef", RepoTags: []string{"app:1"}, Size: 3 * 1024 * 1024},
+			{ID: "sha256:fedcba9876543210", Size: 1024 * 1024},
+		},
+		Containers: []*container.Summary{{ID: "c1", Names: []string{"/job|1"}, State: "exited", Image: "app:1", SizeRw: 4 * 1024 * 1024}},
+		Networks:   []*network.Summary{{ID: "n1", Name: "ci_default", Driver: "bridge"}},
+	}
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	return NewDocument(res, "build-01", "docker", at, hostReport)
+}
+
+func TestNewDocument(t *testing.T) {
+	d := testDocument(nil)
+
+	if d.Count != 4 || d.Size != 8*1024*1024 {
+		t.Errorf("expected 4 resources of 8 MB, got %d of %d", d.Count, d.Size)
+	}
+	if len(d.Sections) != 3 {
+		t.Fatalf("expected images, containers and networks, got %+v", d.Sections)
+	}
+
+	images := d.Sections[0]
+	if images.Percent != 50 || images.Rows[0].Percent != 100 || images.Rows[1].Percent != 33 {
+		t.Errorf("unexpected image bars: %+v", images)
+	}
+	if got := strings.Join(images.Rows[1].Cells, ","); got != "sha256:fedcb,<none>" {
+		t.Errorf("unexpected untagged image row %q", got)
+	}
+	if networks := d.Sections[2]; networks.HasSize || strings.Join(networks.Rows[0].Cells, ",") != "n1,ci_default,bridge,-" {
+		t.Errorf("unexpected networks: %+v", networks)
+	}
+}
+
+func TestWriteDocument(t *testing.T) {
+	host := &docker.HostReport{Host: "build-01", Engine: "docker", Version: "28.1.1", OS: "Ubuntu 24.04", Usage: []docker.TypeUsage{
+		{Type: "Images", Total: 3, Active: 1, Size: 9 * 1024 * 1024, Reclaimable: 4 * 1024 * 1024},
+	}}
+
+	var b bytes.Buffer
+	if err := WriteDocument(&b, testDocument(host), OutputMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# dockr report: build-01\n\nGenerated 2026-10-19 09:00 UTC on docker 28.1.1, Ubuntu 24.04.\n",
+		"| Images | 3 | 1 | 9.00 MB | 4.00 MB |\n",
+		"| **Total** | **4** | **8.00 MB** |\n",
+		"| ID | TAG | SIZE |\n|------|------|-----:|\n| sha256:01234 | app:1 | 3.00 MB |\n| sha256:fedcb | &lt;none> | 1.00 MB |\n",
+		`| c1 | job\|1 | exited | app:1 | 4.00 MB |`,
+		"| ID | NAME | DRIVER | CONTAINERS |\n|------|------|------|------|\n| n1 | ci_default | bridge | - |\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("markdown is missing %q:\n%s", want, b.String())
+		}
+	}
+
+	b.Reset()
+	if err := WriteDocument(&b, testDocument(host), OutputHTML); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<table class="sortable">`,
+		`<td><code>&lt;none&gt;</code></td>`,
+		`<td class="num" data-sort="4194304">4.00 MB</td><td class="bar" data-sort="4194304"><div style="width: 100%"></div></td>`,
+		`<div class="value">4.00 MB</div><div class="label">reclaimable on the host</div>`,
+		"<script>",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("html is missing %q", want)
+		}
+	}
+	if strings.Contains(b.String(), "src=") || strings.Contains(b.String(), "<link") {
+		t.Error("html is not self-contained")
+	}
+
+	b.Reset()
+	if err := WriteDocument(&b, NewDocument(&domain.UnusedResources{}, "h", "docker", time.Now(), nil), OutputMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "Nothing to delete - system is clean!") {
+		t.Errorf("unexpected empty report:\n%s", b.String())
+	}
+
+	if err := WriteDocument(&b, testDocument(nil), "pdf"); err == nil {
+		t.Error("expected an error for an unknown output")
+	}
+}
@@ -5,10 +5,23 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/DobryySoul/dockr/internal/analyzer"
+	"github.com/DobryySoul/dockr/internal/archive"
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/docker"
 	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/fixture"
+	"github.com/DobryySoul/dockr/internal/ipam"
+	"github.com/DobryySoul/dockr/internal/logs"
+	"github.com/DobryySoul/dockr/internal/planner"
+	"github.com/DobryySoul/dockr/internal/registry"
+	"github.com/DobryySoul/dockr/internal/snapshot"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
@@ -114,8 +127,8 @@ func printImagesTable(images []*image.Summary) {
 		}
 
 		fmt.Fprintf(w, "%s\t %s\t %.2f MB\n",
-			truncateID(img.ID),
-			truncate(tags, 30),
+			TruncateID(img.ID),
+			Truncate(tags, 30),
 			float64(img.Size)/1024/1024,
 		)
 	}
@@ -133,10 +146,10 @@ func printContainersTable(containers []*container.Summary) {
 		}
 
 		fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %.2f MB\t\n",
-			truncateID(c.ID),
-			truncate(c.Names[0], 20),
+			TruncateID(c.ID),
+			Truncate(c.Names[0], 20),
 			state,
-			truncate(c.Image, 20),
+			Truncate(c.Image, 20),
 			float64(c.SizeRw)/1024/1024,
 		)
 	}
@@ -145,7 +158,7 @@ func printContainersTable(containers []*container.Summary) {
 
 func printVolumesTable(volumes []*volume.Volume) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "DRIVER\t NAME\t SIZE\t")
+	fmt.Fprintln(w, "DRIVER\t NAME\t CLASS\t SIZE\t")
 
 	for _, v := range volumes {
 		size := 0.0
@@ -153,9 +166,10 @@ func printVolumesTable(volumes []*volume.Volume) {
 			size = float64(v.UsageData.Size) / 1024 / 1024
 		}
 
-		fmt.Fprintf(w, "%s\t %s\t %.2f MB\t\n",
-			truncateID(v.Driver),
-			truncate(v.Name, 40),
+		fmt.Fprintf(w, "%s\t %s\t %s\t %.2f MB\t\n",
+			TruncateID(v.Driver),
+			Truncate(v.Name, 40),
+			analyzer.ClassifyVolume(v),
 			size,
 		)
 	}
@@ -164,28 +178,675 @@ func printVolumesTable(volumes []*volume.Volume) {
 
 func printNetworksTable(networks []*network.Summary) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\t NAME\t DRIVER\t")
+	fmt.Fprintln(w, "ID\t NAME\t DRIVER\t CONTAINERS\t")
 
 	for _, n := range networks {
-		fmt.Fprintf(w, "%s\t %s\t %s\t\n",
-			truncateID(n.ID),
-			truncate(n.Name, 20),
-			truncate(n.Driver, 20),
+		// Stopped containers removed together with the network.
+		users := "-"
+		if len(n.Containers) > 0 {
+			names := make([]string, 0, len(n.Containers))
+			for _, ep := range n.Containers {
+				names = append(names, ep.Name)
+			}
+			slices.Sort(names)
+			users = strings.Join(names, ", ")
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %s\t\n",
+			TruncateID(n.ID),
+			Truncate(n.Name, 20),
+			Truncate(n.Driver, 20),
+			Truncate(users, 40),
 		)
 	}
 	w.Flush()
 }
 
-func truncate(s string, max int) string {
-	if len(s) > max {
-		return s[:max-3] + "..."
+// Truncate shortens s to n characters, marking the cut with "..." when
+// there is room for it. It never splits a UTF-8 character.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
 	}
-	return s
+	if n < 4 {
+		return string(runes[:max(n, 0)])
+	}
+	return string(runes[:n-3]) + "..."
 }
 
-func truncateID(id string) string {
+// TruncateID shortens a resource ID to the 12 characters Docker shows by default.
+func TruncateID(id string) string {
 	if len(id) > 12 {
 		return id[:12]
 	}
 	return id
 }
+
+// Age renders how long ago t was, e.g. "3 days ago".
+func Age(t time.Time) string {
+	if t.IsZero() || t.Unix() <= 0 {
+		return "-"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d.Hours()/24), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return plural(int(d.Hours()/24/30), "month") + " ago"
+	default:
+		return plural(int(d.Hours()/24/365), "year") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// PrintHistory prints a table of cleanup runs from the audit log.
+func PrintHistory(runs []*audit.Run) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\t STARTED\t USER\t HOST\t REMOVED\t FAILED\t RECLAIMED\t")
+
+	for _, run := range runs {
+		failed := strconv.Itoa(run.Failed())
+		if run.Failed() > 0 || run.Error != "" {
+			failed = color.HiRedString(failed)
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %d\t %s\t %.2f MB\t\n",
+			run.ID,
+			run.StartedAt.Local().Format(time.DateTime),
+			Truncate(run.User, 16),
+			Truncate(run.Host, 20),
+			run.Removed(),
+			failed,
+			float64(run.Reclaimed())/1024/1024,
+		)
+	}
+	w.Flush()
+}
+
+// PrintRun prints the details of a single cleanup run and every resource it touched.
+func PrintRun(run *audit.Run) {
+	color.New(color.FgYellow).Printf("\n=== RUN %s ===\n", run.ID)
+	fmt.Printf("Started:     %s\n", run.StartedAt.Local().Format(time.DateTime))
+	fmt.Printf("Finished:    %s\n", run.FinishedAt.Local().Format(time.DateTime))
+	fmt.Printf("User:        %s\n", run.User)
+	fmt.Printf("Host:        %s (%s)\n", run.Host, run.DockerHost)
+	fmt.Printf("Command:     dockr %s\n", strings.Join(run.Args, " "))
+	fmt.Printf("Engine:      %s\n", run.Policy.Engine)
+	if len(run.Policy.ExcludeTags) > 0 {
+		fmt.Printf("Exclude:     %s\n", strings.Join(run.Policy.ExcludeTags, ", "))
+	}
+	if run.Error != "" {
+		ErrorColor.Printf("Error:       %s\n", run.Error)
+	}
+
+	if len(run.Entries) == 0 {
+		return
+	}
+
+	color.New(color.FgGreen).Printf("\nResources (%d):\n", len(run.Entries))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\t ID\t NAME\t SIZE\t RESULT\t")
+
+	for _, e := range run.Entries {
+		result := e.Result
+		if e.Result != audit.ResultRemoved {
+			result = color.HiRedString("%s: %s", e.Result, Truncate(e.Error, 60))
+		}
+
+		id := e.ID
+		if e.Kind != domain.KindVolume {
+			id = TruncateID(strings.TrimPrefix(id, "sha256:"))
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %.2f MB\t %s\t\n",
+			e.Kind,
+			id,
+			Truncate(e.Name, 30),
+			float64(e.Size)/1024/1024,
+			result,
+		)
+	}
+	w.Flush()
+
+	if len(run.Archives) > 0 {
+		color.New(color.FgGreen).Printf("\nArchived (%d):\n", len(run.Archives))
+		PrintArchive(run.Archives)
+	}
+
+	color.New(color.FgHiGreen).Printf("\nReclaimed: %.2f MB\n", float64(run.Reclaimed())/1024/1024)
+}
+
+// PrintBudget prints the resources chosen to meet a --reclaim target,
+// least disruptive first, with the estimate of the space they free.
+func PrintBudget(b *planner.Budget) {
+	color.New(color.FgYellow).Printf("\n=== RECLAIM %s ===\n", domain.HumanSize(b.Target))
+
+	if len(b.Selected) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\t ID\t NAME\t UNIQUE SIZE\t LAST USED\t COST\t REASON\t")
+
+		for _, c := range b.Selected {
+			id := c.Resource.ID
+			if c.Resource.Kind != domain.KindVolume {
+				id = TruncateID(strings.TrimPrefix(id, "sha256:"))
+			}
+
+			fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %s\t %.2f\t %s\t\n",
+				c.Resource.Kind,
+				id,
+				Truncate(c.Resource.Name, 30),
+				domain.HumanSize(c.UniqueSize),
+				Age(c.LastUsed),
+				c.Cost,
+				c.Reason,
+			)
+		}
+		w.Flush()
+	}
+
+	if b.Covered() {
+		color.New(color.FgHiGreen).Printf("\nEstimate: %s from %d resources covers the target\n",
+			domain.HumanSize(b.Estimate), len(b.Selected))
+	} else {
+		WarningColor.Printf("\nEstimate: %s from %d resources, %s short of the target\n",
+			domain.HumanSize(b.Estimate), len(b.Selected), domain.HumanSize(b.Target-b.Estimate))
+	}
+}
+
+// PrintGroups prints CI resources grouped by their label values, one table per
+// group, with the decision for the group.
+func PrintGroups(g *planner.Groups, dryRun bool) {
+	if dryRun {
+		color.New(color.FgYellow).Println("\n=== DRY RUN MODE: CI GROUPS ===")
+	} else {
+		color.New(color.FgYellow).Println("\n=== CI GROUPS ===")
+	}
+
+	var (
+		removeCount int
+		removeSize  int64
+	)
+
+	for _, group := range g.Groups {
+		title := color.New(color.FgHiBlue)
+		verdict := color.New(color.FgHiGreen).Sprint("keep")
+		if group.Remove {
+			title = color.New(color.FgGreen)
+			verdict = color.New(color.FgHiRed).Sprint("remove")
+			removeCount++
+			removeSize += group.Size
+		}
+
+		title.Printf("\n%s", group.Key)
+		if group.Branch != "" {
+			title.Printf(" (branch %s)", group.Branch)
+		}
+		fmt.Printf(" - %d resources, %s, newest %s: %s, %s\n",
+			len(group.Resources)+len(group.Kept), domain.HumanSize(group.Size), Age(group.Newest), verdict, group.Reason)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  KIND\t ID\t NAME\t SIZE\t CREATED\t")
+
+		rows := append(append([]domain.Resource{}, group.Resources...), group.Kept...)
+		for _, res := range rows {
+			id := res.ID
+			if res.Kind != domain.KindVolume {
+				id = TruncateID(strings.TrimPrefix(id, "sha256:"))
+			}
+
+			fmt.Fprintf(w, "  %s\t %s\t %s\t %s\t %s\t\n",
+				res.Kind,
+				id,
+				Truncate(res.Name, 30),
+				domain.HumanSize(res.Size),
+				Age(res.Created),
+			)
+		}
+		w.Flush()
+	}
+
+	if len(g.Groups) == 0 {
+		color.New(color.FgHiGreen).Println("\nNo CI resources found.")
+		return
+	}
+
+	color.New(color.FgHiWhite).Printf("\nGroups: %d, to remove: %d, ", len(g.Groups), removeCount)
+	color.New(color.FgHiGreen).Printf("freed space: %s\n", domain.HumanSize(removeSize))
+}
+
+// PrintAddressSpace prints the subnets of all networks with the pool they were
+// allocated from and their overlaps, then the remaining capacity of every pool.
+func PrintAddressSpace(r *ipam.Report) {
+	color.New(color.FgYellow).Println("\n=== NETWORK SUBNETS ===")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t NAME\t SUBNET\t STATUS\t POOL\t OVERLAPS\t")
+
+	for _, s := range r.Subnets {
+		status := "in use"
+		if s.Unused {
+			status = "unused"
+		}
+
+		pool := s.Pool
+		if pool == "" {
+			pool = "-"
+		}
+
+		overlaps := "-"
+		if len(s.Overlaps) > 0 {
+			overlaps = ErrorColor.Sprint(strings.Join(s.Overlaps, ", "))
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %s\t %s\t\n",
+			TruncateID(s.NetworkID),
+			Truncate(s.Network, 20),
+			s.Prefix,
+			status,
+			pool,
+			overlaps,
+		)
+	}
+	w.Flush()
+
+	color.New(color.FgYellow).Println("\n=== ADDRESS POOLS ===")
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POOL\t SUBNETS\t USED\t FREE\t RECLAIMABLE\t")
+
+	for _, p := range r.Pools {
+		fmt.Fprintf(w, "%s\t %d\t %d\t %d\t %d\t\n", p.Pool, p.Total, p.Used, p.Free, p.Reclaimable)
+	}
+	w.Flush()
+
+	var reclaimable int
+	for _, p := range r.Pools {
+		reclaimable += p.Reclaimable
+	}
+
+	free := r.Free()
+	switch {
+	case free == 0:
+		ErrorColor.Printf("\nAddress pools exhausted: no new network can be created")
+	case free <= 3:
+		WarningColor.Printf("\nAddress pools nearly exhausted: %d subnets left", free)
+	default:
+		color.New(color.FgHiGreen).Printf("\nFree subnets: %d", free)
+	}
+	fmt.Printf(", removing unused networks frees %d more\n", reclaimable)
+}
+
+// PrintLogs prints the log usage of every container, largest first, with its
+// rotation settings. Logs without rotation and oversized logs are highlighted.
+func PrintLogs(r *logs.Report) {
+	color.New(color.FgYellow).Println("\n=== CONTAINER LOGS ===")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t NAME\t STATE\t DRIVER\t SIZE\t FILES\t ROTATION\t NOTE\t")
+
+	var unrotated int
+	for _, e := range r.Entries {
+		size := "-"
+		if e.Path != "" && e.Note == "" {
+			size = domain.HumanSize(e.Size)
+			if e.Oversized {
+				size = ErrorColor.Sprint(size)
+			}
+		}
+
+		rotation := fmt.Sprintf("%s x %d", domain.HumanSize(e.MaxSize), e.MaxFiles)
+		if e.Unrotated {
+			rotation = WarningColor.Sprint("none")
+			unrotated++
+		} else if e.MaxSize == 0 {
+			rotation = "-"
+		}
+
+		note := e.Note
+		if note == "" {
+			note = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %s\t %d\t %s\t %s\t\n",
+			TruncateID(e.ID),
+			Truncate(e.Name, 30),
+			e.State,
+			e.Driver,
+			size,
+			e.Files,
+			rotation,
+			note,
+		)
+	}
+	w.Flush()
+
+	color.New(color.FgHiWhite).Printf("\nTotal log size: %s, ", domain.HumanSize(r.Total()))
+	if n := len(r.Oversized()); n > 0 {
+		ErrorColor.Printf("%d over %s", n, domain.HumanSize(r.Threshold))
+	} else {
+		fmt.Printf("none over %s", domain.HumanSize(r.Threshold))
+	}
+	if unrotated > 0 {
+		WarningColor.Printf(", %d without rotation (set max-size in the log options)", unrotated)
+	}
+	fmt.Println()
+}
+
+// PrintDuplicates prints the redundant tags of every image with the tag that
+// stays, then the images built from identical content and the space they waste.
+func PrintDuplicates(d *planner.Duplicates) {
+	color.New(color.FgYellow).Println("\n=== REDUNDANT TAGS ===")
+
+	if len(d.Tags) == 0 {
+		color.New(color.FgHiGreen).Println("No image has redundant tags.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "IMAGE\t KEEP\t UNTAG\t")
+
+		for _, g := range d.Tags {
+			fmt.Fprintf(w, "%s\t %s\t %s\t\n",
+				TruncateID(strings.TrimPrefix(g.ImageID, "sha256:")),
+				g.Canonical,
+				strings.Join(g.Redundant, ", "),
+			)
+		}
+		w.Flush()
+	}
+
+	color.New(color.FgYellow).Println("\n=== IDENTICAL IMAGES ===")
+
+	if len(d.Content) == 0 {
+		color.New(color.FgHiGreen).Println("No images with identical content.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEEP\t DUPLICATE\t TAGS\t SIZE\t NOTE\t")
+
+	for _, g := range d.Content {
+		for _, dup := range g.Duplicates {
+			note := "-"
+			if slices.Contains(g.Used, dup.ID) {
+				note = "used by containers"
+			}
+
+			fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %s\t\n",
+				TruncateID(strings.TrimPrefix(g.Canonical, "sha256:")),
+				TruncateID(strings.TrimPrefix(dup.ID, "sha256:")),
+				Truncate(strings.Join(dup.RepoTags, ", "), 40),
+				domain.HumanSize(dup.Size),
+				note,
+			)
+		}
+	}
+	w.Flush()
+
+	var apparent int64
+	for _, g := range d.Content {
+		apparent += g.Apparent
+	}
+	color.New(color.FgHiWhite).Printf("\nDuplicates: %s listed, ", domain.HumanSize(apparent))
+	color.New(color.FgHiGreen).Printf("%s wasted on disk\n", domain.HumanSize(d.Wasted()))
+}
+
+// PrintRepullability lists the images that could not be pulled again after
+// deletion. With protect they are kept, otherwise only flagged.
+func PrintRepullability(checks []registry.ImageCheck, protect bool) {
+	var lost []registry.ImageCheck
+	for _, c := range checks {
+		if c.Lost() {
+			lost = append(lost, c)
+		}
+	}
+	if len(lost) == 0 {
+		return
+	}
+
+	color.New(color.FgYellow).Println("\n=== NOT RE-PULLABLE ===")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t TAGS\t STATUS\t DETAIL\t")
+
+	for _, c := range lost {
+		detail := "-"
+		for _, r := range c.Results {
+			if r.Err != "" {
+				detail = r.Err
+				break
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %s\t\n",
+			TruncateID(strings.TrimPrefix(c.ImageID, "sha256:")),
+			Truncate(strings.Join(c.Tags, ", "), 40),
+			c.Status,
+			Truncate(detail, 60),
+		)
+	}
+	w.Flush()
+
+	if protect {
+		InfoColor.Printf("%d image(s) kept: they could not be pulled again.\n", len(lost))
+	} else {
+		WarningColor.Printf("%d image(s) will be lost for good when removed.\n", len(lost))
+	}
+}
+
+// PrintArchive prints where every image tag was archived before removal.
+func PrintArchive(results []archive.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\t ARCHIVE\t DIGEST\t ATTEMPTS\t RESULT\t")
+
+	for _, r := range results {
+		result, digest := "archived", "-"
+		if r.Error != "" {
+			result = color.HiRedString("failed: %s", Truncate(r.Error, 60))
+		} else {
+			digest = TruncateID(strings.TrimPrefix(r.Digest, "sha256:"))
+		}
+
+		fmt.Fprintf(w, "%s\t %s\t %s\t %d\t %s\t\n",
+			r.Source,
+			r.Target,
+			digest,
+			r.Attempts,
+			result,
+		)
+	}
+	w.Flush()
+}
+
+// PrintHostReport prints the engine, its disk usage by resource type and how
+// many unused resources the analysis found.
+func PrintHostReport(r *docker.HostReport, unused *domain.UnusedResources) {
+	color.New(color.FgYellow).Println("\n=== HOST REPORT ===")
+
+	fmt.Printf("Host:           %s\n", r.Host)
+	fmt.Printf("Engine:         %s %s\n", r.Engine, r.Version)
+	fmt.Printf("OS:             %s (kernel %s)\n", r.OS, r.Kernel)
+	fmt.Printf("Storage driver: %s, data in %s\n", r.StorageDriver, r.RootDir)
+
+	color.New(color.FgGreen).Println("\nDisk usage:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\t TOTAL\t ACTIVE\t SIZE\t RECLAIMABLE\t")
+
+	var size, reclaimable int64
+	for _, u := range r.Usage {
+		fmt.Fprintf(w, "%s\t %d\t %d\t %s\t %s\t\n",
+			u.Type,
+			u.Total,
+			u.Active,
+			domain.HumanSize(u.Size),
+			domain.HumanSize(u.Reclaimable),
+		)
+		size += u.Size
+		reclaimable += u.Reclaimable
+	}
+	w.Flush()
+
+	color.New(color.FgHiWhite).Printf("\nTotal: %s, reclaimable: %s\n", domain.HumanSize(size), domain.HumanSize(reclaimable))
+	color.New(color.FgHiGreen).Printf("Unused: %d images, %d containers, %d volumes, %d networks (%.2f MB)\n",
+		len(unused.Images), len(unused.Containers), len(unused.Volumes), len(unused.Networks), unused.TotalSize()/1024/1024)
+}
+
+// PrintExplanations prints the verdict on every matching resource with the
+// reasons behind it.
+func PrintExplanations(explanations []docker.Explanation) {
+	for _, e := range explanations {
+		verdict := color.HiGreenString("kept")
+		if e.Unused {
+			verdict = color.HiRedString("removed")
+		}
+
+		color.New(color.FgYellow).Printf("\n%s %s (%s): ", e.Kind, Truncate(e.Name, 60), TruncateID(strings.TrimPrefix(e.ID, "sha256:")))
+		fmt.Println(verdict)
+		for _, reason := range e.Reasons {
+			fmt.Printf("  - %s\n", reason)
+		}
+	}
+}
+
+// PrintDiff prints the resources that were added, removed or changed between
+// two snapshots, per kind with the size deltas. New resources the analysis
+// already reports as unused are highlighted.
+func PrintDiff(d *snapshot.Diff) {
+	color.New(color.FgYellow).Printf("\n=== CHANGES ON %s ===\n", d.Host)
+	fmt.Printf("From %s to %s\n", d.From.Local().Format("2006-01-02 15:04:05"), d.To.Local().Format("2006-01-02 15:04:05"))
+
+	if len(d.Changes) == 0 {
+		color.New(color.FgHiGreen).Println("\nNothing changed.")
+		return
+	}
+
+	var unused int
+	for _, kind := range domain.Kinds {
+		changes := d.Of(kind)
+		if len(changes) == 0 {
+			continue
+		}
+
+		color.New(color.FgGreen).Printf("\n%ss (%d), %s:\n", strings.ToUpper(string(kind[:1]))+string(kind[1:]), len(changes), signedSize(d.Delta(kind)))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CHANGE\t ID\t NAME\t STATE\t SIZE\t DELTA\t NOTE\t")
+
+		for _, c := range changes {
+			var change, state string
+			switch c.Change {
+			case snapshot.ChangeAdded:
+				change, state = SuccessColor.Sprint("+ added"), c.StateAfter
+			case snapshot.ChangeRemoved:
+				change, state = ErrorColor.Sprint("- removed"), c.StateBefore
+			default:
+				change, state = WarningColor.Sprint("~ changed"), c.StateAfter
+				if c.StateBefore != c.StateAfter {
+					state = c.StateBefore + " -> " + c.StateAfter
+				}
+			}
+
+			note := "-"
+			if c.Unused {
+				note = WarningColor.Sprint("already unused")
+				unused++
+			}
+
+			size := c.SizeAfter
+			if c.Change == snapshot.ChangeRemoved {
+				size = c.SizeBefore
+			}
+
+			fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %s\t %s\t %s\t\n",
+				change,
+				TruncateID(strings.TrimPrefix(c.ID, "sha256:")),
+				Truncate(c.Name, 40),
+				Truncate(state, 40),
+				domain.HumanSize(size),
+				signedSize(c.Delta()),
+				note,
+			)
+		}
+		w.Flush()
+	}
+
+	var delta int64
+	for _, c := range d.Changes {
+		delta += c.Delta()
+	}
+	color.New(color.FgHiWhite).Printf("\nTotal: %d changes, %s", len(d.Changes), signedSize(delta))
+	if unused > 0 {
+		WarningColor.Printf(", %d new or changed resource(s) already unused", unused)
+	}
+	fmt.Println()
+}
+
+func signedSize(delta int64) string {
+	switch {
+	case delta > 0:
+		return "+" + domain.HumanSize(delta)
+	case delta < 0:
+		return "-" + domain.HumanSize(-delta)
+	default:
+		return "0 B"
+	}
+}
+
+// PrintPolicyResults prints one line per fixture, and every failed
+// expectation with the reasons behind the verdict it got.
+func PrintPolicyResults(results []fixture.Result) {
+	var passed, failed int
+	for i := 0; i < len(results); {
+		name, path := results[i].Fixture, results[i].Path
+
+		var fixtureFailed []fixture.Result
+		n := 0
+		for ; i < len(results) && results[i].Path == path; i++ {
+			n++
+			if results[i].Passed() {
+				passed++
+			} else {
+				fixtureFailed = append(fixtureFailed, results[i])
+			}
+		}
+		failed += len(fixtureFailed)
+
+		if len(fixtureFailed) == 0 {
+			Success("%s: %d expectation(s) passed", name, n)
+			continue
+		}
+
+		Error("%s (%s): %d of %d expectation(s) failed", name, path, len(fixtureFailed), n)
+		for _, r := range fixtureFailed {
+			if r.Error != "" {
+				fmt.Printf("  %s: %s\n", r.Resource, r.Error)
+				continue
+			}
+
+			fmt.Printf("  %s %s: want %s, got %s\n", r.Explanation.Kind, r.Resource, r.Want, ErrorColor.Sprint(r.Got))
+			for _, reason := range r.Explanation.Reasons {
+				fmt.Printf("    - %s\n", reason)
+			}
+		}
+	}
+
+	color.New(color.FgHiWhite).Printf("\n%d passed, ", passed)
+	if failed > 0 {
+		ErrorColor.Printf("%d failed\n", failed)
+	} else {
+		fmt.Println("0 failed")
+	}
+}
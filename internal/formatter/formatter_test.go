@@ -0,0 +1,25 @@
+package formatter
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{s: "nginx", n: 10, want: "nginx"},
+		{s: "registry.example.com/app", n: 10, want: "registr..."},
+		{s: "nginx", n: 3, want: "ngi"},
+		{s: "nginx", n: 0, want: ""},
+		{s: "nginx", n: -1, want: ""},
+		{s: "сервис-платежей", n: 8, want: "серви..."},
+		{s: "日本語のイメージ", n: 2, want: "日本"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("Truncate(%q, %d): expected %q, got %q", tt.s, tt.n, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+// tablePrefix makes a --format template align its tab-separated columns like
+// the tables dockr prints.
+const tablePrefix = "table "
+
+// Template is a --format template over the model of a command's output.
+type Template struct {
+	tmpl  *template.Template
+	table bool
+}
+
+var templateFuncs = template.FuncMap{
+	"human":    human,
+	"ago":      ago,
+	"truncate": Truncate,
+	"join":     strings.Join,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseTemplate parses a Go template as docker --format does: `\t` and `\n`
+// stand for a tab and a newline, and a "table " prefix aligns the columns.
+func ParseTemplate(format string) (*Template, error) {
+	text, table := strings.CutPrefix(format, tablePrefix)
+	text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+
+	return &Template{tmpl: tmpl, table: table}, nil
+}
+
+// Execute renders data to w, ending the output with a newline.
+func (t *Template) Execute(w io.Writer, data any) error {
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to render --format template: %w", err)
+	}
+	if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+
+	if !t.table {
+		_, err := w.Write(b.Bytes())
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := tw.Write(b.Bytes()); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// human renders a size in bytes, e.g. "1.50 GB".
+func human(v any) (string, error) {
+	switch n := v.(type) {
+	case int:
+		return domain.HumanSize(int64(n)), nil
+	case int64:
+		return domain.HumanSize(n), nil
+	case uint64:
+		return domain.HumanSize(int64(n)), nil
+	case domain.ByteSize:
+		return domain.HumanSize(int64(n)), nil
+	default:
+		return "", fmt.Errorf("human: cannot render %T as a size", v)
+	}
+}
+
+// ago renders how long ago a time was. Docker reports times as time.Time,
+// Unix seconds (images, containers) or RFC 3339 strings (volumes).
+func ago(v any) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return Age(t), nil
+	case int64:
+		return Age(time.Unix(t, 0)), nil
+	case string:
+		if t == "" {
+			return Age(time.Time{}), nil
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return "", fmt.Errorf("ago: %w", err)
+		}
+		return Age(parsed), nil
+	default:
+		return "", fmt.Errorf("ago: cannot render %T as a time", v)
+	}
+}
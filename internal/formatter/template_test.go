@@ -0,0 +1,95 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func TestTemplate(t *testing.T) {
+	now := time.Now()
+	resources := &domain.UnusedResources{
+		Images: []*image.Summary{
+			{ID: "sha256:0123456789abcdef", RepoTags: []string{"app:1", "app:latest"}, Size: 3 * 1024 * 1024, Created: now.Add(-48 * time.Hour).Unix()},
+			{ID: "sha256:fedcba9876543210", Size: 512},
+		},
+		Containers: []*container.Summary{{ID: "c1", Names: []string{"/web"}, State: "exited"}},
+		Volumes:    []*volume.Volume{{Name: "cache", CreatedAt: now.Add(-3 * time.Hour).Format(time.RFC3339)}},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "range with helpers",
+			format: `{{range .Images}}{{truncate .ID 12}} {{.Size | human}} {{join .RepoTags ","}}\n{{end}}`,
+			want:   "sha256:01... 3.00 MB app:1,app:latest\nsha256:fe... 512 B \n",
+		},
+		{
+			name:   "newline appended",
+			format: `{{len .Items}} resources`,
+			want:   "4 resources\n",
+		},
+		{
+			name:   "ago",
+			format: `{{range .Images}}{{ago .Created}};{{end}}{{range .Volumes}}{{ago .CreatedAt}}{{end}}`,
+			want:   "2 days ago;-;3 hours ago\n",
+		},
+		{
+			name:   "json",
+			format: `{{json (index .Containers 0).Names}}`,
+			want:   `["/web"]` + "\n",
+		},
+		{
+			name:   "table",
+			format: `table KIND\tNAME\tSIZE\n{{range .Items}}{{.Kind}}\t{{.Name}}\t{{human .Size}}\n{{end}}`,
+			want: "KIND       NAME               SIZE\n" +
+				"image      app:1, app:latest  3.00 MB\n" +
+				"image      <none>             512 B\n" +
+				"container  web                0 B\n" +
+				"volume     cache              0 B\n",
+		},
+		{
+			name:    "parse error",
+			format:  `{{range .Images}`,
+			wantErr: "invalid --format template",
+		},
+		{
+			name:    "render error",
+			format:  `{{human .Volumes}}`,
+			wantErr: "cannot render []*volume.Volume as a size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.format)
+			var b bytes.Buffer
+			if err == nil {
+				err = tmpl.Execute(&b, resources)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("expected\n%q\ngot\n%q", tt.want, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+// Package hooks runs user-configured executables and HTTP webhooks around
+// a cleanup run and around every single resource removal.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+// Event is the point of a run at which a hook fires.
+type Event string
+
+const (
+	BeforeRun    Event = "before_run"
+	AfterRun     Event = "after_run"
+	BeforeRemove Event = "before_remove"
+	AfterRemove  Event = "after_remove"
+)
+
+// What to do when a hook cannot be run, times out or returns an unexpected response.
+// A veto is not a failure.
+const (
+	FailAbort  = "abort"  // stop the whole run with an error
+	FailSkip   = "skip"   // keep the resource and continue with the next one
+	FailIgnore = "ignore" // carry on as if the hook had succeeded
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Config describes a single hook. Exactly one of Command or URL must be set.
+type Config struct {
+	Name      string            `yaml:"name"`
+	Events    []Event           `yaml:"events"`
+	Kinds     []string          `yaml:"kinds,omitempty"`
+	Command   []string          `yaml:"command,omitempty"`
+	URL       string            `yaml:"url,omitempty"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	Timeout   time.Duration     `yaml:"timeout,omitempty"`
+	OnFailure string            `yaml:"on_failure,omitempty"`
+}
+
+// Payload is the JSON document a hook receives on stdin or as the request body.
+type Payload struct {
+	Event    Event            `json:"event"`
+	Hook     string           `json:"hook"`
+	Resource *ResourcePayload `json:"resource,omitempty"`
+	// Resources lists the resources about to be removed (before_run only).
+	Resources []ResourcePayload `json:"resources,omitempty"`
+	// Summary is the audit record of the finished run (after_run only).
+	Summary any `json:"summary,omitempty"`
+	// Error holds the removal error (after_remove only).
+	Error string `json:"error,omitempty"`
+}
+
+// ResourcePayload is the JSON view of a resource passed to hooks.
+type ResourcePayload struct {
+	Kind    domain.ResourceKind `json:"kind"`
+	ID      string              `json:"id"`
+	Name    string              `json:"name"`
+	Size    int64               `json:"size"`
+	Created time.Time           `json:"created"`
+	Labels  map[string]string   `json:"labels,omitempty"`
+	Object  any                 `json:"object"`
+}
+
+// NewResourcePayload builds the JSON view of a resource.
+func NewResourcePayload(res domain.Resource) ResourcePayload {
+	return ResourcePayload{
+		Kind:    res.Kind,
+		ID:      res.ID,
+		Name:    res.Name,
+		Size:    res.Size,
+		Created: res.Created,
+		Labels:  res.Labels,
+		Object:  res.Object,
+	}
+}
+
+// Response is the optional JSON body a webhook may answer with to veto a removal.
+type Response struct {
+	Veto   bool   `json:"veto"`
+	Reason string `json:"reason"`
+}
+
+// VetoError is returned when a hook refuses a removal or a run.
+type VetoError struct {
+	Hook   string
+	Reason string
+}
+
+func (e *VetoError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("vetoed by hook %q", e.Hook)
+	}
+	return fmt.Sprintf("vetoed by hook %q: %s", e.Hook, e.Reason)
+}
+
+// Unwrap makes a veto count as a skipped removal.
+func (e *VetoError) Unwrap() error {
+	return domain.ErrSkipped
+}
+
+// Runner executes the configured hooks. It implements cleaner.Observer.
+type Runner struct {
+	hooks  []Config
+	client *http.Client
+
+	// aborted holds the failure of an after_remove hook configured to abort;
+	// it stops the run before the next removal.
+	aborted error
+}
+
+// New validates the hook configuration and returns a runner. Hooks without a
+// name are named after their position; configs itself is not modified.
+func New(configs []Config) (*Runner, error) {
+	configs = slices.Clone(configs)
+	for i, h := range configs {
+		if h.Name == "" {
+			configs[i].Name = fmt.Sprintf("hook-%d", i+1)
+			h.Name = configs[i].Name
+		}
+		if (len(h.Command) == 0) == (h.URL == "") {
+			return nil, fmt.Errorf("hook %q: exactly one of command or url must be set", h.Name)
+		}
+		if len(h.Events) == 0 {
+			return nil, fmt.Errorf("hook %q: no events configured", h.Name)
+		}
+		for _, e := range h.Events {
+			switch e {
+			case BeforeRun, AfterRun, BeforeRemove, AfterRemove:
+			default:
+				return nil, fmt.Errorf("hook %q: unknown event %q", h.Name, e)
+			}
+		}
+		switch h.OnFailure {
+		case "", FailAbort, FailSkip, FailIgnore:
+		default:
+			return nil, fmt.Errorf("hook %q: unknown on_failure %q (expected abort, skip or ignore)", h.Name, h.OnFailure)
+		}
+	}
+
+	return &Runner{hooks: configs, client: &http.Client{}}, nil
+}
+
+// BeforeRun runs the before_run hooks with the list of resources about to be removed.
+// A veto from any hook cancels the whole run.
+func (r *Runner) BeforeRun(ctx context.Context, resources *domain.UnusedResources) error {
+	payload := Payload{Event: BeforeRun}
+	for _, res := range resources.Items() {
+		payload.Resources = append(payload.Resources, NewResourcePayload(res))
+	}
+
+	return r.fire(ctx, payload, "")
+}
+
+// AfterRun runs the after_run hooks with the summary of the finished run.
+func (r *Runner) AfterRun(ctx context.Context, summary any) error {
+	return r.fire(ctx, Payload{Event: AfterRun, Summary: summary}, "")
+}
+
+// BeforeRemove runs the before_remove hooks. A veto skips the resource.
+func (r *Runner) BeforeRemove(ctx context.Context, res domain.Resource) error {
+	if r.aborted != nil {
+		return r.aborted
+	}
+
+	rp := NewResourcePayload(res)
+	return r.fire(ctx, Payload{Event: BeforeRemove, Resource: &rp}, res.Kind)
+}
+
+// AfterRemove runs the after_remove hooks. Resources that were skipped are not reported.
+func (r *Runner) AfterRemove(ctx context.Context, res domain.Resource, err error) {
+	if errors.Is(err, domain.ErrSkipped) {
+		return
+	}
+
+	rp := NewResourcePayload(res)
+	payload := Payload{Event: AfterRemove, Resource: &rp}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+
+	// The removal already happened, so an aborting failure can only stop the next one.
+	if err := r.fire(ctx, payload, res.Kind); err != nil && r.aborted == nil {
+		r.aborted = err
+	}
+}
+
+// fire runs every hook subscribed to the payload's event in configuration order.
+func (r *Runner) fire(ctx context.Context, payload Payload, kind domain.ResourceKind) error {
+	for _, h := range r.hooks {
+		if !slices.Contains(h.Events, payload.Event) {
+			continue
+		}
+		if kind != "" && len(h.Kinds) > 0 && !slices.Contains(h.Kinds, string(kind)) {
+			continue
+		}
+
+		payload.Hook = h.Name
+		if err := r.run(ctx, h, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// run executes one hook and applies its failure policy.
+func (r *Runner) run(ctx context.Context, h Config, payload Payload) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("hook %q: failed to encode payload: %w", h.Name, err)
+	}
+
+	if len(h.Command) > 0 {
+		err = r.runCommand(ctx, h, body)
+	} else {
+		err = r.runWebhook(ctx, h, body)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	// Only before_* hooks may refuse; for after_* hooks a refusal is just a failure.
+	var veto *VetoError
+	if errors.As(err, &veto) {
+		if payload.Event == BeforeRun || payload.Event == BeforeRemove {
+			return err
+		}
+		err = errors.New(veto.Reason)
+	}
+
+	switch failurePolicy(h, payload.Event) {
+	case FailIgnore:
+		return nil
+	case FailSkip:
+		return fmt.Errorf("hook %q failed: %w (%w)", h.Name, err, domain.ErrSkipped)
+	default:
+		return fmt.Errorf("hook %q failed: %w", h.Name, err)
+	}
+}
+
+// failurePolicy defaults to skipping the resource for before_remove hooks
+// (the safe choice) and to aborting for before_run hooks; failures of after_*
+// hooks are ignored unless configured otherwise. "skip" only makes sense for
+// a single resource, so for before_run it aborts and for after_* it ignores.
+func failurePolicy(h Config, event Event) string {
+	policy := h.OnFailure
+	if policy == "" {
+		switch event {
+		case BeforeRemove:
+			return FailSkip
+		case BeforeRun:
+			return FailAbort
+		default:
+			return FailIgnore
+		}
+	}
+
+	if policy == FailSkip {
+		switch event {
+		case BeforeRun:
+			return FailAbort
+		case AfterRun, AfterRemove:
+			return FailIgnore
+		}
+	}
+
+	return policy
+}
+
+// runCommand executes the hook with the payload on stdin. Exit code 0 allows
+// the removal, any other exit code vetoes it; the first line of stdout
+// (or stderr) is used as the reason.
+func (r *Runner) runCommand(ctx context.Context, h Config, body []byte) error {
+	//nolint:gosec // Running the configured hook executable is the whole point.
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	// Don't wait for grandchildren holding the output pipes after a timeout.
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("timed out: %w", ctx.Err())
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		reason := firstLine(stdout.String())
+		if reason == "" {
+			reason = firstLine(stderr.String())
+		}
+		if reason == "" {
+			reason = fmt.Sprintf("exit code %d", exitErr.ExitCode())
+		}
+		return &VetoError{Hook: h.Name, Reason: reason}
+	}
+
+	return err
+}
+
+// runWebhook POSTs the payload to the hook URL. Any 2xx status allows the
+// removal unless the body is a Response with veto set; other statuses are failures.
+func (r *Runner) runWebhook(ctx context.Context, h Config, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var answer Response
+	if len(bytes.TrimSpace(data)) > 0 && json.Unmarshal(data, &answer) == nil && answer.Veto {
+		return &VetoError{Hook: h.Name, Reason: answer.Reason}
+	}
+
+	return nil
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
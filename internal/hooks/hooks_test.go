@@ -0,0 +1,224 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func script(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks are not tested on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testVolume() domain.Resource {
+	return domain.VolumeResource(&volume.Volume{Name: "pgdata", Labels: map[string]string{"owner": "team-a"}})
+}
+
+func TestCommandHookVeto(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "payload.json")
+
+	tests := []struct {
+		name       string
+		body       string
+		onFailure  string
+		expectSkip bool
+		expectErr  bool
+	}{
+		{name: "allow", body: "cat > " + out + "; exit 0"},
+		{name: "veto", body: "echo 'owner did not confirm'; exit 1", expectSkip: true, expectErr: true},
+		{name: "timeout skips by default", body: "exec sleep 5", expectSkip: true, expectErr: true},
+		{name: "timeout ignored", body: "exec sleep 5", onFailure: FailIgnore},
+		{name: "timeout aborts", body: "exec sleep 5", onFailure: FailAbort, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New([]Config{{
+				Name:      "owner",
+				Events:    []Event{BeforeRemove},
+				Kinds:     []string{"volume"},
+				Command:   []string{script(t, tt.body)},
+				Timeout:   200 * time.Millisecond,
+				OnFailure: tt.onFailure,
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.BeforeRemove(context.Background(), testVolume())
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if errors.Is(err, domain.ErrSkipped) != tt.expectSkip {
+				t.Fatalf("expected skip=%v, got %v", tt.expectSkip, err)
+			}
+			if tt.name == "veto" && !strings.Contains(err.Error(), "owner did not confirm") {
+				t.Errorf("expected the reason in the error, got %v", err)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != BeforeRemove || payload.Resource.Name != "pgdata" || payload.Resource.Labels["owner"] != "team-a" {
+		t.Errorf("unexpected payload: %s", data)
+	}
+}
+
+func TestKindFilter(t *testing.T) {
+	r, err := New([]Config{{
+		Events:  []Event{BeforeRemove},
+		Kinds:   []string{"network"},
+		Command: []string{script(t, "exit 1")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.BeforeRemove(context.Background(), testVolume()); err != nil {
+		t.Errorf("hook for networks must not run for volumes: %v", err)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var received []Payload
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var p Payload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		received = append(received, p)
+
+		if p.Event == BeforeRemove && p.Resource.Name == "pgdata" {
+			_ = json.NewEncoder(w).Encode(Response{Veto: true, Reason: "still registered in IPAM"})
+		}
+	}))
+	defer srv.Close()
+
+	r, err := New([]Config{{
+		Name:    "ipam",
+		Events:  []Event{BeforeRun, BeforeRemove, AfterRemove, AfterRun},
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	resources := &domain.UnusedResources{Volumes: []*volume.Volume{{Name: "pgdata"}, {Name: "cache"}}}
+
+	if err := r.BeforeRun(ctx, resources); err != nil {
+		t.Fatalf("unexpected before_run error: %v", err)
+	}
+
+	err = r.BeforeRemove(ctx, testVolume())
+	var veto *VetoError
+	if !errors.As(err, &veto) || veto.Reason != "still registered in IPAM" {
+		t.Fatalf("expected veto, got %v", err)
+	}
+	r.AfterRemove(ctx, testVolume(), err)
+
+	cache := domain.VolumeResource(resources.Volumes[1])
+	if err := r.BeforeRemove(ctx, cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.AfterRemove(ctx, cache, nil)
+
+	if err := r.AfterRun(ctx, map[string]int{"removed": 1}); err != nil {
+		t.Fatalf("unexpected after_run error: %v", err)
+	}
+
+	var events []string
+	for _, p := range received {
+		events = append(events, string(p.Event))
+	}
+	expected := "before_run,before_remove,before_remove,after_remove,after_run"
+	if got := strings.Join(events, ","); got != expected {
+		t.Errorf("expected events %s, got %s", expected, got)
+	}
+	if len(received[0].Resources) != 2 {
+		t.Errorf("expected before_run to receive the whole plan, got %d resources", len(received[0].Resources))
+	}
+}
+
+func TestAfterRemoveAbortStopsRun(t *testing.T) {
+	r, err := New([]Config{{
+		Events:    []Event{AfterRemove},
+		Command:   []string{script(t, "exit 2")},
+		OnFailure: FailAbort,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	r.AfterRemove(ctx, testVolume(), nil)
+
+	err = r.BeforeRemove(ctx, testVolume())
+	if err == nil || errors.Is(err, domain.ErrSkipped) {
+		t.Errorf("expected the next removal to be aborted, got %v", err)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []Config{
+		{Events: []Event{BeforeRun}},
+		{Events: []Event{BeforeRun}, URL: "http://x", Command: []string{"true"}},
+		{URL: "http://x"},
+		{Events: []Event{"during_run"}, URL: "http://x"},
+		{Events: []Event{BeforeRun}, URL: "http://x", OnFailure: "retry"},
+	}
+
+	for _, cfg := range tests {
+		if _, err := New([]Config{cfg}); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestNewDoesNotModifyConfig(t *testing.T) {
+	configs := []Config{{Events: []Event{BeforeRun}, URL: "http://x"}}
+
+	r, err := New(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs[0].Name != "" {
+		t.Errorf("expected the caller's config to stay unnamed, got %q", configs[0].Name)
+	}
+	if r.hooks[0].Name != "hook-1" {
+		t.Errorf("expected hook-1, got %q", r.hooks[0].Name)
+	}
+}
@@ -0,0 +1,338 @@
+// Package ipam analyzes how the subnets of container networks use the
+// engine's default address pools and where they collide with each other or
+// with the host's routes.
+package ipam
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+// DefaultDaemonConfig is where dockerd reads default-address-pools from.
+const DefaultDaemonConfig = "/etc/docker/daemon.json"
+
+// Pool is a range the engine carves network subnets of Size bits out of.
+type Pool struct {
+	Base netip.Prefix `json:"base"`
+	Size int          `json:"size"`
+}
+
+func (p Pool) String() string {
+	return fmt.Sprintf("%s/%d", p.Base, p.Size)
+}
+
+// Subnets returns how many subnets of Size bits fit into the pool.
+func (p Pool) Subnets() int {
+	return 1 << (p.Size - p.Base.Bits())
+}
+
+// ParsePool parses "base/size", e.g. "10.10.0.0/16/24". Only IPv4 pools are supported.
+func ParsePool(s string) (Pool, error) {
+	i := strings.LastIndex(s, "/")
+	if i < 0 || !strings.Contains(s[:i], "/") {
+		return Pool{}, fmt.Errorf("invalid address pool %q (expected base/size, e.g. 10.10.0.0/16/24)", s)
+	}
+
+	size, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return Pool{}, fmt.Errorf("invalid address pool %q: %w", s, err)
+	}
+
+	return newPool(s[:i], size)
+}
+
+func newPool(base string, size int) (Pool, error) {
+	prefix, err := netip.ParsePrefix(base)
+	if err != nil {
+		return Pool{}, fmt.Errorf("invalid address pool base %q: %w", base, err)
+	}
+	if !prefix.Addr().Is4() {
+		return Pool{}, fmt.Errorf("address pool %s: only IPv4 pools are supported", base)
+	}
+	if size < prefix.Bits() || size > 32 {
+		return Pool{}, fmt.Errorf("address pool %s: size %d must be between %d and 32", base, size, prefix.Bits())
+	}
+
+	return Pool{Base: prefix.Masked(), Size: size}, nil
+}
+
+// DefaultPools returns the pools an engine uses when it is not configured otherwise.
+func DefaultPools(engine domain.Engine) []Pool {
+	if engine == domain.EnginePodman {
+		// default_subnet_pools of containers.conf.
+		return []Pool{
+			mustPool("10.89.0.0/16", 24), mustPool("10.90.0.0/15", 24), mustPool("10.92.0.0/14", 24),
+			mustPool("10.96.0.0/11", 24), mustPool("10.128.0.0/9", 24),
+		}
+	}
+
+	// dockerd's built-in default-address-pools: 31 networks in total.
+	return []Pool{
+		mustPool("172.17.0.0/16", 16), mustPool("172.18.0.0/16", 16), mustPool("172.19.0.0/16", 16),
+		mustPool("172.20.0.0/14", 16), mustPool("172.24.0.0/14", 16), mustPool("172.28.0.0/14", 16),
+		mustPool("192.168.0.0/16", 20),
+	}
+}
+
+func mustPool(base string, size int) Pool {
+	pool, err := newPool(base, size)
+	if err != nil {
+		panic(err)
+	}
+	return pool
+}
+
+// LoadDaemonPools reads default-address-pools from a dockerd configuration
+// file. It returns nil without an error when the file does not exist or does
+// not configure any pools.
+func LoadDaemonPools(path string) ([]Pool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read daemon config: %w", err)
+	}
+
+	var cfg struct {
+		Pools []struct {
+			Base string `json:"base"`
+			Size int    `json:"size"`
+		} `json:"default-address-pools"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon config %s: %w", path, err)
+	}
+
+	var pools []Pool
+	for _, p := range cfg.Pools {
+		pool, err := newPool(p.Base, p.Size)
+		if err != nil {
+			// IPv6 pools are allowed by dockerd but not analyzed here.
+			if base, perr := netip.ParsePrefix(p.Base); perr == nil && base.Addr().Is6() {
+				continue
+			}
+			return nil, fmt.Errorf("daemon config %s: %w", path, err)
+		}
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
+
+// Network is a container network with the subnets of its IPAM config.
+type Network struct {
+	ID      string
+	Name    string
+	Subnets []netip.Prefix
+	// Unused marks networks the cleanup would remove.
+	Unused bool
+}
+
+// Route is a route of the host's routing table.
+type Route struct {
+	Iface  string
+	Prefix netip.Prefix
+}
+
+func (r Route) String() string {
+	return fmt.Sprintf("route %s via %s", r.Prefix, r.Iface)
+}
+
+// Subnet is one subnet of a network with what it collides with.
+type Subnet struct {
+	Network   string       `json:"network"`
+	NetworkID string       `json:"network_id"`
+	Prefix    netip.Prefix `json:"subnet"`
+	Unused    bool         `json:"unused"`
+	// Pool is the default address pool the subnet was taken from, if any.
+	Pool string `json:"pool,omitempty"`
+	// Slots is how many subnets of its pool this subnet occupies.
+	Slots int `json:"slots,omitempty"`
+	// Overlaps lists other networks' subnets and host routes overlapping this one.
+	Overlaps []string `json:"overlaps,omitempty"`
+}
+
+// PoolUsage is the remaining capacity of a default address pool.
+type PoolUsage struct {
+	Pool  string `json:"pool"`
+	Total int    `json:"total"`
+	Used  int    `json:"used"`
+	Free  int    `json:"free"`
+	// Reclaimable is how many subnets removing the unused networks frees.
+	Reclaimable int `json:"reclaimable"`
+}
+
+// Report is the outcome of Analyze.
+type Report struct {
+	Subnets []Subnet    `json:"subnets"`
+	Pools   []PoolUsage `json:"pools"`
+}
+
+// Free returns the number of subnets still available in all pools.
+func (r *Report) Free() int {
+	var free int
+	for _, p := range r.Pools {
+		free += p.Free
+	}
+	return free
+}
+
+// Reclaimable returns the unused networks holding address pool space, the
+// ones occupying the most subnets first.
+func (r *Report) Reclaimable() []string {
+	slots := make(map[string]int)
+	for _, s := range r.Subnets {
+		if s.Unused && s.Slots > 0 {
+			slots[s.NetworkID] += s.Slots
+		}
+	}
+
+	ids := make([]string, 0, len(slots))
+	for id := range slots {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return cmp.Or(cmp.Compare(slots[b], slots[a]), cmp.Compare(a, b))
+	})
+
+	return ids
+}
+
+// Analyze maps every subnet to its pool and finds overlaps with other
+// networks and with host routes. Routes for a network's own subnet, which
+// the engine adds for its bridges, are not counted as overlaps, and neither
+// is the default route. A pool subnet is used when any network subnet or
+// host route overlaps it.
+func Analyze(networks []Network, routes []Route, pools []Pool) *Report {
+	report := &Report{}
+
+	own := make(map[netip.Prefix]bool)
+	for _, n := range networks {
+		for _, prefix := range n.Subnets {
+			own[prefix.Masked()] = true
+		}
+	}
+
+	var foreign []Route
+	for _, r := range routes {
+		if r.Prefix.Bits() == 0 || own[r.Prefix.Masked()] {
+			continue
+		}
+		foreign = append(foreign, r)
+	}
+
+	for _, n := range networks {
+		for _, prefix := range n.Subnets {
+			s := Subnet{Network: n.Name, NetworkID: n.ID, Prefix: prefix.Masked(), Unused: n.Unused}
+
+			for _, other := range networks {
+				if other.ID == n.ID {
+					continue
+				}
+				for _, op := range other.Subnets {
+					if op.Overlaps(prefix) {
+						s.Overlaps = append(s.Overlaps, fmt.Sprintf("%s (%s)", op.Masked(), other.Name))
+					}
+				}
+			}
+			for _, r := range foreign {
+				if r.Prefix.Overlaps(prefix) {
+					s.Overlaps = append(s.Overlaps, r.String())
+				}
+			}
+
+			for _, pool := range pools {
+				if pool.Base.Overlaps(prefix) {
+					s.Pool = pool.String()
+					s.Slots = countSlots(pool, []netip.Prefix{prefix})
+					break
+				}
+			}
+
+			report.Subnets = append(report.Subnets, s)
+		}
+	}
+
+	for _, pool := range pools {
+		var all, kept []netip.Prefix
+		for _, n := range networks {
+			all = append(all, n.Subnets...)
+			if !n.Unused {
+				kept = append(kept, n.Subnets...)
+			}
+		}
+		for _, r := range foreign {
+			all = append(all, r.Prefix)
+			kept = append(kept, r.Prefix)
+		}
+
+		used := countSlots(pool, all)
+		report.Pools = append(report.Pools, PoolUsage{
+			Pool:        pool.String(),
+			Total:       pool.Subnets(),
+			Used:        used,
+			Free:        pool.Subnets() - used,
+			Reclaimable: used - countSlots(pool, kept),
+		})
+	}
+
+	return report
+}
+
+// countSlots returns how many of the pool's subnets the prefixes overlap.
+func countSlots(pool Pool, prefixes []netip.Prefix) int {
+	type span struct{ start, end uint32 }
+
+	base := addr4(pool.Base.Addr())
+	shift := 32 - pool.Size
+	total := uint32(pool.Subnets())
+
+	var spans []span
+	for _, p := range prefixes {
+		if !p.Addr().Is4() || !pool.Base.Overlaps(p) {
+			continue
+		}
+		if p.Bits() <= pool.Base.Bits() {
+			spans = append(spans, span{0, total})
+			continue
+		}
+
+		start := (addr4(p.Masked().Addr()) - base) >> shift
+		length := uint32(1)
+		if p.Bits() < pool.Size {
+			length = 1 << (pool.Size - p.Bits())
+		}
+		spans = append(spans, span{start, start + length})
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return cmp.Compare(a.start, b.start) })
+
+	var (
+		count int
+		end   uint32
+	)
+	for _, s := range spans {
+		s.start = max(s.start, end)
+		if s.end > s.start {
+			count += int(s.end - s.start)
+			end = s.end
+		}
+	}
+
+	return count
+}
+
+func addr4(a netip.Addr) uint32 {
+	b := a.As4()
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
@@ -0,0 +1,140 @@
+package ipam
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePool(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		subnets int
+		wantErr bool
+	}{
+		{in: "10.10.0.0/16/24", want: "10.10.0.0/16/24", subnets: 256},
+		{in: "192.168.1.7/16/20", want: "192.168.0.0/16/20", subnets: 16},
+		{in: "10.0.0.0/8", wantErr: true},
+		{in: "10.0.0.0/16/8", wantErr: true},
+		{in: "fd00::/8/64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		pool, err := ParsePool(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParsePool(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if pool.String() != tt.want || pool.Subnets() != tt.subnets {
+			t.Errorf("ParsePool(%q) = %s with %d subnets, want %s with %d", tt.in, pool, pool.Subnets(), tt.want, tt.subnets)
+		}
+	}
+}
+
+func TestDefaultDockerPoolsHold31Networks(t *testing.T) {
+	var total int
+	for _, p := range DefaultPools("docker") {
+		total += p.Subnets()
+	}
+	if total != 31 {
+		t.Errorf("default pools hold %d networks, want 31", total)
+	}
+}
+
+func TestLoadDaemonPools(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "daemon.json")
+	config := `{"default-address-pools": [{"base": "10.200.0.0/16", "size": 24}, {"base": "fd00::/104", "size": 112}]}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pools, err := LoadDaemonPools(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 1 || pools[0].String() != "10.200.0.0/16/24" {
+		t.Errorf("pools = %v, want [10.200.0.0/16/24]", pools)
+	}
+
+	pools, err = LoadDaemonPools(filepath.Join(dir, "missing.json"))
+	if err != nil || pools != nil {
+		t.Errorf("missing config: pools = %v, err = %v", pools, err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	prefix := netip.MustParsePrefix
+	pools := []Pool{mustPool("172.18.0.0/16", 16), mustPool("192.168.0.0/16", 20)}
+
+	networks := []Network{
+		{ID: "bridge", Name: "bridge", Subnets: []netip.Prefix{prefix("172.17.0.0/16")}},
+		{ID: "n1", Name: "app", Subnets: []netip.Prefix{prefix("172.18.0.0/16")}},
+		{ID: "n2", Name: "stale", Subnets: []netip.Prefix{prefix("192.168.0.0/20")}, Unused: true},
+		{ID: "n3", Name: "stale-big", Subnets: []netip.Prefix{prefix("192.168.32.0/19")}, Unused: true},
+		{ID: "n4", Name: "manual", Subnets: []netip.Prefix{prefix("192.168.40.0/24")}},
+	}
+	routes := []Route{
+		{Iface: "eth0", Prefix: prefix("0.0.0.0/0")},
+		{Iface: "docker0", Prefix: prefix("172.17.0.0/16")},
+		{Iface: "wg0", Prefix: prefix("192.168.16.0/24")},
+	}
+
+	report := Analyze(networks, routes, pools)
+
+	byName := make(map[string]Subnet)
+	for _, s := range report.Subnets {
+		byName[s.Network] = s
+	}
+
+	if s := byName["bridge"]; s.Pool != "" || len(s.Overlaps) != 0 {
+		t.Errorf("bridge = %+v, want no pool and no overlaps", s)
+	}
+	if s := byName["stale-big"]; s.Pool != "192.168.0.0/16/20" || s.Slots != 2 {
+		t.Errorf("stale-big = %+v, want 2 slots of 192.168.0.0/16/20", s)
+	}
+	if s := byName["manual"]; !reflect.DeepEqual(s.Overlaps, []string{"192.168.32.0/19 (stale-big)"}) {
+		t.Errorf("manual overlaps = %v", s.Overlaps)
+	}
+
+	want := []PoolUsage{
+		{Pool: "172.18.0.0/16/16", Total: 1, Used: 1, Free: 0},
+		// stale (1) + stale-big (2, covering manual) + the wg0 route (1).
+		{Pool: "192.168.0.0/16/20", Total: 16, Used: 4, Free: 12, Reclaimable: 2},
+	}
+	if !reflect.DeepEqual(report.Pools, want) {
+		t.Errorf("pools = %+v, want %+v", report.Pools, want)
+	}
+
+	if got := report.Reclaimable(); !reflect.DeepEqual(got, []string{"n3", "n2"}) {
+		t.Errorf("Reclaimable() = %v, want [n3 n2]", got)
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0100A8C0	0003	0	0	100	00000000	0	0	0
+docker0	000011AC	00000000	0001	0	0	0	0000FFFF	0	0	0
+wg0	0010A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+`
+
+	routes, err := parseRoutes(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, r := range routes {
+		got = append(got, r.Iface+" "+r.Prefix.String())
+	}
+	want := []string{"eth0 0.0.0.0/0", "docker0 172.17.0.0/16", "wg0 192.168.16.0/24"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package ipam
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// procRoutes is the kernel's IPv4 routing table on Linux.
+const procRoutes = "/proc/net/route"
+
+// HostRoutes returns the IPv4 routes of the host dockr runs on. They only
+// matter when the engine runs on the same host. On systems without
+// /proc/net/route it returns no routes.
+func HostRoutes() ([]Route, error) {
+	f, err := os.Open(procRoutes)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read host routes: %w", err)
+	}
+	defer f.Close()
+
+	return parseRoutes(f)
+}
+
+// parseRoutes parses the /proc/net/route format: a header line, then
+// whitespace-separated fields with the destination and mask as
+// little-endian hex.
+func parseRoutes(r io.Reader) ([]Route, error) {
+	var routes []Route
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		dest, err := hexAddr(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := hex.DecodeString(fields[7])
+		if err != nil || len(mask) != 4 {
+			return nil, fmt.Errorf("invalid route mask %q", fields[7])
+		}
+
+		ones := bits.OnesCount32(binary.LittleEndian.Uint32(mask))
+		routes = append(routes, Route{Iface: fields[0], Prefix: netip.PrefixFrom(dest, ones).Masked()})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read host routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+func hexAddr(s string) (netip.Addr, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return netip.Addr{}, fmt.Errorf("invalid route destination %q", s)
+	}
+	return netip.AddrFrom4([4]byte{b[3], b[2], b[1], b[0]}), nil
+}
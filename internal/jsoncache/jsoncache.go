@@ -0,0 +1,130 @@
+// Package jsoncache keeps values in a JSON file between runs, keyed by string
+// and trusted for a limited time.
+package jsoncache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Options configure a cache.
+type Options[T any] struct {
+	// Name is what the cache holds in error messages, e.g. "registry".
+	Name string
+	// TTL is how long a value is trusted; zero trusts it forever.
+	TTL time.Duration
+	// Stamp returns when a value was stored.
+	Stamp func(T) time.Time
+	// Prune drops expired values on Save. Without it they are kept for
+	// lookups of any age.
+	Prune bool
+}
+
+// Cache is a JSON file of values. It is safe for concurrent use.
+type Cache[T any] struct {
+	path string
+	opts Options[T]
+
+	mu      sync.Mutex
+	entries map[string]T
+	dirty   bool
+}
+
+// Load reads the cache at path; a missing file is an empty cache.
+func Load[T any](path string, opts Options[T]) (*Cache[T], error) {
+	c := &Cache[T]{path: path, opts: opts, entries: make(map[string]T)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s cache: %w", opts.Name, err)
+	}
+
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s cache %s: %w", opts.Name, path, err)
+	}
+
+	return c, nil
+}
+
+// Get returns the value stored for key unless it is older than the TTL.
+func (c *Cache[T]) Get(key string, now time.Time) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.entries[key]
+	if !ok || c.expired(v, now) {
+		var zero T
+		return zero, false
+	}
+
+	return v, true
+}
+
+// Lookup returns the value stored for key, whatever its age.
+func (c *Cache[T]) Lookup(key string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+// Put stores a value.
+func (c *Cache[T]) Put(key string, v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = v
+	c.dirty = true
+}
+
+// Save writes the cache back if it changed.
+func (c *Cache[T]) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	if c.opts.Prune {
+		now := time.Now()
+		for k, v := range c.entries {
+			if c.expired(v, now) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s cache directory: %w", c.opts.Name, err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s cache: %w", c.opts.Name, err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write %s cache: %w", c.opts.Name, err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+func (c *Cache[T]) expired(v T, now time.Time) bool {
+	return c.opts.TTL > 0 && now.Sub(c.opts.Stamp(v)) > c.opts.TTL
+}
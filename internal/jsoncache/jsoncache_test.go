@@ -0,0 +1,57 @@
+package jsoncache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type entry struct {
+	Value    string    `json:"value"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "dockr", "cache.json")
+	opts := func(prune bool) Options[entry] {
+		return Options[entry]{Name: "test", TTL: time.Hour, Stamp: func(e entry) time.Time { return e.StoredAt }, Prune: prune}
+	}
+
+	tests := []struct {
+		name      string
+		prune     bool
+		wantStale bool
+	}{
+		{name: "keeps expired values", prune: false, wantStale: true},
+		{name: "prunes expired values", prune: true, wantStale: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(path, opts(tt.prune))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Put("fresh", entry{Value: "a", StoredAt: now})
+			c.Put("stale", entry{Value: "b", StoredAt: now.Add(-2 * time.Hour)})
+			if err := c.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err = Load(path, opts(tt.prune))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, ok := c.Get("fresh", now); !ok || v.Value != "a" {
+				t.Errorf("fresh: got %+v, %v", v, ok)
+			}
+			if _, ok := c.Get("stale", now); ok {
+				t.Error("stale: expected Get to ignore an expired value")
+			}
+			if _, ok := c.Lookup("stale"); ok != tt.wantStale {
+				t.Errorf("stale: expected Lookup %v, got %v", tt.wantStale, ok)
+			}
+		})
+	}
+}
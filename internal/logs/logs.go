@@ -0,0 +1,292 @@
+// Package logs measures the log files engines keep for containers and
+// truncates or rotates the oversized ones.
+package logs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+// Drivers that write a plain file at the container's LogPath. The local
+// driver keeps its files in a binary format and rotates them by default.
+const (
+	DriverJSONFile = "json-file"
+	DriverLocal    = "local"
+	DriverK8sFile  = "k8s-file" // Podman
+)
+
+// Container is the logging setup of a container as reported by inspect.
+type Container struct {
+	ID      string
+	Name    string
+	State   string
+	Driver  string
+	LogPath string
+	// Options are the log driver options, daemon defaults included.
+	Options map[string]string
+}
+
+// Entry is the log usage of one container.
+type Entry struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Driver string `json:"driver"`
+	Path   string `json:"path,omitempty"`
+	// Size is the size of the current log file plus its rotated files.
+	Size    int64 `json:"size"`
+	Current int64 `json:"current"`
+	Files   int   `json:"files"`
+	// MaxSize and MaxFiles are the rotation settings; MaxSize 0 means none.
+	MaxSize  int64 `json:"max_size,omitempty"`
+	MaxFiles int   `json:"max_files,omitempty"`
+	// Unrotated marks logs that grow without limit.
+	Unrotated bool `json:"unrotated"`
+	// Oversized marks logs larger than the report's threshold.
+	Oversized bool `json:"oversized"`
+	// Note explains why the size is unknown, e.g. a driver without a local file.
+	Note string `json:"note,omitempty"`
+}
+
+// Report is the outcome of Analyze, the largest logs first.
+type Report struct {
+	Threshold int64   `json:"threshold"`
+	Entries   []Entry `json:"entries"`
+}
+
+// Total returns the disk space used by all logs.
+func (r *Report) Total() int64 {
+	var total int64
+	for _, e := range r.Entries {
+		total += e.Size
+	}
+	return total
+}
+
+// Oversized returns the entries larger than the threshold.
+func (r *Report) Oversized() []Entry {
+	var entries []Entry
+	for _, e := range r.Entries {
+		if e.Oversized {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Shrinkable reports whether Truncate or Rotate would bring the entry under
+// threshold, and otherwise why it is left alone. Both only touch the current
+// file, so logs over the threshold because of their rotated files, which the
+// engine removes on its own, are not shrinkable; neither is the local driver.
+func (e Entry) Shrinkable(threshold int64) (bool, string) {
+	switch {
+	case e.Path == "":
+		return false, "not stored in a local file"
+	case e.Driver == DriverLocal:
+		return false, "the local driver rotates on its own"
+	case e.Current <= threshold:
+		return false, fmt.Sprintf("the current file is only %s, the rest is rotated files the engine removes",
+			domain.HumanSize(e.Current))
+	}
+	return true, ""
+}
+
+// Analyze measures the log files of the containers. root is prepended to
+// every LogPath, for when the engine's data directory is mounted elsewhere
+// (e.g. dockr running in a container with / of the host at /host); it may
+// be empty. Logs larger than threshold are flagged as oversized.
+func Analyze(containers []Container, root string, threshold int64) *Report {
+	report := &Report{Threshold: threshold}
+
+	for _, c := range containers {
+		e := Entry{ID: c.ID, Name: c.Name, State: c.State, Driver: c.Driver}
+		e.MaxSize, e.MaxFiles = rotation(c.Driver, c.Options)
+
+		switch {
+		case !storesFile(c.Driver):
+			e.Note = "not stored in a local file"
+		case c.LogPath == "":
+			e.Note = "no log path reported"
+		default:
+			e.Path = filepath.Join(root, c.LogPath)
+			e.Unrotated = e.MaxSize == 0
+			if err := measure(&e); err != nil {
+				e.Note = err.Error()
+			}
+		}
+
+		e.Oversized = threshold > 0 && e.Size > threshold
+		report.Entries = append(report.Entries, e)
+	}
+
+	slices.SortStableFunc(report.Entries, func(a, b Entry) int {
+		switch {
+		case a.Size > b.Size:
+			return -1
+		case a.Size < b.Size:
+			return 1
+		}
+		return 0
+	})
+
+	return report
+}
+
+func storesFile(driver string) bool {
+	return driver == DriverJSONFile || driver == DriverLocal || driver == DriverK8sFile
+}
+
+// rotation returns the effective max-size and max-file of a driver.
+func rotation(driver string, opts map[string]string) (int64, int) {
+	maxSize, maxFiles := int64(0), 1
+	if driver == DriverLocal {
+		// Defaults of the local driver.
+		maxSize, maxFiles = 20*1024*1024, 5
+	}
+
+	if v, ok := opts["max-size"]; ok && v != "" && v != "-1" {
+		if size, err := domain.ParseByteSize(v); err == nil && size > 0 {
+			maxSize = int64(size)
+		}
+	}
+	if v, ok := opts["max-file"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxFiles = n
+		}
+	}
+
+	return maxSize, maxFiles
+}
+
+// measure adds up the current log file and the files rotated next to it
+// (path.1, path.2.gz, ...).
+func measure(e *Entry) error {
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errors.New("log file not found (is the engine on another host?)")
+		}
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	e.Current = info.Size()
+	e.Size = info.Size()
+	e.Files = 1
+
+	rotated, err := filepath.Glob(globEscape(e.Path) + ".*")
+	if err != nil {
+		return nil
+	}
+	for _, path := range rotated {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			e.Size += info.Size()
+			e.Files++
+		}
+	}
+
+	return nil
+}
+
+func globEscape(path string) string {
+	var escaped []rune
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[', '\\':
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, r)
+	}
+	return string(escaped)
+}
+
+// Truncate empties the current log file. The engine keeps writing to it at
+// the new end, so running containers are not disturbed.
+func Truncate(e Entry) error {
+	if err := checkWritable(e); err != nil {
+		return err
+	}
+
+	if err := os.Truncate(e.Path, 0); err != nil {
+		return fmt.Errorf("failed to truncate log of %s: %w", e.Name, err)
+	}
+
+	return nil
+}
+
+// Rotate compresses the current log file to path.dockr-<time>.gz next to it
+// and then truncates it, keeping the history at a fraction of the size.
+// As with logrotate's copytruncate, lines written between the copy and the
+// truncation are lost.
+func Rotate(e Entry, now time.Time) (string, error) {
+	if err := checkWritable(e); err != nil {
+		return "", err
+	}
+
+	dst := fmt.Sprintf("%s.dockr-%s.gz", e.Path, now.UTC().Format("20060102T150405Z"))
+	if err := compress(e.Path, dst); err != nil {
+		_ = os.Remove(dst)
+		return "", fmt.Errorf("failed to rotate log of %s: %w", e.Name, err)
+	}
+
+	if err := os.Truncate(e.Path, 0); err != nil {
+		return dst, fmt.Errorf("failed to truncate log of %s: %w", e.Name, err)
+	}
+
+	return dst, nil
+}
+
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// checkWritable refuses logs that cannot be handled safely and explains
+// permission problems, which usually mean dockr is not running as root.
+func checkWritable(e Entry) error {
+	if e.Path == "" {
+		return fmt.Errorf("log of %s is not a local file", e.Name)
+	}
+	// The local driver's files are framed binary; cutting them is up to the engine.
+	if e.Driver == DriverLocal {
+		return fmt.Errorf("log of %s uses the local driver, which rotates on its own", e.Name)
+	}
+
+	f, err := os.OpenFile(e.Path, os.O_WRONLY, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("no permission to modify log of %s (run as root): %w", e.Name, err)
+		}
+		return fmt.Errorf("failed to open log of %s: %w", e.Name, err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,173 @@
+package logs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLog creates a log file of size bytes under root.
+func writeLog(t *testing.T, root, path string, size int) {
+	t.Helper()
+
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(strings.Repeat("x", size)), 0o640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	root := t.TempDir()
+	writeLog(t, root, "/containers/a/a-json.log", 3000)
+	writeLog(t, root, "/containers/a/a-json.log.1", 1000)
+	writeLog(t, root, "/containers/b/b-json.log", 500)
+
+	containers := []Container{
+		{ID: "b", Name: "small", Driver: DriverJSONFile, LogPath: "/containers/b/b-json.log",
+			Options: map[string]string{"max-size": "10m", "max-file": "3"}},
+		{ID: "a", Name: "chatty", Driver: DriverJSONFile, LogPath: "/containers/a/a-json.log"},
+		{ID: "c", Name: "journal", Driver: "journald"},
+		{ID: "d", Name: "gone", Driver: DriverJSONFile, LogPath: "/containers/d/d-json.log"},
+	}
+
+	report := Analyze(containers, root, 2000)
+
+	if got := report.Entries[0]; got.Name != "chatty" || got.Size != 4000 || got.Current != 3000 ||
+		got.Files != 2 || !got.Unrotated || !got.Oversized {
+		t.Errorf("largest entry = %+v", got)
+	}
+
+	byName := make(map[string]Entry)
+	for _, e := range report.Entries {
+		byName[e.Name] = e
+	}
+
+	if e := byName["small"]; e.MaxSize != 10*1024*1024 || e.MaxFiles != 3 || e.Unrotated || e.Oversized {
+		t.Errorf("small = %+v, want rotation 10MB x 3 and not flagged", e)
+	}
+	if e := byName["journal"]; e.Note == "" || e.Unrotated {
+		t.Errorf("journal = %+v, want a note and no rotation warning", e)
+	}
+	if e := byName["gone"]; !strings.Contains(e.Note, "not found") {
+		t.Errorf("gone = %+v, want a missing file note", e)
+	}
+
+	if report.Total() != 4500 || len(report.Oversized()) != 1 {
+		t.Errorf("total = %d, oversized = %d; want 4500, 1", report.Total(), len(report.Oversized()))
+	}
+}
+
+func TestRotationDefaults(t *testing.T) {
+	tests := []struct {
+		driver    string
+		opts      map[string]string
+		wantSize  int64
+		wantFiles int
+	}{
+		{driver: DriverJSONFile, wantSize: 0, wantFiles: 1},
+		{driver: DriverJSONFile, opts: map[string]string{"max-size": "-1"}, wantSize: 0, wantFiles: 1},
+		{driver: DriverJSONFile, opts: map[string]string{"max-size": "100k", "max-file": "2"}, wantSize: 100 * 1024, wantFiles: 2},
+		{driver: DriverLocal, wantSize: 20 * 1024 * 1024, wantFiles: 5},
+	}
+
+	for _, tt := range tests {
+		size, files := rotation(tt.driver, tt.opts)
+		if size != tt.wantSize || files != tt.wantFiles {
+			t.Errorf("rotation(%s, %v) = %d, %d; want %d, %d", tt.driver, tt.opts, size, files, tt.wantSize, tt.wantFiles)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	root := t.TempDir()
+	writeLog(t, root, "/a-json.log", 100)
+
+	e := Analyze([]Container{{Name: "a", Driver: DriverJSONFile, LogPath: "/a-json.log"}}, root, 0).Entries[0]
+	if err := Truncate(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(e.Path); err != nil || info.Size() != 0 {
+		t.Errorf("log after truncate: %v, %v", info, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	root := t.TempDir()
+	writeLog(t, root, "/a-json.log", 100)
+
+	e := Analyze([]Container{{Name: "a", Driver: DriverJSONFile, LogPath: "/a-json.log"}}, root, 0).Entries[0]
+	dst, err := Rotate(e, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := e.Path + ".dockr-20240102T030405Z.gz"; dst != want {
+		t.Errorf("rotated to %s, want %s", dst, want)
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(zr)
+	if len(data) != 100 {
+		t.Errorf("rotated file holds %d bytes, want 100", len(data))
+	}
+
+	if info, _ := os.Stat(e.Path); info.Size() != 0 {
+		t.Errorf("current log holds %d bytes after rotation, want 0", info.Size())
+	}
+}
+
+func TestTruncateRefusesLocalDriver(t *testing.T) {
+	root := t.TempDir()
+	writeLog(t, root, "/container.log", 10)
+
+	e := Analyze([]Container{{Name: "a", Driver: DriverLocal, LogPath: "/container.log"}}, root, 0).Entries[0]
+	if err := Truncate(e); err == nil {
+		t.Error("expected an error for the local driver")
+	}
+}
+
+func TestShrinkable(t *testing.T) {
+	root := t.TempDir()
+	writeLog(t, root, "/rotated-json.log", 500)
+	writeLog(t, root, "/rotated-json.log.1", 1000)
+	writeLog(t, root, "/rotated-json.log.2", 1000)
+	writeLog(t, root, "/chatty-json.log", 3000)
+	writeLog(t, root, "/local.log", 3000)
+
+	report := Analyze([]Container{
+		{Name: "rotated", Driver: DriverJSONFile, LogPath: "/rotated-json.log", Options: map[string]string{"max-size": "1k", "max-file": "3"}},
+		{Name: "chatty", Driver: DriverJSONFile, LogPath: "/chatty-json.log"},
+		{Name: "local", Driver: DriverLocal, LogPath: "/local.log"},
+	}, root, 2000)
+
+	want := map[string]bool{"rotated": false, "chatty": true, "local": false}
+	for _, e := range report.Oversized() {
+		ok, why := e.Shrinkable(report.Threshold)
+		if ok != want[e.Name] {
+			t.Errorf("%s: expected shrinkable %v, got %v (%s)", e.Name, want[e.Name], ok, why)
+		}
+		if !ok && why == "" {
+			t.Errorf("%s: expected a reason", e.Name)
+		}
+	}
+	if n := len(report.Oversized()); n != 3 {
+		t.Errorf("expected 3 oversized logs, got %d", n)
+	}
+}
@@ -0,0 +1,268 @@
+// Package notify posts cleanup run summaries to webhooks, chat and email.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+)
+
+// Notifier types.
+const (
+	TypeWebhook    = "webhook"
+	TypeSlack      = "slack"
+	TypeMattermost = "mattermost"
+	TypeEmail      = "email"
+)
+
+const (
+	defaultTop     = 5
+	defaultTimeout = 15 * time.Second
+)
+
+// DefaultTemplate is the message used by chat and email notifiers unless overridden.
+const DefaultTemplate = `dockr on {{.Host}}: removed {{.Removed}} resources, reclaimed {{human .Reclaimed}}
+{{- if .Skipped}}, {{.Skipped}} skipped{{end}}
+{{- if .Failed}}, {{.Failed}} failed{{end}}
+{{- if .Error}}
+Error: {{.Error}}{{end}}
+{{- if .Top}}
+
+Largest deletions:
+{{- range .Top}}
+• {{.Kind}} {{truncate .Name 60}} ({{human .Size}}){{end}}{{end}}
+{{- if .Failures}}
+
+Failures:
+{{- range .Failures}}
+✖ {{.Kind}} {{truncate .Name 60}}: {{.Error}}{{end}}{{end}}
+`
+
+// DefaultSubject is the email subject used unless overridden.
+const DefaultSubject = `[dockr] {{.Host}}: reclaimed {{human .Reclaimed}}{{if .Failed}}, {{.Failed}} failed{{end}}`
+
+// Config describes a single notification target.
+type Config struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`
+	URL      string            `yaml:"url,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Channel  string            `yaml:"channel,omitempty"`
+	Username string            `yaml:"username,omitempty"`
+	SMTP     SMTPConfig        `yaml:"smtp,omitempty"`
+	Template string            `yaml:"template,omitempty"`
+	Subject  string            `yaml:"subject,omitempty"`
+	Top      int               `yaml:"top,omitempty"`
+	Timeout  time.Duration     `yaml:"timeout,omitempty"`
+	When     *Condition        `yaml:"when,omitempty"`
+}
+
+// Condition restricts when a notifier fires. A run matches if it reclaimed at
+// least MinReclaimed, or if OnError is set and something failed. Without a
+// condition every run is reported.
+type Condition struct {
+	MinReclaimed domain.ByteSize `yaml:"min_reclaimed,omitempty"`
+	OnError      bool            `yaml:"on_error,omitempty"`
+}
+
+// Match reports whether the summary satisfies the condition.
+func (c *Condition) Match(s *Summary) bool {
+	if c == nil {
+		return true
+	}
+	if c.OnError && (s.Failed > 0 || s.Error != "") {
+		return true
+	}
+	return c.MinReclaimed > 0 && s.Reclaimed >= int64(c.MinReclaimed)
+}
+
+// Item is a single resource in a summary.
+type Item struct {
+	Kind  domain.ResourceKind `json:"kind"`
+	ID    string              `json:"id"`
+	Name  string              `json:"name"`
+	Size  int64               `json:"size"`
+	Error string              `json:"error,omitempty"`
+}
+
+// Summary is the data available to templates and sent to generic webhooks.
+type Summary struct {
+	RunID     string        `json:"run_id"`
+	Host      string        `json:"host"`
+	User      string        `json:"user"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Removed   int           `json:"removed"`
+	Skipped   int           `json:"skipped"`
+	Failed    int           `json:"failed"`
+	Reclaimed int64         `json:"reclaimed_bytes"`
+	Error     string        `json:"error,omitempty"`
+	Top       []Item        `json:"top"`
+	Failures  []Item        `json:"failures,omitempty"`
+}
+
+// NewSummary builds the summary of a run with its top largest deletions.
+func NewSummary(run *audit.Run, top int) *Summary {
+	s := &Summary{
+		RunID:     run.ID,
+		Host:      run.Host,
+		User:      run.User,
+		StartedAt: run.StartedAt,
+		Duration:  run.FinishedAt.Sub(run.StartedAt),
+		Removed:   run.Removed(),
+		Failed:    run.Failed(),
+		Reclaimed: run.Reclaimed(),
+		Error:     run.Error,
+	}
+
+	for _, e := range run.Entries {
+		item := Item{Kind: e.Kind, ID: e.ID, Name: e.Name, Size: e.Size, Error: e.Error}
+		switch e.Result {
+		case audit.ResultRemoved:
+			s.Top = append(s.Top, item)
+		case audit.ResultSkipped:
+			s.Skipped++
+		case audit.ResultFailed:
+			s.Failures = append(s.Failures, item)
+		}
+	}
+
+	sort.SliceStable(s.Top, func(i, j int) bool { return s.Top[i].Size > s.Top[j].Size })
+	if len(s.Top) > top {
+		s.Top = s.Top[:top]
+	}
+
+	return s
+}
+
+// Notifier delivers a summary to one target.
+type Notifier interface {
+	Notify(ctx context.Context, s *Summary) error
+}
+
+// Target is a configured notifier together with its condition.
+type Target struct {
+	Config   Config
+	notifier Notifier
+}
+
+// New validates the notification configuration and builds the targets.
+func New(configs []Config) ([]*Target, error) {
+	targets := make([]*Target, 0, len(configs))
+
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("%s-%d", cfg.Type, i+1)
+		}
+		if cfg.Top <= 0 {
+			cfg.Top = defaultTop
+		}
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = defaultTimeout
+		}
+
+		n, err := newNotifier(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("notification %q: %w", cfg.Name, err)
+		}
+
+		targets = append(targets, &Target{Config: cfg, notifier: n})
+	}
+
+	return targets, nil
+}
+
+func newNotifier(cfg Config) (Notifier, error) {
+	body, err := parseTemplate("template", cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case TypeWebhook:
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		if cfg.Template == "" {
+			body = nil
+		}
+		return &webhook{cfg: cfg, body: body}, nil
+	case TypeSlack, TypeMattermost:
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		return &chat{cfg: cfg, text: body}, nil
+	case TypeEmail:
+		subject, err := parseTemplate("subject", orDefault(cfg.Subject, DefaultSubject))
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.SMTP.validate(); err != nil {
+			return nil, err
+		}
+		return &email{cfg: cfg, subject: subject, body: body}, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q (expected webhook, slack, mattermost or email)", cfg.Type)
+	}
+}
+
+// Send delivers the run summary to every target whose condition matches and
+// returns the combined delivery errors.
+func Send(ctx context.Context, targets []*Target, run *audit.Run) error {
+	var errs []error
+
+	for _, t := range targets {
+		s := NewSummary(run, t.Config.Top)
+		if !t.Config.When.Match(s) {
+			continue
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, t.Config.Timeout)
+		err := t.notifier.Notify(tctx, s)
+		cancel()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("notification %q: %w", t.Config.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+var funcs = template.FuncMap{
+	"human":    func(size int64) string { return domain.HumanSize(size) },
+	"truncate": formatter.Truncate,
+	"join":     strings.Join,
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(funcs).Parse(orDefault(text, DefaultTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func render(t *template.Template, s *Summary) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, s); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
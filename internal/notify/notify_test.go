@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+const gb = 1024 * 1024 * 1024
+
+func testRun() *audit.Run {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return &audit.Run{
+		ID:         "20240501T100000-abcd",
+		Host:       "build-01",
+		User:       "ci",
+		StartedAt:  start,
+		FinishedAt: start.Add(42 * time.Second),
+		Entries: []audit.Entry{
+			{Kind: domain.KindImage, ID: "sha256:a", Name: "app:1", Size: 3 * gb, Result: audit.ResultRemoved},
+			{Kind: domain.KindImage, ID: "sha256:b", Name: "app:2", Size: 1 * gb, Result: audit.ResultRemoved},
+			{Kind: domain.KindVolume, ID: "cache", Name: "cache", Size: 4 * gb, Result: audit.ResultRemoved},
+			{Kind: domain.KindVolume, ID: "pgdata", Name: "pgdata", Result: audit.ResultSkipped},
+			{Kind: domain.KindNetwork, ID: "n1", Name: "ci_default", Result: audit.ResultFailed, Error: "has active endpoints"},
+		},
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	s := NewSummary(testRun(), 2)
+
+	if s.Removed != 3 || s.Skipped != 1 || s.Failed != 1 || s.Reclaimed != 8*gb {
+		t.Errorf("unexpected counts: %+v", s)
+	}
+	if len(s.Top) != 2 || s.Top[0].Name != "cache" || s.Top[1].Name != "app:1" {
+		t.Errorf("unexpected top deletions: %+v", s.Top)
+	}
+	if len(s.Failures) != 1 || s.Failures[0].Error != "has active endpoints" {
+		t.Errorf("unexpected failures: %+v", s.Failures)
+	}
+	if s.Duration != 42*time.Second {
+		t.Errorf("unexpected duration: %v", s.Duration)
+	}
+}
+
+func TestCondition(t *testing.T) {
+	ok := &Summary{Reclaimed: 6 * gb}
+	small := &Summary{Reclaimed: 1 * gb}
+	failed := &Summary{Reclaimed: 1 * gb, Failed: 1}
+
+	tests := []struct {
+		name     string
+		cond     *Condition
+		summary  *Summary
+		expected bool
+	}{
+		{"no condition", nil, small, true},
+		{"above threshold", &Condition{MinReclaimed: 5 * gb}, ok, true},
+		{"below threshold", &Condition{MinReclaimed: 5 * gb}, small, false},
+		{"error without on_error", &Condition{MinReclaimed: 5 * gb}, failed, false},
+		{"error with on_error", &Condition{MinReclaimed: 5 * gb, OnError: true}, failed, true},
+		{"only on error", &Condition{OnError: true}, ok, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.Match(tt.summary); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSendSlackAndWebhook(t *testing.T) {
+	var slack chatMessage
+	var generic Summary
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slack":
+			_ = json.NewDecoder(r.Body).Decode(&slack)
+		case "/generic":
+			_ = json.NewDecoder(r.Body).Decode(&generic)
+		case "/quiet":
+			t.Error("notifier below its threshold must not be called")
+		}
+	}))
+	defer srv.Close()
+
+	targets, err := New([]Config{
+		{Type: TypeSlack, URL: srv.URL + "/slack", Channel: "#ops"},
+		{Type: TypeWebhook, URL: srv.URL + "/generic", Top: 1},
+		{Type: TypeMattermost, URL: srv.URL + "/quiet", When: &Condition{MinReclaimed: 100 * gb}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Send(context.Background(), targets, testRun()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"build-01", "reclaimed 8.00 GB", "1 skipped", "1 failed", "• volume cache (4.00 GB)", "✖ network ci_default: has active endpoints"} {
+		if !strings.Contains(slack.Text, want) {
+			t.Errorf("expected %q in slack message:\n%s", want, slack.Text)
+		}
+	}
+	if slack.Channel != "#ops" {
+		t.Errorf("expected channel override, got %q", slack.Channel)
+	}
+	if generic.RunID != "20240501T100000-abcd" || len(generic.Top) != 1 {
+		t.Errorf("unexpected webhook summary: %+v", generic)
+	}
+}
+
+func TestSendReportsFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	targets, err := New([]Config{{Name: "ops", Type: TypeSlack, URL: srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Send(context.Background(), targets, testRun())
+	if err == nil || !strings.Contains(err.Error(), `notification "ops"`) || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("expected delivery error, got %v", err)
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	targets, err := New([]Config{{
+		Type:     TypeEmail,
+		Template: "Reclaimed {{human .Reclaimed}} on {{.Host}}",
+		SMTP:     SMTPConfig{Host: "smtp.example.com", From: "dockr@example.com", To: []string{"ops@example.com", "dev@example.com"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := targets[0].notifier.(*email).message(NewSummary(testRun(), 5), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [dockr] build-01: reclaimed 8.00 GB, 1 failed\r\n",
+		"\r\n\r\nReclaimed 8.00 GB on build-01",
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("expected %q in message:\n%s", want, msg)
+		}
+	}
+
+	targets, err = New([]Config{{
+		Type:    TypeEmail,
+		Subject: "Nettoyé {{.Host}}\r\nBcc: evil@example.com\rX",
+		SMTP:    SMTPConfig{Host: "smtp.example.com", From: "dockr@example.com", To: []string{"ops@example.com"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err = targets[0].notifier.(*email).message(NewSummary(testRun(), 5), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Subject: =?utf-8?q?Nettoy=C3=A9_build-01_Bcc:_evil@example.com_X?=\r\n"; !strings.Contains(string(msg), want) {
+		t.Errorf("expected %q in message:\n%s", want, msg)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []Config{
+		{Type: "pager"},
+		{Type: TypeSlack},
+		{Type: TypeWebhook, URL: "http://x", Template: "{{.Nope"},
+		{Type: TypeEmail, SMTP: SMTPConfig{Host: "smtp"}},
+	}
+
+	for _, cfg := range tests {
+		if _, err := New([]Config{cfg}); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
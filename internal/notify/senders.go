@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// webhook posts the summary as JSON, or the rendered template if one is configured.
+type webhook struct {
+	cfg  Config
+	body *template.Template
+}
+
+func (w *webhook) Notify(ctx context.Context, s *Summary) error {
+	var (
+		body []byte
+		err  error
+	)
+
+	if w.body != nil {
+		var text string
+		text, err = render(w.body, s)
+		body = []byte(text)
+	} else {
+		body, err = json.Marshal(s)
+	}
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, w.cfg.URL, w.cfg.Headers, body)
+}
+
+// chat posts to Slack- and Mattermost-compatible incoming webhooks.
+type chat struct {
+	cfg  Config
+	text *template.Template
+}
+
+type chatMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+func (c *chat) Notify(ctx context.Context, s *Summary) error {
+	text, err := render(c.text, s)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(chatMessage{Text: text, Channel: c.cfg.Channel, Username: c.cfg.Username})
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, c.cfg.URL, c.cfg.Headers, body)
+}
+
+func post(ctx context.Context, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// SMTPConfig holds the mail server settings of an email notifier.
+// STARTTLS is used automatically when the server offers it.
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+func (c SMTPConfig) validate() error {
+	if c.Host == "" || c.From == "" || len(c.To) == 0 {
+		return errors.New("smtp.host, smtp.from and smtp.to are required")
+	}
+	return nil
+}
+
+type email struct {
+	cfg     Config
+	subject *template.Template
+	body    *template.Template
+}
+
+func (e *email) Notify(ctx context.Context, s *Summary) error {
+	msg, err := e.message(s, time.Now())
+	if err != nil {
+		return err
+	}
+
+	port := e.cfg.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(e.cfg.SMTP.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if e.cfg.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.SMTP.Username, e.cfg.SMTP.Password, e.cfg.SMTP.Host)
+	}
+
+	// net/smtp has no context support, so honor the timeout by running it aside.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, e.cfg.SMTP.From, e.cfg.SMTP.To, msg)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// message renders the complete RFC 5322 message.
+func (e *email) message(s *Summary, now time.Time) ([]byte, error) {
+	subject, err := render(e.subject, s)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := render(e.body, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.SMTP.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.SMTP.To, ", "))
+	// A line break would end the header; non-ASCII text needs RFC 2047.
+	subject = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(strings.TrimSpace(subject))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return b.Bytes(), nil
+}
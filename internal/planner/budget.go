@@ -0,0 +1,157 @@
+// Package planner decides which of the unused resources to remove and in what order.
+package planner
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/image"
+)
+
+// Base disruption of removing a resource of each class. Lower is safer.
+const (
+	costDanglingImage = 0.1 // untagged layers nobody can refer to by name
+	costPullableImage = 0.5 // can be pulled again from its registry
+	costLocalImage    = 2.0 // built locally, would have to be rebuilt
+	costContainer     = 1.0 // loses the container's writable layer and logs
+	costVolume        = 4.0 // loses data
+)
+
+// recentWindow is how long after its last use a resource counts as recently used.
+// Removing a resource used just now costs twice as much as one idle for long.
+const recentWindow = 7 * 24 * time.Hour
+
+// Candidate is a resource scored by the budget cost model.
+type Candidate struct {
+	Resource   domain.Resource
+	UniqueSize int64
+	LastUsed   time.Time
+	Cost       float64
+	Reason     string
+}
+
+// Budget is a selection of resources whose unique size covers a target.
+type Budget struct {
+	Target   int64
+	Selected []Candidate
+	Estimate int64
+}
+
+// Covered reports whether the selection frees at least the target.
+func (b *Budget) Covered() bool {
+	return b.Estimate >= b.Target
+}
+
+// Resources returns the selected resources out of all.
+func (b *Budget) Resources(all *domain.UnusedResources) *domain.UnusedResources {
+	selected := make(map[string]bool, len(b.Selected))
+	for _, c := range b.Selected {
+		selected[c.Resource.Key()] = true
+	}
+
+	return all.Select(func(res domain.Resource) bool {
+		return selected[res.Key()]
+	})
+}
+
+// UniqueSize returns the disk space that removing the resource actually frees.
+// Layers shared with other images stay on disk, so they are not counted.
+func UniqueSize(res domain.Resource) int64 {
+	if img, ok := res.Object.(*image.Summary); ok && img.SharedSize > 0 {
+		return max(img.Size-img.SharedSize, 0)
+	}
+	return max(res.Size, 0)
+}
+
+// Score rates how disruptive removing the resource is: the base cost of its
+// class, scaled up by up to 2x the more recently it was used.
+func Score(res domain.Resource, lastUsed, now time.Time) (float64, string) {
+	var (
+		cost   float64
+		reason string
+	)
+
+	switch res.Kind {
+	case domain.KindImage:
+		img, _ := res.Object.(*image.Summary)
+		switch {
+		case img == nil || len(domain.ImageTags(img)) == 0:
+			cost, reason = costDanglingImage, "dangling image"
+		case len(img.RepoDigests) > 0:
+			cost, reason = costPullableImage, "re-pullable image"
+		default:
+			cost, reason = costLocalImage, "local image"
+		}
+	case domain.KindContainer:
+		cost, reason = costContainer, "stopped container"
+	case domain.KindVolume:
+		cost, reason = costVolume, "volume"
+	default:
+		return 0, "no disk space"
+	}
+
+	if lastUsed.IsZero() {
+		lastUsed = res.Created
+	}
+
+	idle := now.Sub(lastUsed)
+	if idle < 0 {
+		idle = 0
+	}
+
+	recency := float64(recentWindow) / float64(recentWindow+idle)
+
+	return cost * (1 + recency), reason
+}
+
+// PlanBudget picks the least disruptive resources whose unique size together
+// covers target bytes. Candidates are taken in order of cost (larger first
+// among equals) until the target is met; afterwards the most disruptive
+// picks that are not needed to stay above the target are dropped again.
+// If everything together is not enough, all candidates are selected.
+func PlanBudget(resources *domain.UnusedResources, target int64, lastUsed map[string]time.Time, now time.Time) *Budget {
+	var candidates []Candidate
+
+	for _, res := range resources.Items() {
+		size := UniqueSize(res)
+		if size == 0 {
+			continue
+		}
+
+		cost, reason := Score(res, lastUsed[res.Key()], now)
+		candidates = append(candidates, Candidate{
+			Resource:   res,
+			UniqueSize: size,
+			LastUsed:   lastUsed[res.Key()],
+			Cost:       cost,
+			Reason:     reason,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Cost != candidates[j].Cost {
+			return candidates[i].Cost < candidates[j].Cost
+		}
+		return candidates[i].UniqueSize > candidates[j].UniqueSize
+	})
+
+	b := &Budget{Target: target}
+	for _, c := range candidates {
+		if b.Estimate >= target {
+			break
+		}
+		b.Selected = append(b.Selected, c)
+		b.Estimate += c.UniqueSize
+	}
+
+	// Prune from the most disruptive end while the target stays covered.
+	for i := len(b.Selected) - 1; i >= 0 && b.Covered(); i-- {
+		if b.Estimate-b.Selected[i].UniqueSize >= target {
+			b.Estimate -= b.Selected[i].UniqueSize
+			b.Selected = append(b.Selected[:i], b.Selected[i+1:]...)
+		}
+	}
+
+	return b
+}
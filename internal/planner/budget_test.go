@@ -0,0 +1,151 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+const gb = 1024 * 1024 * 1024
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func daysAgo(n int) int64 {
+	return now.AddDate(0, 0, -n).Unix()
+}
+
+func budgetResources() *domain.UnusedResources {
+	return &domain.UnusedResources{
+		Images: []*image.Summary{
+			{ID: "dangling", Size: 2 * gb, Created: daysAgo(30)},
+			{ID: "pullable", RepoTags: []string{"nginx:1.25"}, RepoDigests: []string{"nginx@sha256:abc"}, Size: 6 * gb, SharedSize: 1 * gb, Created: daysAgo(60)},
+			{ID: "local", RepoTags: []string{"my-app:dev"}, Size: 8 * gb, Created: daysAgo(60)},
+		},
+		Containers: []*container.Summary{
+			{ID: "stopped", SizeRw: 3 * gb, Created: daysAgo(90)},
+		},
+		Volumes: []*volume.Volume{
+			{Name: "pgdata", UsageData: &volume.UsageData{Size: 20 * gb}},
+		},
+	}
+}
+
+func TestScore(t *testing.T) {
+	old := domain.ImageResource(&image.Summary{RepoTags: []string{"app:1"}, RepoDigests: []string{"app@sha256:1"}})
+	fresh, _ := Score(old, now, now)
+	stale, reason := Score(old, now.AddDate(-1, 0, 0), now)
+
+	if reason != "re-pullable image" {
+		t.Errorf("unexpected reason: %s", reason)
+	}
+	if fresh <= stale {
+		t.Errorf("recently used resources must cost more: fresh=%.2f stale=%.2f", fresh, stale)
+	}
+	if fresh != 2*costPullableImage {
+		t.Errorf("expected resource used just now to cost twice the base, got %.2f", fresh)
+	}
+
+	// Some engines list "<none>:<none>" as the tag of a dangling image.
+	placeholder := domain.ImageResource(&image.Summary{RepoTags: []string{"<none>:<none>"}, RepoDigests: []string{"app@sha256:1"}})
+	if _, reason := Score(placeholder, time.Time{}, now); reason != "dangling image" {
+		t.Errorf("expected a dangling image, got %s", reason)
+	}
+}
+
+func TestUniqueSize(t *testing.T) {
+	img := domain.ImageResource(&image.Summary{Size: 6 * gb, SharedSize: 1 * gb})
+	if got := UniqueSize(img); got != 5*gb {
+		t.Errorf("expected shared layers to be excluded, got %d", got)
+	}
+
+	unknown := domain.ImageResource(&image.Summary{Size: 6 * gb, SharedSize: -1})
+	if got := UniqueSize(unknown); got != 6*gb {
+		t.Errorf("expected full size when shared size is unknown, got %d", got)
+	}
+}
+
+func TestPlanBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   int64
+		expected []string
+		covered  bool
+	}{
+		{
+			name:     "dangling image is enough",
+			target:   1 * gb,
+			expected: []string{"dangling"},
+			covered:  true,
+		},
+		{
+			name:     "pullable image before container and local image",
+			target:   6 * gb,
+			expected: []string{"dangling", "pullable"},
+			covered:  true,
+		},
+		{
+			name:     "stopped container next",
+			target:   9 * gb,
+			expected: []string{"dangling", "pullable", "stopped"},
+			covered:  true,
+		},
+		{
+			name:     "volume only when needed, without unneeded extra picks",
+			target:   30 * gb,
+			expected: []string{"dangling", "pullable", "stopped", "pgdata"},
+			covered:  true,
+		},
+		{
+			name:     "not enough",
+			target:   100 * gb,
+			expected: []string{"dangling", "pullable", "stopped", "local", "pgdata"},
+			covered:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := PlanBudget(budgetResources(), tt.target, nil, now)
+
+			var ids []string
+			for _, c := range b.Selected {
+				ids = append(ids, c.Resource.ID)
+			}
+
+			if len(ids) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.expected[i] {
+					t.Fatalf("expected %v, got %v", tt.expected, ids)
+				}
+			}
+			if b.Covered() != tt.covered {
+				t.Errorf("expected covered=%v with estimate %d", tt.covered, b.Estimate)
+			}
+		})
+	}
+}
+
+func TestPlanBudgetPrunesUnneeded(t *testing.T) {
+	resources := &domain.UnusedResources{
+		Images: []*image.Summary{
+			{ID: "small", Size: 1 * gb, Created: daysAgo(30)},
+			{ID: "big", RepoTags: []string{"app:1"}, RepoDigests: []string{"app@sha256:1"}, Size: 10 * gb, Created: daysAgo(30)},
+		},
+	}
+
+	b := PlanBudget(resources, 10*gb, nil, now)
+	if len(b.Selected) != 1 || b.Selected[0].Resource.ID != "big" {
+		t.Errorf("expected the small image to be pruned, got %+v", b.Selected)
+	}
+
+	selected := b.Resources(resources)
+	if len(selected.Images) != 1 || selected.Images[0].ID != "big" {
+		t.Errorf("unexpected selected resources: %+v", selected.Images)
+	}
+}
@@ -0,0 +1,307 @@
+package planner
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/image"
+)
+
+// TagPolicy picks the canonical tag among tags of the same image.
+type TagPolicy string
+
+const (
+	// TagPolicyLatest keeps "latest", else the highest version.
+	TagPolicyLatest TagPolicy = "latest"
+	// TagPolicyVersion keeps the highest version-like tag.
+	TagPolicyVersion TagPolicy = "version"
+	// TagPolicyShortest keeps the shortest reference.
+	TagPolicyShortest TagPolicy = "shortest"
+)
+
+// ParseTagPolicy validates the value of the --keep-tag flag.
+func ParseTagPolicy(s string) (TagPolicy, error) {
+	switch p := TagPolicy(s); p {
+	case TagPolicyLatest, TagPolicyVersion, TagPolicyShortest:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown tag policy %q (expected latest, version or shortest)", s)
+	}
+}
+
+// DuplicatePolicy decides which tags and images are redundant.
+type DuplicatePolicy struct {
+	Keep TagPolicy
+	// AcrossRepositories keeps one tag per image instead of one per repository.
+	AcrossRepositories bool
+	// Collapse moves the tags of identical-content duplicates to one image,
+	// so the duplicates can be deleted.
+	Collapse bool
+	// ExcludeTags are never untagged.
+	ExcludeTags []string
+}
+
+// TagChange removes the reference Ref from ImageID or, when Target is set,
+// moves it to the image Target.
+type TagChange struct {
+	Ref     string
+	ImageID string
+	Target  string
+}
+
+// Resource is the view of the change observers and the audit log see.
+func (c TagChange) Resource() domain.Resource {
+	return domain.Resource{Kind: domain.KindTag, ID: c.Ref, Name: c.Ref, Object: c}
+}
+
+// TagGroup is the set of tags of one image (and repository) with the one that stays.
+type TagGroup struct {
+	ImageID    string
+	Repository string
+	Canonical  string
+	Redundant  []string
+}
+
+// ContentGroup is a set of images with identical layers and configuration.
+type ContentGroup struct {
+	Digest    string
+	Canonical string
+	// Duplicates are the other images; Used ones cannot be collapsed.
+	Duplicates []*image.Summary
+	Used       []string
+	// Apparent is the size of the duplicates as listed; Wasted is what
+	// they take on disk beyond layers shared with other images.
+	Apparent int64
+	Wasted   int64
+}
+
+// Duplicates is the outcome of PlanDuplicates.
+type Duplicates struct {
+	Policy  DuplicatePolicy
+	Tags    []TagGroup
+	Content []ContentGroup
+}
+
+// Wasted returns the disk space taken by identical-content duplicates.
+func (d *Duplicates) Wasted() int64 {
+	var wasted int64
+	for _, g := range d.Content {
+		wasted += g.Wasted
+	}
+	return wasted
+}
+
+// Untag returns the removal of every redundant tag.
+func (d *Duplicates) Untag() []TagChange {
+	var changes []TagChange
+	for _, g := range d.Tags {
+		for _, ref := range g.Redundant {
+			changes = append(changes, TagChange{Ref: ref, ImageID: g.ImageID})
+		}
+	}
+	return changes
+}
+
+// Collapse returns the moves of the duplicates' tags to their canonical
+// image and the duplicates to delete afterwards. Duplicates used by
+// containers are left alone.
+func (d *Duplicates) Collapse() ([]TagChange, []*image.Summary) {
+	var (
+		changes []TagChange
+		images  []*image.Summary
+	)
+	for _, g := range d.Content {
+		for _, dup := range g.Duplicates {
+			if slices.Contains(g.Used, dup.ID) {
+				continue
+			}
+			for _, ref := range domain.ImageTags(dup) {
+				changes = append(changes, TagChange{Ref: ref, ImageID: dup.ID, Target: g.Canonical})
+			}
+			images = append(images, dup)
+		}
+	}
+	return changes, images
+}
+
+// PlanDuplicates groups images by ID to find redundant tags and by content
+// digest to find images built from identical content. digests maps image IDs
+// to their content digest and imageUsers maps image IDs to the containers
+// using them. With policy.Collapse, tag groups already account for the tags
+// moved to the canonical images.
+func PlanDuplicates(images []*image.Summary, digests map[string]string, imageUsers map[string][]string, policy DuplicatePolicy) *Duplicates {
+	d := &Duplicates{Policy: policy}
+
+	byDigest := make(map[string][]*image.Summary)
+	var order []string
+	for _, img := range images {
+		digest, ok := digests[img.ID]
+		if !ok {
+			continue
+		}
+		if _, seen := byDigest[digest]; !seen {
+			order = append(order, digest)
+		}
+		byDigest[digest] = append(byDigest[digest], img)
+	}
+
+	tags := make(map[string][]string, len(images))
+	for _, img := range images {
+		tags[img.ID] = append(tags[img.ID], domain.ImageTags(img)...)
+	}
+
+	for _, digest := range order {
+		members := byDigest[digest]
+		if len(members) < 2 {
+			continue
+		}
+
+		slices.SortStableFunc(members, func(a, b *image.Summary) int {
+			return cmp.Or(
+				-cmp.Compare(len(imageUsers[a.ID]), len(imageUsers[b.ID])),
+				-cmp.Compare(len(domain.ImageTags(a)), len(domain.ImageTags(b))),
+				-cmp.Compare(a.Created, b.Created),
+				cmp.Compare(a.ID, b.ID),
+			)
+		})
+
+		g := ContentGroup{Digest: digest, Canonical: members[0].ID, Duplicates: members[1:]}
+		for _, dup := range g.Duplicates {
+			if len(imageUsers[dup.ID]) > 0 {
+				g.Used = append(g.Used, dup.ID)
+			} else if policy.Collapse {
+				tags[g.Canonical] = append(tags[g.Canonical], tags[dup.ID]...)
+				delete(tags, dup.ID)
+			}
+
+			g.Apparent += max(dup.Size, 0)
+			g.Wasted += UniqueSize(domain.ImageResource(dup))
+		}
+
+		d.Content = append(d.Content, g)
+	}
+
+	for _, img := range images {
+		refs, ok := tags[img.ID]
+		if !ok || len(refs) < 2 {
+			continue
+		}
+
+		for _, repoRefs := range groupByRepository(refs, policy.AcrossRepositories) {
+			if len(repoRefs) < 2 {
+				continue
+			}
+
+			canonical := pickCanonical(repoRefs, policy.Keep)
+			g := TagGroup{ImageID: img.ID, Canonical: canonical}
+			if !policy.AcrossRepositories {
+				g.Repository = repository(canonical)
+			}
+			for _, ref := range repoRefs {
+				if ref != canonical && !isExcluded(ref, policy.ExcludeTags) {
+					g.Redundant = append(g.Redundant, ref)
+				}
+			}
+			if len(g.Redundant) > 0 {
+				d.Tags = append(d.Tags, g)
+			}
+		}
+	}
+
+	return d
+}
+
+func groupByRepository(refs []string, across bool) [][]string {
+	if across {
+		return [][]string{refs}
+	}
+
+	byRepo := make(map[string][]string)
+	var repos []string
+	for _, ref := range refs {
+		repo := repository(ref)
+		if _, ok := byRepo[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		byRepo[repo] = append(byRepo[repo], ref)
+	}
+
+	groups := make([][]string, 0, len(repos))
+	for _, repo := range repos {
+		groups = append(groups, byRepo[repo])
+	}
+	return groups
+}
+
+// repository strips the tag from a reference; a colon in the registry
+// host's port is not a tag separator.
+func repository(ref string) string {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref
+	}
+	return ref[:i]
+}
+
+func tag(ref string) string {
+	return strings.TrimPrefix(ref, repository(ref)+":")
+}
+
+func pickCanonical(refs []string, policy TagPolicy) string {
+	sorted := slices.Clone(refs)
+	slices.SortFunc(sorted, func(a, b string) int {
+		switch policy {
+		case TagPolicyShortest:
+			return cmp.Or(cmp.Compare(len(a), len(b)), cmp.Compare(a, b))
+		case TagPolicyLatest:
+			if la, lb := tag(a) == "latest", tag(b) == "latest"; la != lb {
+				if la {
+					return -1
+				}
+				return 1
+			}
+		}
+		return cmp.Or(-compareVersions(tag(a), tag(b)), cmp.Compare(a, b))
+	})
+	return sorted[0]
+}
+
+// compareVersions orders version-like tags ("1.10.2", "v2-alpine") by their
+// numeric parts; tags without a leading number sort below all versions.
+func compareVersions(a, b string) int {
+	va, vb := versionParts(a), versionParts(b)
+	if (va == nil) != (vb == nil) {
+		if va == nil {
+			return -1
+		}
+		return 1
+	}
+	return slices.Compare(va, vb)
+}
+
+func versionParts(t string) []int {
+	t = strings.TrimPrefix(t, "v")
+
+	var parts []int
+	for _, field := range strings.FieldsFunc(t, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func isExcluded(ref string, excludeTags []string) bool {
+	for _, excluded := range excludeTags {
+		if strings.Contains(ref, excluded) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package planner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+func TestPickCanonical(t *testing.T) {
+	refs := []string{"app:build-457", "app:1.9.0", "app:latest", "app:1.10.2", "app:v1.10"}
+
+	tests := []struct {
+		policy TagPolicy
+		want   string
+	}{
+		{policy: TagPolicyLatest, want: "app:latest"},
+		{policy: TagPolicyVersion, want: "app:1.10.2"},
+		{policy: TagPolicyShortest, want: "app:1.9.0"},
+	}
+
+	for _, tt := range tests {
+		if got := pickCanonical(refs, tt.policy); got != tt.want {
+			t.Errorf("pickCanonical(%s) = %s, want %s", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	tests := map[string]string{
+		"app:1.0":                   "app",
+		"registry:5000/team/app:v2": "registry:5000/team/app",
+		"registry:5000/team/app":    "registry:5000/team/app",
+	}
+
+	for ref, want := range tests {
+		if got := repository(ref); got != want {
+			t.Errorf("repository(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestPlanDuplicates(t *testing.T) {
+	images := []*image.Summary{
+		{ID: "sha256:a", Created: 100, Size: 500, RepoTags: []string{"app:latest", "app:1.0", "app:sha-1", "mirror/app:1.0"}},
+		{ID: "sha256:b", Created: 200, Size: 500, SharedSize: 450, RepoTags: []string{"app:rebuilt"}},
+		{ID: "sha256:c", Created: 300, Size: 500, RepoTags: []string{"app:in-use"}},
+		{ID: "sha256:d", Created: 50, Size: 80, RepoTags: []string{"tool:1", "tool:prod-1"}},
+	}
+	digests := map[string]string{"sha256:a": "x", "sha256:b": "x", "sha256:c": "x", "sha256:d": "y"}
+	users := map[string][]string{"sha256:c": {"web"}}
+
+	d := PlanDuplicates(images, digests, users, DuplicatePolicy{Keep: TagPolicyLatest, ExcludeTags: []string{"prod"}})
+
+	// The image used by a container is canonical among identical ones.
+	if len(d.Content) != 1 || d.Content[0].Canonical != "sha256:c" {
+		t.Fatalf("content groups = %+v", d.Content)
+	}
+	if g := d.Content[0]; g.Apparent != 1000 || g.Wasted != 550 || len(g.Used) != 0 {
+		t.Errorf("content group = %+v, want apparent 1000, wasted 550", g)
+	}
+
+	wantUntag := []TagChange{
+		{Ref: "app:1.0", ImageID: "sha256:a"},
+		{Ref: "app:sha-1", ImageID: "sha256:a"},
+	}
+	if got := d.Untag(); !reflect.DeepEqual(got, wantUntag) {
+		t.Errorf("Untag() = %+v, want %+v", got, wantUntag)
+	}
+
+	moves, dups := d.Collapse()
+	if len(moves) != 5 || len(dups) != 2 || moves[0].Target != "sha256:c" {
+		t.Errorf("Collapse() = %+v, %d images", moves, len(dups))
+	}
+}
+
+func TestPlanDuplicatesCollapseMergesTags(t *testing.T) {
+	images := []*image.Summary{
+		{ID: "sha256:a", Created: 200, RepoTags: []string{"app:2.0"}},
+		{ID: "sha256:b", Created: 100, RepoTags: []string{"app:1.0"}},
+	}
+	digests := map[string]string{"sha256:a": "x", "sha256:b": "x"}
+
+	d := PlanDuplicates(images, digests, nil, DuplicatePolicy{Keep: TagPolicyVersion, Collapse: true})
+
+	want := []TagGroup{{ImageID: "sha256:a", Repository: "app", Canonical: "app:2.0", Redundant: []string{"app:1.0"}}}
+	if !reflect.DeepEqual(d.Tags, want) {
+		t.Errorf("tags = %+v, want %+v", d.Tags, want)
+	}
+}
@@ -0,0 +1,241 @@
+package planner
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+)
+
+// GitLab Runner labels every container, network and volume of a job with these keys.
+const (
+	GitLabJobLabel      = "com.gitlab.gitlab-runner.job.id"
+	GitLabPipelineLabel = "com.gitlab.gitlab-runner.pipeline.id"
+	GitLabBranchLabel   = "com.gitlab.gitlab-runner.job.ref"
+)
+
+// GroupPolicy decides which CI groups to remove.
+type GroupPolicy struct {
+	// GroupBy are the label keys whose values form a group, e.g. pipeline and job.
+	GroupBy []string
+	// PipelineLabel and BranchLabel identify a group's pipeline and branch
+	// for KeepPipelines. Either may be empty.
+	PipelineLabel string
+	BranchLabel   string
+	// OlderThan is how old the newest member of a group must be.
+	OlderThan time.Duration
+	// KeepPipelines keeps every group of the N most recent pipelines per branch.
+	KeepPipelines int
+}
+
+// Group is the set of resources sharing the values of the GroupBy labels.
+type Group struct {
+	// Key is "key=value" of every GroupBy label the group has, joined by ", ".
+	Key       string
+	Pipeline  string
+	Branch    string
+	Resources []domain.Resource
+	// Kept are members of a removed group that stay, with the reason in Reason.
+	Kept   []domain.Resource
+	Newest time.Time
+	Size   int64
+	Remove bool
+	Reason string
+}
+
+// Groups is the outcome of PlanGroups.
+type Groups struct {
+	Policy GroupPolicy
+	Groups []Group
+}
+
+// Resources returns the resources of the removed groups out of all.
+func (g *Groups) Resources(all *domain.UnusedResources) *domain.UnusedResources {
+	selected := make(map[string]bool)
+	for _, group := range g.Groups {
+		if !group.Remove {
+			continue
+		}
+		for _, res := range group.Resources {
+			selected[res.Key()] = true
+		}
+	}
+
+	return all.Select(func(res domain.Resource) bool {
+		return selected[res.Key()]
+	})
+}
+
+// PlanGroups groups the resources by the policy's labels and decides for each
+// group as a whole. A group is removed when its newest member is older than
+// OlderThan, none of its containers is running and its pipeline is not one of
+// the KeepPipelines most recent ones of its branch. imageUsers maps image IDs
+// to the containers using them; images still used outside the removed groups
+// are kept. Resources without any of the labels are ignored.
+func PlanGroups(resources *domain.UnusedResources, policy GroupPolicy, imageUsers map[string][]string, now time.Time) *Groups {
+	byKey := make(map[string]*Group)
+	var order []string
+
+	for _, res := range resources.Items() {
+		var parts []string
+		for _, key := range policy.GroupBy {
+			if value, ok := res.Labels[key]; ok {
+				parts = append(parts, key+"="+value)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		key := strings.Join(parts, ", ")
+		g, ok := byKey[key]
+		if !ok {
+			g = &Group{Key: key}
+			byKey[key] = g
+			order = append(order, key)
+		}
+
+		g.Resources = append(g.Resources, res)
+		g.Size += max(res.Size, 0)
+		if res.Created.After(g.Newest) {
+			g.Newest = res.Created
+		}
+		if g.Pipeline == "" {
+			g.Pipeline = res.Labels[policy.PipelineLabel]
+		}
+		if g.Branch == "" {
+			g.Branch = res.Labels[policy.BranchLabel]
+		}
+	}
+
+	recent := recentPipelines(byKey, policy.KeepPipelines)
+
+	result := &Groups{Policy: policy}
+	for _, key := range order {
+		g := byKey[key]
+		decide(g, policy, recent, now)
+		result.Groups = append(result.Groups, *g)
+	}
+
+	keepUsedImages(result.Groups, imageUsers)
+
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		a, b := result.Groups[i], result.Groups[j]
+		if a.Branch != b.Branch {
+			return a.Branch < b.Branch
+		}
+		return a.Newest.After(b.Newest)
+	})
+
+	return result
+}
+
+// recentPipelines returns the n most recent pipelines of every branch,
+// keyed by branch and pipeline. A pipeline's time is that of its newest group.
+func recentPipelines(groups map[string]*Group, n int) map[[2]string]bool {
+	recent := make(map[[2]string]bool)
+	if n <= 0 {
+		return recent
+	}
+
+	newest := make(map[string]map[string]time.Time)
+	for _, g := range groups {
+		if g.Pipeline == "" {
+			continue
+		}
+		if newest[g.Branch] == nil {
+			newest[g.Branch] = make(map[string]time.Time)
+		}
+		if g.Newest.After(newest[g.Branch][g.Pipeline]) {
+			newest[g.Branch][g.Pipeline] = g.Newest
+		}
+	}
+
+	for branch, pipelines := range newest {
+		ids := make([]string, 0, len(pipelines))
+		for id := range pipelines {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return pipelines[ids[i]].After(pipelines[ids[j]])
+		})
+
+		for _, id := range ids[:min(n, len(ids))] {
+			recent[[2]string{branch, id}] = true
+		}
+	}
+
+	return recent
+}
+
+func decide(g *Group, policy GroupPolicy, recent map[[2]string]bool, now time.Time) {
+	for _, res := range g.Resources {
+		if c, ok := res.Object.(*container.Summary); ok && c.State == "running" {
+			g.Reason = "has running containers"
+			return
+		}
+	}
+
+	if g.Pipeline != "" && recent[[2]string{g.Branch, g.Pipeline}] {
+		branch := g.Branch
+		if branch == "" {
+			branch = "no branch"
+		}
+		g.Reason = fmt.Sprintf("one of the %d most recent pipelines on %s", policy.KeepPipelines, branch)
+		return
+	}
+
+	idle := now.Sub(g.Newest)
+	if idle < policy.OlderThan {
+		g.Reason = fmt.Sprintf("newest member is younger than %s", policy.OlderThan)
+		return
+	}
+
+	g.Remove = true
+	g.Reason = fmt.Sprintf("idle for more than %s", policy.OlderThan)
+}
+
+// keepUsedImages moves images out of removed groups while a container that
+// is not being removed still uses them.
+func keepUsedImages(groups []Group, imageUsers map[string][]string) {
+	removed := make(map[string]bool)
+	for _, g := range groups {
+		if !g.Remove {
+			continue
+		}
+		for _, res := range g.Resources {
+			if res.Kind == domain.KindContainer {
+				removed[res.ID] = true
+			}
+		}
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		if !g.Remove {
+			continue
+		}
+
+		g.Resources = slices.DeleteFunc(g.Resources, func(res domain.Resource) bool {
+			if res.Kind != domain.KindImage {
+				return false
+			}
+			for _, id := range imageUsers[res.ID] {
+				if !removed[id] {
+					g.Kept = append(g.Kept, res)
+					g.Size -= max(res.Size, 0)
+					return true
+				}
+			}
+			return false
+		})
+
+		if len(g.Kept) > 0 {
+			g.Reason += fmt.Sprintf("; %d image(s) still in use are kept", len(g.Kept))
+		}
+	}
+}
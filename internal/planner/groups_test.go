@@ -0,0 +1,122 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func ciLabels(pipeline, job, branch string) map[string]string {
+	return map[string]string{
+		GitLabPipelineLabel: pipeline,
+		GitLabJobLabel:      job,
+		GitLabBranchLabel:   branch,
+	}
+}
+
+func TestPlanGroups(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) int64 { return now.Add(-d).Unix() }
+
+	resources := &domain.UnusedResources{
+		Containers: []*container.Summary{
+			// main: pipelines 1 (old), 2 (old), 3 (recent enough to keep by count)
+			{ID: "c1", State: "exited", Created: ago(72 * time.Hour), SizeRw: 10, Labels: ciLabels("1", "11", "main")},
+			{ID: "c2", State: "exited", Created: ago(48 * time.Hour), SizeRw: 10, Labels: ciLabels("2", "21", "main")},
+			{ID: "c3", State: "exited", Created: ago(30 * time.Hour), SizeRw: 10, Labels: ciLabels("3", "31", "main")},
+			// feature: one old pipeline with a running container, one young pipeline
+			{ID: "c4", State: "running", Created: ago(96 * time.Hour), Labels: ciLabels("4", "41", "feature")},
+			{ID: "c5", State: "exited", Created: ago(time.Hour), Labels: ciLabels("5", "51", "feature")},
+			// not a CI container
+			{ID: "c6", State: "exited", Created: ago(96 * time.Hour)},
+		},
+		Volumes: []*volume.Volume{
+			{Name: "cache-1", CreatedAt: now.Add(-72 * time.Hour).Format(time.RFC3339), Labels: ciLabels("1", "11", "main")},
+		},
+		Images: []*image.Summary{
+			{ID: "sha256:built-1", Created: ago(72 * time.Hour), Size: 100, Labels: ciLabels("1", "11", "main")},
+			{ID: "sha256:built-2", Created: ago(48 * time.Hour), Size: 100, Labels: ciLabels("2", "21", "main")},
+		},
+	}
+
+	// built-2 is also used by a container outside the CI groups.
+	imageUsers := map[string][]string{
+		"sha256:built-1": {"c1"},
+		"sha256:built-2": {"c2", "c6"},
+	}
+
+	policy := GroupPolicy{
+		GroupBy:       []string{GitLabPipelineLabel, GitLabJobLabel},
+		PipelineLabel: GitLabPipelineLabel,
+		BranchLabel:   GitLabBranchLabel,
+		OlderThan:     24 * time.Hour,
+		KeepPipelines: 1,
+	}
+
+	groups := PlanGroups(resources, policy, imageUsers, now)
+
+	type want struct {
+		remove    bool
+		reason    string
+		resources int
+		kept      int
+	}
+	wants := map[string]want{
+		"1": {remove: true, reason: "idle for more than 24h", resources: 3},
+		"2": {remove: true, reason: "still in use", resources: 1, kept: 1},
+		"3": {remove: false, reason: "most recent pipelines on main", resources: 1},
+		"4": {remove: false, reason: "running", resources: 1},
+		"5": {remove: false, reason: "most recent pipelines on feature", resources: 1},
+	}
+
+	if len(groups.Groups) != len(wants) {
+		t.Fatalf("got %d groups, want %d", len(groups.Groups), len(wants))
+	}
+
+	for _, g := range groups.Groups {
+		w, ok := wants[g.Pipeline]
+		if !ok {
+			t.Errorf("unexpected group %s", g.Key)
+			continue
+		}
+		if g.Remove != w.remove || !strings.Contains(g.Reason, w.reason) ||
+			len(g.Resources) != w.resources || len(g.Kept) != w.kept {
+			t.Errorf("group %s: remove=%v reason=%q resources=%d kept=%d, want %+v",
+				g.Key, g.Remove, g.Reason, len(g.Resources), len(g.Kept), w)
+		}
+	}
+
+	// Sorted by branch, newest first.
+	if groups.Groups[0].Branch != "feature" || groups.Groups[2].Pipeline != "3" {
+		t.Errorf("unexpected order: %s, %s", groups.Groups[0].Key, groups.Groups[2].Key)
+	}
+
+	selected := groups.Resources(resources)
+	if len(selected.Containers) != 2 || len(selected.Volumes) != 1 || len(selected.Images) != 1 {
+		t.Errorf("selected %d containers, %d volumes, %d images; want 2, 1, 1",
+			len(selected.Containers), len(selected.Volumes), len(selected.Images))
+	}
+}
+
+func TestPlanGroupsWithoutPipelineRetention(t *testing.T) {
+	now := time.Now()
+
+	resources := &domain.UnusedResources{
+		Containers: []*container.Summary{
+			{ID: "a", State: "exited", Created: now.Add(-2 * time.Hour).Unix(), Labels: map[string]string{"ci.job": "1"}},
+			{ID: "b", State: "exited", Created: now.Add(-10 * time.Minute).Unix(), Labels: map[string]string{"ci.job": "1"}},
+		},
+	}
+
+	groups := PlanGroups(resources, GroupPolicy{GroupBy: []string{"ci.job"}, OlderThan: time.Hour}, nil, now)
+
+	// The newest member decides for the whole group.
+	if len(groups.Groups) != 1 || groups.Groups[0].Remove {
+		t.Fatalf("expected one kept group, got %+v", groups.Groups)
+	}
+}
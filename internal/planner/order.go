@@ -0,0 +1,13 @@
+package planner
+
+import "github.com/DobryySoul/dockr/internal/domain"
+
+// RemovalOrder is the order in which resource kinds are removed. Containers
+// go first because they keep networks, volumes and images in use; images go
+// last because any container removed before may have been their last user.
+var RemovalOrder = []domain.ResourceKind{
+	domain.KindContainer,
+	domain.KindNetwork,
+	domain.KindVolume,
+	domain.KindImage,
+}
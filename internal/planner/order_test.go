@@ -0,0 +1,24 @@
+package planner
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+func TestRemovalOrder(t *testing.T) {
+	if len(RemovalOrder) != len(domain.Kinds) {
+		t.Fatalf("RemovalOrder has %d kinds, want %d", len(RemovalOrder), len(domain.Kinds))
+	}
+	for _, kind := range domain.Kinds {
+		if !slices.Contains(RemovalOrder, kind) {
+			t.Errorf("RemovalOrder misses %s", kind)
+		}
+	}
+
+	// Containers hold the other kinds in use, so they must go first.
+	if RemovalOrder[0] != domain.KindContainer {
+		t.Errorf("RemovalOrder starts with %s, want containers", RemovalOrder[0])
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultContext is the context that means "DOCKER_HOST or the default socket".
+const DefaultContext = "default"
+
+// Endpoint is the Docker connection a context resolves to.
+// An empty Host means the default connection.
+type Endpoint struct {
+	Context       string
+	Host          string
+	TLSDir        string
+	SkipTLSVerify bool
+}
+
+// Env returns the environment variables that make the Docker client connect
+// to the endpoint.
+func (e Endpoint) Env() map[string]string {
+	env := make(map[string]string)
+	if e.Host == "" {
+		return env
+	}
+
+	env["DOCKER_HOST"] = e.Host
+	if e.TLSDir != "" {
+		env["DOCKER_CERT_PATH"] = e.TLSDir
+		if !e.SkipTLSVerify {
+			env["DOCKER_TLS_VERIFY"] = "1"
+		}
+	}
+
+	return env
+}
+
+// Resolve picks the endpoint the Docker CLI would use for the invocation,
+// with the same precedence: --host, --context, $DOCKER_HOST, $DOCKER_CONTEXT,
+// then the current context of the CLI configuration.
+func Resolve(inv *Invocation, getenv func(string) string) (Endpoint, error) {
+	configDir := inv.ConfigDir
+	if configDir == "" {
+		configDir = DefaultConfigDir(getenv)
+	}
+
+	if inv.Context != "" && len(inv.Hosts) > 0 {
+		return Endpoint{}, errors.New("conflicting docker options: --host and --context cannot both be set")
+	}
+
+	switch {
+	case len(inv.Hosts) > 1:
+		return Endpoint{}, errors.New("dockr can only connect to a single --host")
+	case len(inv.Hosts) == 1:
+		return Endpoint{Context: DefaultContext, Host: inv.Hosts[0]}, nil
+	case inv.Context != "":
+		return loadContext(configDir, inv.Context)
+	case getenv("DOCKER_HOST") != "":
+		return Endpoint{Context: DefaultContext}, nil
+	case getenv("DOCKER_CONTEXT") != "":
+		return loadContext(configDir, getenv("DOCKER_CONTEXT"))
+	}
+
+	current, err := currentContext(configDir)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	return loadContext(configDir, current)
+}
+
+// currentContext reads currentContext from config.json.
+func currentContext(configDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultContext, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read docker config: %w", err)
+	}
+
+	var cfg struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("failed to parse docker config: %w", err)
+	}
+
+	if cfg.CurrentContext == "" {
+		return DefaultContext, nil
+	}
+
+	return cfg.CurrentContext, nil
+}
+
+// contextMeta is the part of a context's meta.json that dockr needs.
+type contextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+// loadContext reads a context from the CLI's context store, where contexts
+// live in directories named by the SHA-256 of their name.
+func loadContext(configDir, name string) (Endpoint, error) {
+	if name == DefaultContext {
+		return Endpoint{Context: DefaultContext}, nil
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+
+	data, err := os.ReadFile(filepath.Join(configDir, "contexts", "meta", id, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Endpoint{}, fmt.Errorf("docker context %q not found", name)
+	}
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("failed to read docker context %q: %w", name, err)
+	}
+
+	var meta contextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Endpoint{}, fmt.Errorf("failed to parse docker context %q: %w", name, err)
+	}
+
+	docker, ok := meta.Endpoints["docker"]
+	if !ok || docker.Host == "" {
+		return Endpoint{}, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+	if strings.HasPrefix(docker.Host, "ssh://") {
+		return Endpoint{}, fmt.Errorf("docker context %q uses SSH, which dockr does not support yet", name)
+	}
+
+	endpoint := Endpoint{Context: name, Host: docker.Host, SkipTLSVerify: docker.SkipTLSVerify}
+
+	tlsDir := filepath.Join(configDir, "contexts", "tls", id, "docker")
+	if _, err := os.Stat(filepath.Join(tlsDir, "ca.pem")); err == nil {
+		endpoint.TLSDir = tlsDir
+	}
+
+	return endpoint, nil
+}
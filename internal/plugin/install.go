@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Install copies the executable at src into the CLI plugin directory of
+// configDir and returns the installed path. An existing plugin is replaced.
+func Install(src, configDir string) (string, error) {
+	dir := filepath.Join(configDir, "cli-plugins")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+
+	name := BinaryName
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	dst := filepath.Join(dir, name)
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open executable: %w", err)
+	}
+	defer in.Close()
+
+	// Write next to the target and rename, so a running plugin is never
+	// replaced by a half-written file.
+	tmp, err := os.CreateTemp(dir, "."+name+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to install plugin: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to install plugin: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to install plugin: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return "", fmt.Errorf("failed to install plugin: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", fmt.Errorf("failed to install plugin: %w", err)
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,174 @@
+// Package plugin implements the Docker CLI plugin protocol, so that dockr can
+// run as "docker dockr" with the connection settings of the calling CLI.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Name is the plugin's subcommand under docker.
+	Name = "dockr"
+	// BinaryName is the file name the Docker CLI looks for in its plugin directories.
+	BinaryName = "docker-" + Name
+	// MetadataSubcommand is how the Docker CLI asks a plugin for its metadata.
+	MetadataSubcommand = "docker-cli-plugin-metadata"
+	// originalCLIEnv is set by the Docker CLI when it runs a plugin.
+	originalCLIEnv = "DOCKER_CLI_PLUGIN_ORIGINAL_CLI_COMMAND"
+)
+
+// Metadata is the answer to MetadataSubcommand.
+type Metadata struct {
+	SchemaVersion    string `json:"SchemaVersion"`
+	Vendor           string `json:"Vendor"`
+	Version          string `json:"Version"`
+	ShortDescription string `json:"ShortDescription"`
+	URL              string `json:"URL,omitempty"`
+}
+
+// NewMetadata returns the plugin metadata for the given version.
+func NewMetadata(version string) Metadata {
+	return Metadata{
+		SchemaVersion:    "0.1.0",
+		Vendor:           "DobryySoul",
+		Version:          version,
+		ShortDescription: "Smart Docker resource cleaner",
+		URL:              "https://github.com/DobryySoul/dockr",
+	}
+}
+
+// IsPlugin reports whether the process was started by the Docker CLI as a
+// plugin: either it is installed under the plugin binary name or the CLI
+// marked the environment.
+func IsPlugin(argv0 string, getenv func(string) string) bool {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	return name == BinaryName || getenv(originalCLIEnv) != ""
+}
+
+// Invocation is a plugin command line split into the Docker CLI's global
+// options and the arguments meant for dockr.
+type Invocation struct {
+	Context   string
+	Hosts     []string
+	ConfigDir string
+	// Args are the arguments after the plugin name.
+	Args []string
+}
+
+// globalFlags are the Docker CLI options that take a value. Boolean options
+// (--debug, --tls, --tlsverify) are accepted and ignored.
+var globalFlags = map[string]string{
+	"--context":   "context",
+	"-c":          "context",
+	"--host":      "host",
+	"-H":          "host",
+	"--config":    "config",
+	"--log-level": "log-level",
+	"-l":          "log-level",
+	"--tlscacert": "tls",
+	"--tlscert":   "tls",
+	"--tlskey":    "tls",
+}
+
+var globalBoolFlags = map[string]bool{
+	"--debug": true, "-D": true, "--tls": true, "--tlsverify": true,
+}
+
+// ParseInvocation splits the arguments the Docker CLI passed to the plugin:
+// its own global options, then the plugin name, then the plugin's arguments.
+// Arguments without the plugin name (e.g. the metadata subcommand) are
+// returned as they are.
+func ParseInvocation(args []string) (*Invocation, error) {
+	inv := &Invocation{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == Name {
+			inv.Args = args[i+1:]
+			return inv, nil
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+
+		flag, value, hasValue := strings.Cut(arg, "=")
+		if globalBoolFlags[flag] {
+			continue
+		}
+
+		kind, ok := globalFlags[flag]
+		if !ok {
+			return nil, fmt.Errorf("unknown docker option %q", flag)
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("docker option %s needs a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch kind {
+		case "context":
+			inv.Context = value
+		case "host":
+			inv.Hosts = append(inv.Hosts, value)
+		case "config":
+			inv.ConfigDir = value
+		case "tls":
+			return nil, fmt.Errorf("docker option %s is not supported by dockr, use a docker context with TLS instead", flag)
+		}
+	}
+
+	inv.Args = args
+	return inv, nil
+}
+
+// offlineCommands answer without the engine, so they must work even when the
+// current context cannot be resolved: the Docker CLI asks for the metadata
+// while listing plugins in docker --help and docker info.
+var offlineCommands = map[string]bool{
+	MetadataSubcommand: true,
+	"help":             true,
+	"completion":       true,
+	"__complete":       true,
+	"plugin":           true,
+}
+
+// NeedsEndpoint reports whether the invocation runs a command that talks to
+// the engine, as opposed to the metadata, help, completion or installation.
+func (inv *Invocation) NeedsEndpoint() bool {
+	if len(inv.Args) > 0 && offlineCommands[inv.Args[0]] {
+		return false
+	}
+	for _, arg := range inv.Args {
+		if arg == "--" {
+			break
+		}
+		if arg == "-h" || arg == "--help" {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultConfigDir returns the Docker CLI configuration directory:
+// $DOCKER_CONFIG or ~/.docker.
+func DefaultConfigDir(getenv func(string) string) string {
+	if dir := getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".docker"
+	}
+
+	return filepath.Join(home, ".docker")
+}
@@ -0,0 +1,251 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestIsPlugin(t *testing.T) {
+	tests := []struct {
+		argv0 string
+		env   map[string]string
+		want  bool
+	}{
+		{argv0: "/home/u/.docker/cli-plugins/docker-dockr", want: true},
+		{argv0: "docker-dockr.exe", want: true},
+		{argv0: "/usr/local/bin/dockr", want: false},
+		{argv0: "/tmp/dockr-dev", env: map[string]string{originalCLIEnv: "/usr/bin/docker"}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsPlugin(tt.argv0, env(tt.env)); got != tt.want {
+			t.Errorf("IsPlugin(%q) = %v, want %v", tt.argv0, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    *Invocation
+		wantErr bool
+	}{
+		{
+			name: "plain",
+			args: []string{"dockr", "clean", "--dry-run"},
+			want: &Invocation{Args: []string{"clean", "--dry-run"}},
+		},
+		{
+			name: "global options",
+			args: []string{"--context", "prod", "-D", "--config=/etc/docker", "dockr", "clean"},
+			want: &Invocation{Context: "prod", ConfigDir: "/etc/docker", Args: []string{"clean"}},
+		},
+		{
+			name: "host",
+			args: []string{"-H", "tcp://10.0.0.1:2375", "dockr"},
+			want: &Invocation{Hosts: []string{"tcp://10.0.0.1:2375"}, Args: []string{}},
+		},
+		{
+			name: "metadata",
+			args: []string{MetadataSubcommand},
+			want: &Invocation{Args: []string{MetadataSubcommand}},
+		},
+		{name: "missing value", args: []string{"--context"}, wantErr: true},
+		{name: "unknown option", args: []string{"--orchestrator", "swarm", "dockr"}, wantErr: true},
+		{name: "tls files", args: []string{"--tlscert", "cert.pem", "dockr"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInvocation(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsEndpoint(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{}, want: true},
+		{args: []string{"clean", "--dry-run"}, want: true},
+		{args: []string{MetadataSubcommand}, want: false},
+		{args: []string{"help", "clean"}, want: false},
+		{args: []string{"plugin", "install"}, want: false},
+		{args: []string{"clean", "--help"}, want: false},
+		{args: []string{"reap", "--", "--help"}, want: true},
+	}
+
+	for _, tt := range tests {
+		inv := &Invocation{Args: tt.args}
+		if got := inv.NeedsEndpoint(); got != tt.want {
+			t.Errorf("%v: expected %v, got %v", tt.args, tt.want, got)
+		}
+	}
+}
+
+// writeContext stores a context the way the Docker CLI does.
+func writeContext(t *testing.T, configDir, name, host string, withTLS bool) string {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+
+	metaDir := filepath.Join(configDir, "contexts", "meta", id)
+	if err := os.MkdirAll(metaDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"Name":"` + name + `","Metadata":{},"Endpoints":{"docker":{"Host":"` + host + `","SkipTLSVerify":false}}}`
+	if err := os.WriteFile(filepath.Join(metaDir, "meta.json"), []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !withTLS {
+		return ""
+	}
+
+	tlsDir := filepath.Join(configDir, "contexts", "tls", id, "docker")
+	if err := os.MkdirAll(tlsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"ca.pem", "cert.pem", "key.pem"} {
+		if err := os.WriteFile(filepath.Join(tlsDir, f), []byte("pem"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return tlsDir
+}
+
+func TestResolve(t *testing.T) {
+	configDir := t.TempDir()
+	tlsDir := writeContext(t, configDir, "prod", "tcp://prod:2376", true)
+	writeContext(t, configDir, "lab", "unix:///run/lab.sock", false)
+	writeContext(t, configDir, "remote", "ssh://me@remote", false)
+
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"currentContext":"lab"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		inv     Invocation
+		env     map[string]string
+		want    Endpoint
+		wantErr bool
+	}{
+		{
+			name: "current context from config",
+			want: Endpoint{Context: "lab", Host: "unix:///run/lab.sock"},
+		},
+		{
+			name: "context option with TLS",
+			inv:  Invocation{Context: "prod"},
+			want: Endpoint{Context: "prod", Host: "tcp://prod:2376", TLSDir: tlsDir},
+		},
+		{
+			name: "host option",
+			inv:  Invocation{Hosts: []string{"tcp://other:2375"}},
+			want: Endpoint{Context: DefaultContext, Host: "tcp://other:2375"},
+		},
+		{
+			name: "DOCKER_HOST beats DOCKER_CONTEXT and config",
+			env:  map[string]string{"DOCKER_HOST": "tcp://env:2375", "DOCKER_CONTEXT": "prod"},
+			want: Endpoint{Context: DefaultContext},
+		},
+		{
+			name: "DOCKER_CONTEXT beats config",
+			env:  map[string]string{"DOCKER_CONTEXT": "prod"},
+			want: Endpoint{Context: "prod", Host: "tcp://prod:2376", TLSDir: tlsDir},
+		},
+		{
+			name: "default context",
+			inv:  Invocation{Context: DefaultContext},
+			want: Endpoint{Context: DefaultContext},
+		},
+		{name: "unknown context", inv: Invocation{Context: "nope"}, wantErr: true},
+		{name: "ssh context", inv: Invocation{Context: "remote"}, wantErr: true},
+		{name: "host and context", inv: Invocation{Context: "prod", Hosts: []string{"tcp://x:1"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := tt.inv
+			inv.ConfigDir = configDir
+
+			got, err := Resolve(&inv, env(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveWithoutConfig(t *testing.T) {
+	got, err := Resolve(&Invocation{ConfigDir: t.TempDir()}, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (Endpoint{Context: DefaultContext}) {
+		t.Errorf("got %+v, want the default context", got)
+	}
+}
+
+func TestEndpointEnv(t *testing.T) {
+	e := Endpoint{Host: "tcp://prod:2376", TLSDir: "/certs"}
+	want := map[string]string{"DOCKER_HOST": "tcp://prod:2376", "DOCKER_CERT_PATH": "/certs", "DOCKER_TLS_VERIFY": "1"}
+	if got := e.Env(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Env() = %v, want %v", got, want)
+	}
+
+	if got := (Endpoint{Context: DefaultContext}).Env(); len(got) != 0 {
+		t.Errorf("default endpoint must not change the environment, got %v", got)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dockr")
+	if err := os.WriteFile(src, []byte("binary"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	configDir := t.TempDir()
+
+	for range 2 { // installing again replaces the plugin
+		path, err := Install(src, configDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(path) != filepath.Join(configDir, "cli-plugins") || info.Mode().Perm()&0o111 == 0 {
+			t.Errorf("unexpected plugin %s with mode %v", path, info.Mode())
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(configDir, "cli-plugins"))
+	if len(entries) != 1 {
+		t.Errorf("expected only the plugin in the directory, got %d entries", len(entries))
+	}
+}
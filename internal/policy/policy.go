@@ -0,0 +1,262 @@
+// Package policy evaluates user-defined expressions that decide whether a
+// resource is removed, kept or left to the built-in analysis.
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/analyzer"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Results of a rule expression.
+const (
+	Delete  = "delete"
+	Keep    = "keep"
+	Default = "default"
+)
+
+// Config is the policy section of the configuration file.
+type Config struct {
+	Rules []RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig is one rule: an expression over a View that evaluates to
+// "delete", "keep" or "default".
+type RuleConfig struct {
+	Name string `yaml:"name"`
+	// Kind limits the rule to images, containers, volumes or networks; empty
+	// applies it to all of them.
+	Kind domain.ResourceKind `yaml:"kind"`
+	Expr string              `yaml:"expr"`
+}
+
+// View is what a rule expression sees of a resource. Durations compare with
+// duration("36h") and days(30); time with now().
+type View struct {
+	Kind    string            `expr:"kind"`
+	ID      string            `expr:"id"`
+	Name    string            `expr:"name"`
+	Labels  map[string]string `expr:"labels"`
+	Size    int64             `expr:"size"`
+	Created time.Time         `expr:"created"`
+	Age     time.Duration     `expr:"age"`
+	// State is the state of a container ("running", "exited", ...); "used" or
+	// "unused" for images, volumes and networks depending on whether
+	// containers reference them, and "dangling" for untagged images.
+	State string `expr:"state"`
+	// Tags, Repositories and Registries describe an image's references, e.g.
+	// "ghcr.io/team/app:1.2", "ghcr.io/team/app" and "ghcr.io".
+	Tags         []string `expr:"tags"`
+	Repositories []string `expr:"repositories"`
+	Registries   []string `expr:"registries"`
+	// Image is the image a container was created from.
+	Image string `expr:"image"`
+	// Class is "anonymous" or "named" for volumes.
+	Class string `expr:"class"`
+	// Containers are the names of the containers referencing the resource.
+	Containers []string `expr:"containers"`
+	// LastUsed is when the resource was last used (see Facts.LastUsed) and
+	// Idle how long ago that was.
+	LastUsed time.Time     `expr:"lastUsed"`
+	Idle     time.Duration `expr:"idle"`
+
+	Delete  string `expr:"delete"`
+	Keep    string `expr:"keep"`
+	Default string `expr:"default"`
+}
+
+// Facts is what the views need beyond the resource itself, keyed by
+// domain.Resource.Key.
+type Facts struct {
+	// Users are the names of the containers referencing a resource.
+	Users map[string][]string
+	// LastUsed is only filled when Policy.NeedsLastUsed.
+	LastUsed map[string]time.Time
+}
+
+// Policy is a compiled set of rules.
+type Policy struct {
+	rules         []rule
+	needsLastUsed bool
+	now           func() time.Time
+}
+
+type rule struct {
+	name    string
+	kind    domain.ResourceKind
+	program *vm.Program
+}
+
+var days = expr.Function("days", func(params ...any) (any, error) {
+	return time.Duration(params[0].(int)) * 24 * time.Hour, nil
+}, new(func(int) time.Duration))
+
+// Compile type-checks the rules.
+func Compile(cfg Config) (*Policy, error) {
+	p := &Policy{now: time.Now}
+
+	for i, rc := range cfg.Rules {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		if rc.Kind != "" && !slices.Contains(domain.Kinds, rc.Kind) {
+			return nil, fmt.Errorf("policy %s: unknown kind %q", name, rc.Kind)
+		}
+
+		idents := &identifiers{}
+		program, err := expr.Compile(rc.Expr, expr.Env(View{}), expr.AsKind(reflect.String), days, expr.Patch(idents))
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", name, err)
+		}
+
+		if idents.seen["lastUsed"] || idents.seen["idle"] {
+			p.needsLastUsed = true
+		}
+
+		p.rules = append(p.rules, rule{name: name, kind: rc.Kind, program: program})
+	}
+
+	return p, nil
+}
+
+// identifiers records the variables an expression reads.
+type identifiers struct {
+	seen map[string]bool
+}
+
+func (v *identifiers) Visit(node *ast.Node) {
+	if id, ok := (*node).(*ast.IdentifierNode); ok {
+		if v.seen == nil {
+			v.seen = make(map[string]bool)
+		}
+		v.seen[id.Value] = true
+	}
+}
+
+// Empty reports whether the policy has no rules.
+func (p *Policy) Empty() bool {
+	return p == nil || len(p.rules) == 0
+}
+
+// NeedsLastUsed reports whether a rule reads lastUsed or idle, which are
+// expensive to collect.
+func (p *Policy) NeedsLastUsed() bool {
+	return p.needsLastUsed
+}
+
+// SetClock replaces time.Now for ages and idle times.
+func (p *Policy) SetClock(now func() time.Time) {
+	p.now = now
+}
+
+// Rule turns the policy into a domain.Rule. The rules are evaluated in order
+// and the first one that does not return "default" decides; a rule that fails
+// at run time keeps the resource.
+func (p *Policy) Rule(facts *Facts) domain.Rule {
+	return func(res domain.Resource) domain.Decision {
+		var view *View
+		for _, r := range p.rules {
+			if r.kind != "" && r.kind != res.Kind {
+				continue
+			}
+			if view == nil {
+				view = p.View(res, facts)
+			}
+
+			out, err := expr.Run(r.program, view)
+			if err != nil {
+				return domain.Decision{Verdict: domain.VerdictKeep, Reason: fmt.Sprintf("policy %s failed: %v", r.name, err)}
+			}
+
+			switch out {
+			case Delete:
+				return domain.Decision{Verdict: domain.VerdictDelete, Reason: "policy " + r.name}
+			case Keep:
+				return domain.Decision{Verdict: domain.VerdictKeep, Reason: "policy " + r.name}
+			case Default:
+			default:
+				return domain.Decision{Verdict: domain.VerdictKeep, Reason: fmt.Sprintf("policy %s returned %q, want delete, keep or default", r.name, out)}
+			}
+		}
+		return domain.Decision{}
+	}
+}
+
+// View builds what the rule expressions see of a resource.
+func (p *Policy) View(res domain.Resource, facts *Facts) *View {
+	now := p.now()
+
+	v := &View{
+		Kind:    string(res.Kind),
+		ID:      res.ID,
+		Name:    res.Name,
+		Labels:  res.Labels,
+		Size:    res.Size,
+		Created: res.Created,
+		Delete:  Delete,
+		Keep:    Keep,
+		Default: Default,
+	}
+	if v.Labels == nil {
+		v.Labels = map[string]string{}
+	}
+	if !res.Created.IsZero() {
+		v.Age = now.Sub(res.Created)
+	}
+
+	if facts != nil {
+		v.Containers = facts.Users[res.Key()]
+		if t, ok := facts.LastUsed[res.Key()]; ok {
+			v.LastUsed = t
+			v.Idle = now.Sub(t)
+		}
+	}
+
+	state := "unused"
+	if len(v.Containers) > 0 {
+		state = "used"
+	}
+
+	switch obj := res.Object.(type) {
+	case *image.Summary:
+		for _, tag := range domain.ImageTags(obj) {
+			v.Tags = append(v.Tags, tag)
+
+			named, err := reference.ParseNormalizedNamed(tag)
+			if err != nil {
+				continue
+			}
+			if repo := named.Name(); !slices.Contains(v.Repositories, repo) {
+				v.Repositories = append(v.Repositories, repo)
+			}
+			if host := reference.Domain(named); !slices.Contains(v.Registries, host) {
+				v.Registries = append(v.Registries, host)
+			}
+		}
+		if len(v.Tags) == 0 {
+			state = "dangling"
+		}
+	case *container.Summary:
+		state = obj.State
+		v.Image = obj.Image
+	case *volume.Volume:
+		v.Class = string(analyzer.ClassifyVolume(obj))
+	}
+	v.State = strings.ToLower(state)
+
+	return v
+}
@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+var now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func compile(t *testing.T, rules ...RuleConfig) *Policy {
+	t.Helper()
+
+	p, err := Compile(Config{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetClock(func() time.Time { return now })
+	return p
+}
+
+func imageAged(tags []string, age time.Duration) domain.Resource {
+	return domain.ImageResource(&image.Summary{ID: "sha256:" + tags[0], RepoTags: tags, Created: now.Add(-age).Unix()})
+}
+
+func TestRegistryRule(t *testing.T) {
+	// Keep images from registry X unless older than 30 days and not tagged release-*.
+	p := compile(t, RuleConfig{
+		Name: "registry-x",
+		Kind: domain.KindImage,
+		Expr: `"registry.x.io" in registries ? (age > days(30) && none(tags, # matches ":release-") ? delete : keep) : default`,
+	})
+	rule := p.Rule(nil)
+
+	tests := []struct {
+		name string
+		res  domain.Resource
+		want domain.Verdict
+	}{
+		{"young", imageAged([]string{"registry.x.io/app:1"}, 24*time.Hour), domain.VerdictKeep},
+		{"old", imageAged([]string{"registry.x.io/app:1"}, 40*24*time.Hour), domain.VerdictDelete},
+		{"old release", imageAged([]string{"registry.x.io/app:release-1"}, 40*24*time.Hour), domain.VerdictKeep},
+		{"other registry", imageAged([]string{"nginx:1.27"}, 40*24*time.Hour), domain.VerdictDefault},
+		{"container", domain.ContainerResource(&container.Summary{ID: "c1"}), domain.VerdictDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule(tt.res); got.Verdict != tt.want {
+				t.Errorf("verdict = %s (%s), want %s", got.Verdict, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	p := compile(t)
+
+	res := domain.ImageResource(&image.Summary{ID: "sha256:a", RepoTags: []string{"ghcr.io/team/app:1", "app:dev"}, Created: now.Add(-time.Hour).Unix()})
+	v := p.View(res, &Facts{
+		Users:    map[string][]string{res.Key(): {"web"}},
+		LastUsed: map[string]time.Time{res.Key(): now.Add(-time.Minute)},
+	})
+
+	if strings.Join(v.Repositories, " ") != "ghcr.io/team/app docker.io/library/app" ||
+		strings.Join(v.Registries, " ") != "ghcr.io docker.io" {
+		t.Errorf("repositories = %v, registries = %v", v.Repositories, v.Registries)
+	}
+	if v.State != "used" || v.Age != time.Hour || v.Idle != time.Minute {
+		t.Errorf("state = %s, age = %s, idle = %s", v.State, v.Age, v.Idle)
+	}
+
+	vol := p.View(domain.VolumeResource(&volume.Volume{Name: "data"}), nil)
+	if vol.Class != "named" || vol.State != "unused" {
+		t.Errorf("volume class = %s, state = %s", vol.Class, vol.State)
+	}
+}
+
+func TestRuleOrder(t *testing.T) {
+	p := compile(t,
+		RuleConfig{Name: "defers", Expr: `default`},
+		RuleConfig{Name: "keeps-db", Expr: `labels["app"] == "db" ? keep : default`},
+		RuleConfig{Name: "deletes-exited", Kind: domain.KindContainer, Expr: `state == "exited" ? delete : default`},
+	)
+	rule := p.Rule(nil)
+
+	db := domain.ContainerResource(&container.Summary{ID: "c1", State: "exited", Labels: map[string]string{"app": "db"}})
+	if got := rule(db); got.Verdict != domain.VerdictKeep || got.Reason != "policy keeps-db" {
+		t.Errorf("db = %+v, want kept by keeps-db", got)
+	}
+
+	web := domain.ContainerResource(&container.Summary{ID: "c2", State: "exited"})
+	if got := rule(web); got.Verdict != domain.VerdictDelete {
+		t.Errorf("web = %+v, want deleted", got)
+	}
+}
+
+func TestInvalidResultKeeps(t *testing.T) {
+	rule := compile(t, RuleConfig{Name: "typo", Expr: `"remove"`}).Rule(nil)
+
+	got := rule(domain.ContainerResource(&container.Summary{ID: "c1"}))
+	if got.Verdict != domain.VerdictKeep || !strings.Contains(got.Reason, "typo") {
+		t.Errorf("decision = %+v, want kept with the rule named", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := map[string]RuleConfig{
+		"unknown variable": {Expr: `registry == "x" ? delete : default`},
+		"not a string":     {Expr: `size > 10`},
+		"unknown kind":     {Kind: "pod", Expr: `default`},
+	}
+
+	for name, rc := range tests {
+		if _, err := Compile(Config{Rules: []RuleConfig{rc}}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNeedsLastUsed(t *testing.T) {
+	if compile(t, RuleConfig{Expr: `age > days(1) ? delete : default`}).NeedsLastUsed() {
+		t.Error("age alone does not need the last-used times")
+	}
+	if !compile(t, RuleConfig{Expr: `idle > days(1) ? delete : default`}).NeedsLastUsed() {
+		t.Error("idle needs the last-used times")
+	}
+}
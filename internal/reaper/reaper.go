@@ -0,0 +1,260 @@
+// Package reaper tears down the resources of test and CI sessions whose
+// owner went away. A session is a set of labels; a process keeps its session
+// alive by holding a connection to the watchdog.
+package reaper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReapFunc removes every resource carrying all the labels.
+type ReapFunc func(ctx context.Context, labels []string) error
+
+// ParseLabel validates a session label. It accepts "key=value" and, for
+// compatibility with Ryuk clients, "label=key=value".
+func ParseLabel(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(s, "label="); ok && strings.Contains(rest, "=") {
+		s = rest
+	}
+
+	key, _, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return "", fmt.Errorf("invalid session label %q (expected key=value)", s)
+	}
+
+	return s, nil
+}
+
+// ParseFilter parses one line of the watchdog protocol: labels joined by '&'.
+func ParseFilter(line string) ([]string, error) {
+	var labels []string
+	for part := range strings.SplitSeq(line, "&") {
+		label, err := ParseLabel(part)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+
+	slices.Sort(labels)
+	return slices.Compact(labels), nil
+}
+
+// Listen opens the watchdog listener. addr is "unix:///path/to.sock",
+// "tcp://host:port" or a plain "host:port".
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		// A socket left over from a previous run would make Listen fail.
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+
+	return net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+}
+
+// Watchdog reaps a session once its last connection has been gone for Grace.
+//
+// Protocol: after connecting, a client sends one line per session it owns,
+// each holding labels joined by '&' (e.g. "ci.job=123" or, as Ryuk clients
+// do, "label=org.testcontainers.sessionId=abc"). Every line is answered with
+// "ACK". Connections that send nothing belong to the Default session.
+type Watchdog struct {
+	Grace   time.Duration
+	Default []string
+	Reap    ReapFunc
+	// Logf reports session activity; it may be nil.
+	Logf func(format string, args ...any)
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	reapMu   sync.Mutex
+	wg       sync.WaitGroup
+}
+
+type session struct {
+	labels []string
+	conns  int
+	timer  *time.Timer
+}
+
+// Serve accepts connections until ctx is done. On shutdown, sessions waiting
+// for their grace period are reaped right away; sessions that still have
+// connections are left alone.
+func (w *Watchdog) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				w.shutdown()
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.handle(ctx, conn)
+		}()
+	}
+}
+
+func (w *Watchdog) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	var owned []string
+	defer func() {
+		// Connections closed by the shutdown keep their sessions alive.
+		if ctx.Err() != nil {
+			return
+		}
+		for _, key := range owned {
+			w.release(key)
+		}
+	}()
+
+	if len(w.Default) > 0 {
+		owned = append(owned, w.acquire(w.Default))
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		labels, err := ParseFilter(line)
+		if err != nil {
+			fmt.Fprintf(conn, "ERR %v\n", err)
+			continue
+		}
+
+		owned = append(owned, w.acquire(labels))
+		fmt.Fprintln(conn, "ACK")
+	}
+}
+
+// acquire adds a connection to the session, cancelling a pending reap.
+func (w *Watchdog) acquire(labels []string) string {
+	key := strings.Join(labels, "&")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sessions == nil {
+		w.sessions = make(map[string]*session)
+	}
+
+	s, ok := w.sessions[key]
+	if !ok {
+		s = &session{labels: labels}
+		w.sessions[key] = s
+		w.logf("Session %s started", key)
+	}
+	if s.timer != nil {
+		if s.timer.Stop() {
+			w.wg.Done()
+		}
+		s.timer = nil
+		w.logf("Session %s reconnected", key)
+	}
+	s.conns++
+
+	return key
+}
+
+// release removes a connection from the session and schedules the reap
+// when it was the last one.
+func (w *Watchdog) release(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s, ok := w.sessions[key]
+	if !ok {
+		return
+	}
+
+	s.conns--
+	if s.conns > 0 {
+		return
+	}
+
+	w.logf("Session %s disconnected, reaping in %s", key, w.Grace)
+
+	w.wg.Add(1)
+	s.timer = time.AfterFunc(w.Grace, func() {
+		defer w.wg.Done()
+
+		w.mu.Lock()
+		if w.sessions[key] != s || s.conns > 0 || s.timer == nil {
+			w.mu.Unlock()
+			return
+		}
+		delete(w.sessions, key)
+		w.mu.Unlock()
+
+		w.reap(s.labels)
+	})
+}
+
+// shutdown reaps the sessions waiting for their grace period and waits for
+// connections and reaps in flight.
+func (w *Watchdog) shutdown() {
+	w.mu.Lock()
+	var pending [][]string
+	for key, s := range w.sessions {
+		if s.timer != nil && s.timer.Stop() {
+			w.wg.Done()
+			pending = append(pending, s.labels)
+			delete(w.sessions, key)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, labels := range pending {
+		w.reap(labels)
+	}
+
+	w.wg.Wait()
+}
+
+// reap runs one reap at a time, so removals of different sessions do not interleave.
+func (w *Watchdog) reap(labels []string) {
+	w.reapMu.Lock()
+	defer w.reapMu.Unlock()
+
+	// A reap must finish even while the watchdog shuts down.
+	if err := w.Reap(context.Background(), labels); err != nil && !errors.Is(err, context.Canceled) {
+		w.logf("Failed to reap session %s: %v", strings.Join(labels, "&"), err)
+		return
+	}
+
+	w.logf("Session %s reaped", strings.Join(labels, "&"))
+}
+
+func (w *Watchdog) logf(format string, args ...any) {
+	if w.Logf != nil {
+		w.Logf(format, args...)
+	}
+}
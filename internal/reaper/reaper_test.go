@@ -0,0 +1,207 @@
+package reaper
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "ci.job=123", want: []string{"ci.job=123"}},
+		{line: "label=org.testcontainers.sessionId=abc", want: []string{"org.testcontainers.sessionId=abc"}},
+		{line: "b=2&a=1&b=2", want: []string{"a=1", "b=2"}},
+		{line: "label=keep", want: []string{"label=keep"}},
+		{line: "novalue", wantErr: true},
+		{line: "=x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFilter(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseFilter(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFilter(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// recorder collects reaped sessions.
+type recorder struct {
+	mu     sync.Mutex
+	reaped []string
+	ch     chan string
+}
+
+func newRecorder() *recorder {
+	return &recorder{ch: make(chan string, 10)}
+}
+
+func (r *recorder) reap(_ context.Context, labels []string) error {
+	key := strings.Join(labels, "&")
+	r.mu.Lock()
+	r.reaped = append(r.reaped, key)
+	r.mu.Unlock()
+	r.ch <- key
+	return nil
+}
+
+func (r *recorder) sessions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.reaped...)
+}
+
+func startWatchdog(t *testing.T, w *Watchdog) (addr string, stop func()) {
+	t.Helper()
+
+	ln, err := Listen("unix://" + filepath.Join(t.TempDir(), "dockr.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Serve(ctx, ln) }()
+
+	return ln.Addr().String(), func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	}
+}
+
+// register connects and claims a session.
+func register(t *testing.T, addr, line string) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line != "" {
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil || reply != "ACK\n" {
+			t.Fatalf("reply = %q, %v; want ACK", reply, err)
+		}
+	}
+
+	return conn
+}
+
+func TestWatchdogReapsAfterGrace(t *testing.T) {
+	rec := newRecorder()
+	addr, stop := startWatchdog(t, &Watchdog{Grace: 50 * time.Millisecond, Reap: rec.reap})
+	defer stop()
+
+	conn := register(t, addr, "ci.job=1")
+	start := time.Now()
+	conn.Close()
+
+	select {
+	case key := <-rec.ch:
+		if key != "ci.job=1" {
+			t.Errorf("reaped %q, want ci.job=1", key)
+		}
+		if time.Since(start) < 50*time.Millisecond {
+			t.Error("session reaped before the grace period")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("session was not reaped")
+	}
+}
+
+func TestWatchdogReconnectCancelsReap(t *testing.T) {
+	rec := newRecorder()
+	addr, stop := startWatchdog(t, &Watchdog{Grace: 200 * time.Millisecond, Reap: rec.reap})
+
+	first := register(t, addr, "ci.job=2")
+	second := register(t, addr, "ci.job=2")
+	first.Close()
+
+	// The session is still held by the second connection.
+	time.Sleep(300 * time.Millisecond)
+	second.Close()
+
+	// Reconnect within the grace period.
+	time.Sleep(50 * time.Millisecond)
+	third := register(t, addr, "ci.job=2")
+	time.Sleep(300 * time.Millisecond)
+
+	if got := rec.sessions(); len(got) != 0 {
+		t.Errorf("reaped %v while the session was held", got)
+	}
+
+	third.Close()
+	time.Sleep(50 * time.Millisecond) // let the watchdog notice the disconnect
+	stop()
+
+	// Shutdown reaps the disconnected session without waiting for the grace period.
+	if got := rec.sessions(); !reflect.DeepEqual(got, []string{"ci.job=2"}) {
+		t.Errorf("reaped %v after shutdown, want [ci.job=2]", got)
+	}
+}
+
+func TestWatchdogDefaultSession(t *testing.T) {
+	rec := newRecorder()
+	addr, stop := startWatchdog(t, &Watchdog{Grace: 10 * time.Millisecond, Default: []string{"run=42"}, Reap: rec.reap})
+	defer stop()
+
+	register(t, addr, "").Close()
+
+	select {
+	case key := <-rec.ch:
+		if key != "run=42" {
+			t.Errorf("reaped %q, want run=42", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("default session was not reaped")
+	}
+}
+
+func TestWatchdogShutdownKeepsConnectedSessions(t *testing.T) {
+	rec := newRecorder()
+	addr, stop := startWatchdog(t, &Watchdog{Grace: time.Hour, Reap: rec.reap})
+
+	conn := register(t, addr, "ci.job=3")
+	defer conn.Close()
+
+	stop()
+
+	if got := rec.sessions(); len(got) != 0 {
+		t.Errorf("reaped %v, want no reaps for connected sessions", got)
+	}
+}
+
+func TestWatchdogRejectsInvalidLine(t *testing.T) {
+	addr, stop := startWatchdog(t, &Watchdog{Grace: time.Hour, Reap: newRecorder().reap})
+	defer stop()
+
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("garbage\n"))
+	reply, _ := bufio.NewReader(conn).ReadString('\n')
+	if !strings.HasPrefix(reply, "ERR ") {
+		t.Errorf("reply = %q, want an error", reply)
+	}
+}
@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Auth is a registry login.
+type Auth struct {
+	Username string
+	Password string
+}
+
+// Credentials are the registry logins of a Docker client configuration:
+// inline "auths" entries and credential helpers.
+type Credentials struct {
+	Auths       map[string]authEntry `json:"auths"`
+	CredsStore  string               `json:"credsStore"`
+	CredHelpers map[string]string    `json:"credHelpers"`
+}
+
+type authEntry struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// DefaultConfigPath returns config.json in $DOCKER_CONFIG or ~/.docker.
+func DefaultConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".docker", "config.json")
+	}
+
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// LoadCredentials reads the Docker client configuration at path. A missing
+// file means no logins.
+func LoadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Credentials{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Docker config: %w", err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse Docker config %s: %w", path, err)
+	}
+
+	return &creds, nil
+}
+
+// Lookup returns the login for a registry host, asking the credential
+// helper configured for it when there is no inline entry.
+func (c *Credentials) Lookup(host string) (Auth, bool) {
+	if c == nil {
+		return Auth{}, false
+	}
+
+	for key, entry := range c.Auths {
+		if normalizeHost(key) != host {
+			continue
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if user, pass, ok := strings.Cut(string(decoded), ":"); err == nil && ok {
+				return Auth{Username: user, Password: pass}, true
+			}
+		}
+		if entry.Username != "" {
+			return Auth{Username: entry.Username, Password: entry.Password}, true
+		}
+	}
+
+	helper := c.CredsStore
+	for key, h := range c.CredHelpers {
+		if normalizeHost(key) == host {
+			helper = h
+		}
+	}
+	if helper == "" {
+		return Auth{}, false
+	}
+
+	auth, err := helperLookup(helper, serverURL(host))
+	return auth, err == nil && auth.Username != ""
+}
+
+// serverURL is the key a host is stored under by "docker login".
+func serverURL(host string) string {
+	if host == dockerHub {
+		return "https://index.docker.io/v1/"
+	}
+	return host
+}
+
+// helperLookup runs "docker-credential-<helper> get" for the server.
+func helperLookup(helper, server string) (Auth, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return Auth{}, fmt.Errorf("failed to run credential helper %s: %w", helper, err)
+	}
+
+	var resp struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return Auth{}, fmt.Errorf("failed to parse credential helper output: %w", err)
+	}
+
+	return Auth{Username: resp.Username, Password: resp.Secret}, nil
+}
+
+// authorize answers a WWW-Authenticate challenge with a Basic login or a
+// pull token from the registry's token service.
+func (c *Checker) authorize(ctx context.Context, host, repo, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	auth, hasAuth := c.Credentials.Lookup(host)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasAuth {
+			return "", fmt.Errorf("registry %s requires a login (docker login %s)", host, host)
+		}
+		return "Basic " + basicAuth(auth), nil
+	case "bearer":
+		return c.token(ctx, params, repo, auth, hasAuth)
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", host, challenge)
+	}
+}
+
+func (c *Checker) token(ctx context.Context, params map[string]string, repo string, auth Auth, hasAuth bool) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+repo+":pull")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasAuth {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service answered %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	if token == "" {
+		return "", errors.New("token service returned no token")
+	}
+
+	return "Bearer " + token, nil
+}
+
+// parseChallenge splits `Bearer realm="...",service="..."` into the scheme
+// and its parameters.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+
+	return scheme, params
+}
+
+func basicAuth(auth Auth) string {
+	return base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+}
@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/jsoncache"
+)
+
+// DefaultCacheTTL is how long an answer is trusted when online.
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache keeps the answers of registries in a JSON file, so repeated runs do
+// not query them again and offline runs can still decide.
+type Cache struct {
+	entries *jsoncache.Cache[Result]
+}
+
+// DefaultCachePath returns dockr/registry.json in the user's cache directory.
+func DefaultCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "dockr-registry.json"
+	}
+
+	return filepath.Join(dir, "dockr", "registry.json")
+}
+
+// LoadCache reads the cache at path; a missing file is an empty cache.
+// Entries older than ttl are ignored unless offline.
+func LoadCache(path string, ttl time.Duration) (*Cache, error) {
+	entries, err := jsoncache.Load(path, jsoncache.Options[Result]{
+		Name:  "registry",
+		TTL:   ttl,
+		Stamp: func(r Result) time.Time { return r.CheckedAt },
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{entries: entries}, nil
+}
+
+// Get returns the cached answer for a repo digest. Offline, any age will do.
+func (c *Cache) Get(ref string, now time.Time, offline bool) (Result, bool) {
+	var (
+		r  Result
+		ok bool
+	)
+	if offline {
+		r, ok = c.entries.Lookup(ref)
+	} else {
+		r, ok = c.entries.Get(ref, now)
+	}
+	if !ok {
+		return Result{}, false
+	}
+
+	r.Cached = true
+	return r, true
+}
+
+// Put stores a definite answer; failed checks are not cached.
+func (c *Cache) Put(r Result) {
+	if r.Status != StatusAvailable && r.Status != StatusMissing {
+		return
+	}
+
+	c.entries.Put(r.Ref, r)
+}
+
+// Save writes the cache back if it changed.
+func (c *Cache) Save() error {
+	return c.entries.Save()
+}
@@ -0,0 +1,386 @@
+// Package registry checks that images can be pulled again before they are
+// deleted, by asking their registries whether the manifest behind each
+// repo digest still exists.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+)
+
+// Mode decides what happens to images that cannot be pulled again.
+type Mode string
+
+const (
+	// ModeOff skips the check.
+	ModeOff Mode = "off"
+	// ModeFlag reports the images and removes them anyway.
+	ModeFlag Mode = "flag"
+	// ModeProtect reports the images and keeps them.
+	ModeProtect Mode = "protect"
+)
+
+// ParseMode validates the value of the --check-registry flag; empty means off.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case "":
+		return ModeOff, nil
+	case ModeOff, ModeFlag, ModeProtect:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown registry check mode %q (expected off, flag or protect)", s)
+	}
+}
+
+// Status is the outcome of checking one repo digest or image.
+type Status string
+
+const (
+	// StatusAvailable means the registry still serves the manifest.
+	StatusAvailable Status = "available"
+	// StatusMissing means the registry no longer has the manifest.
+	StatusMissing Status = "missing"
+	// StatusUnpushed means the image has tags but no repo digest: it was
+	// built or loaded locally and never pulled or pushed.
+	StatusUnpushed Status = "unpushed"
+	// StatusUnknown means the registry could not answer, or the offline
+	// cache holds no entry.
+	StatusUnknown Status = "unknown"
+	// StatusUnchecked means the registry is not one of the checked ones.
+	StatusUnchecked Status = "unchecked"
+)
+
+// Result is the outcome of checking one repo digest.
+type Result struct {
+	Ref       string    `json:"ref"`
+	Status    Status    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Err       string    `json:"error,omitempty"`
+	// Cached marks results answered from the cache.
+	Cached bool `json:"-"`
+}
+
+// ImageCheck is the outcome of checking all repo digests of an image.
+type ImageCheck struct {
+	ImageID string
+	Tags    []string
+	Status  Status
+	Results []Result
+}
+
+// Repullable reports whether the image can be pulled again after deletion.
+func (c ImageCheck) Repullable() bool {
+	return c.Status == StatusAvailable
+}
+
+// Lost reports whether deleting the image would lose it: it is known to be
+// gone from its registries, was never pushed, or could not be checked.
+// Images of registries outside Checker.Registries are not lost.
+func (c ImageCheck) Lost() bool {
+	return c.Status == StatusMissing || c.Status == StatusUnpushed || c.Status == StatusUnknown
+}
+
+// Protect returns the resources without the images the checks found lost, for
+// protect mode.
+func Protect(resources *domain.UnusedResources, checks []ImageCheck) *domain.UnusedResources {
+	lost := make(map[string]bool)
+	for _, c := range checks {
+		if c.Lost() {
+			lost[c.ImageID] = true
+		}
+	}
+
+	return resources.Select(func(res domain.Resource) bool {
+		return res.Kind != domain.KindImage || !lost[res.ID]
+	})
+}
+
+// Checker asks registries whether repo digests still resolve.
+type Checker struct {
+	// Registries limits the checks to these hosts (e.g. "localhost:5000",
+	// "docker.io"); empty checks every registry.
+	Registries []string
+	// Insecure hosts are reached over plain HTTP. Loopback hosts always are,
+	// as the engine does for a local registry:2.
+	Insecure []string
+	// Credentials are the logins from the Docker client configuration.
+	Credentials *Credentials
+	// Cache keeps answers between runs; it may be nil.
+	Cache *Cache
+	// Offline answers from the cache only.
+	Offline bool
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// Concurrency limits the parallel requests; it defaults to 4.
+	Concurrency int
+}
+
+// CheckImages checks every tagged image. Untagged images can never be pulled
+// by name and are left out; their results would only be noise.
+func (c *Checker) CheckImages(ctx context.Context, images []*image.Summary) []ImageCheck {
+	var tagged []*image.Summary
+	for _, img := range images {
+		if len(domain.ImageTags(img)) > 0 {
+			tagged = append(tagged, img)
+		}
+	}
+
+	limit := c.Concurrency
+	if limit <= 0 {
+		limit = 4
+	}
+	sem := make(chan struct{}, limit)
+
+	checks := make([]ImageCheck, len(tagged))
+	var wg sync.WaitGroup
+	for i, img := range tagged {
+		checks[i] = ImageCheck{ImageID: img.ID, Tags: domain.ImageTags(img), Results: make([]Result, len(img.RepoDigests))}
+
+		for j, ref := range img.RepoDigests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				checks[i].Results[j] = c.Check(ctx, ref)
+			}()
+		}
+	}
+	wg.Wait()
+
+	for i := range checks {
+		checks[i].Status = imageStatus(checks[i].Results)
+	}
+
+	return checks
+}
+
+// imageStatus folds the results of an image's repo digests: one available
+// digest is enough to pull the image again.
+func imageStatus(results []Result) Status {
+	if len(results) == 0 {
+		return StatusUnpushed
+	}
+
+	for _, want := range []Status{StatusAvailable, StatusUnknown, StatusMissing} {
+		if slices.ContainsFunc(results, func(r Result) bool { return r.Status == want }) {
+			return want
+		}
+	}
+	return StatusUnchecked
+}
+
+// Check resolves one repo digest ("registry/repo@sha256:...").
+func (c *Checker) Check(ctx context.Context, ref string) Result {
+	result := Result{Ref: ref, CheckedAt: time.Now()}
+
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return result.fail(fmt.Errorf("invalid repo digest: %w", err))
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return result.fail(errors.New("reference has no digest"))
+	}
+
+	host := reference.Domain(named)
+	if !c.checks(host) {
+		result.Status = StatusUnchecked
+		return result
+	}
+
+	if c.Cache != nil {
+		if cached, ok := c.Cache.Get(ref, result.CheckedAt, c.Offline); ok {
+			return cached
+		}
+	}
+	if c.Offline {
+		return result.fail(errors.New("not in the offline cache"))
+	}
+
+	exists, err := c.manifestExists(ctx, host, reference.Path(named), canonical.Digest().String())
+	if err != nil {
+		return result.fail(err)
+	}
+
+	result.Status = StatusMissing
+	if exists {
+		result.Status = StatusAvailable
+	}
+
+	if c.Cache != nil {
+		c.Cache.Put(result)
+	}
+
+	return result
+}
+
+func (r Result) fail(err error) Result {
+	r.Status = StatusUnknown
+	r.Err = err.Error()
+	return r
+}
+
+func (c *Checker) checks(host string) bool {
+	return len(c.Registries) == 0 || slices.ContainsFunc(c.Registries, func(r string) bool {
+		return normalizeHost(r) == host
+	})
+}
+
+// Manifest types a HEAD request accepts, so registries do not answer 404
+// for manifests they could only serve in another format.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// Resolve returns the digest of the manifest a tagged reference
+// ("registry/repo:tag") points to, bypassing the cache.
+func (c *Checker) Resolve(ctx context.Context, ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference: %w", err)
+	}
+	named = reference.TagNameOnly(named)
+	tagged, ok := named.(reference.Tagged)
+	if !ok {
+		return "", errors.New("reference has no tag")
+	}
+
+	host := reference.Domain(named)
+	resp, err := c.headManifest(ctx, host, reference.Path(named), tagged.Tag())
+	if err != nil {
+		return "", err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+			return digest, nil
+		}
+		return "", fmt.Errorf("registry %s reported no digest for %s", host, ref)
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%s not found in registry", ref)
+	default:
+		return "", fmt.Errorf("registry %s answered %s", host, resp.Status)
+	}
+}
+
+// manifestExists asks the registry for the manifest by digest.
+func (c *Checker) manifestExists(ctx context.Context, host, repo, digest string) (bool, error) {
+	resp, err := c.headManifest(ctx, host, repo, digest)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("registry %s answered %s", host, resp.Status)
+	}
+}
+
+// headManifest requests a manifest by tag or digest, logging in when the
+// registry answers with an authentication challenge.
+func (c *Checker) headManifest(ctx context.Context, host, repo, ref string) (*http.Response, error) {
+	url := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme(host), apiHost(host), repo, ref)
+
+	resp, err := c.head(ctx, url, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		auth, err := c.authorize(ctx, host, repo, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, err
+		}
+		return c.head(ctx, url, auth)
+	}
+
+	return resp, nil
+}
+
+func (c *Checker) head(ctx context.Context, url, auth string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry: %w", err)
+	}
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+func (c *Checker) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+func (c *Checker) scheme(host string) string {
+	if slices.ContainsFunc(c.Insecure, func(h string) bool { return normalizeHost(h) == host }) || isLoopback(host) {
+		return "http"
+	}
+	return "https"
+}
+
+func isLoopback(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// The Docker Hub is named docker.io in references but served elsewhere.
+const (
+	dockerHub    = "docker.io"
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+func apiHost(host string) string {
+	if host == dockerHub {
+		return dockerHubAPI
+	}
+	return host
+}
+
+func normalizeHost(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host = strings.TrimSuffix(host, "/")
+	switch host {
+	case "index.docker.io", dockerHubAPI, "index.docker.io/v1":
+		return dockerHub
+	}
+	return host
+}
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+const (
+	digestKept = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	digestGone = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// fakeRegistry serves the manifests of app@digestKept behind token auth, as
+// registry:2 with a token service does.
+func fakeRegistry(t *testing.T) (host string, requests *int) {
+	t.Helper()
+
+	requests = new(int)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if user, pass, _ := r.BasicAuth(); user != "ci" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				t.Errorf("token scope = %q", r.URL.Query().Get("scope"))
+			}
+			w.Write([]byte(`{"token": "t0k3n"}`))
+		case r.Header.Get("Authorization") != "Bearer t0k3n":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/team/app/manifests/1.0":
+			w.Header().Set("Docker-Content-Digest", digestKept)
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/team/app/manifests/"+digestKept:
+			*requests++
+			w.WriteHeader(http.StatusOK)
+		default:
+			*requests++
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://"), requests
+}
+
+func writeDockerConfig(t *testing.T, host string) *Credentials {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	// "ci:secret"
+	config := `{"auths": {"` + host + `": {"auth": "Y2k6c2VjcmV0"}}}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := LoadCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+func TestCheckImages(t *testing.T) {
+	host, _ := fakeRegistry(t)
+
+	checker := &Checker{Credentials: writeDockerConfig(t, host), Registries: []string{host}}
+
+	images := []*image.Summary{
+		{ID: "kept", RepoTags: []string{host + "/team/app:1.0"}, RepoDigests: []string{host + "/team/app@" + digestKept}},
+		{ID: "overwritten", RepoTags: []string{host + "/team/app:latest"}, RepoDigests: []string{host + "/team/app@" + digestGone}},
+		{ID: "local", RepoTags: []string{"app:dev"}},
+		{ID: "hub", RepoTags: []string{"nginx:1.27"}, RepoDigests: []string{"nginx@" + digestKept}},
+		{ID: "dangling", RepoTags: []string{"<none>:<none>"}},
+	}
+
+	want := map[string]Status{
+		"kept":        StatusAvailable,
+		"overwritten": StatusMissing,
+		"local":       StatusUnpushed,
+		"hub":         StatusUnchecked,
+	}
+
+	checks := checker.CheckImages(context.Background(), images)
+	if len(checks) != len(want) {
+		t.Fatalf("got %d checks, want %d", len(checks), len(want))
+	}
+	for _, c := range checks {
+		if c.Status != want[c.ImageID] {
+			t.Errorf("%s: status %s (%+v), want %s", c.ImageID, c.Status, c.Results, want[c.ImageID])
+		}
+	}
+}
+
+func TestCheckWithoutLogin(t *testing.T) {
+	host, _ := fakeRegistry(t)
+
+	r := (&Checker{}).Check(context.Background(), host+"/team/app@"+digestKept)
+	if r.Status != StatusUnknown || !strings.Contains(r.Err, "401") {
+		t.Errorf("result = %+v, want unknown with the token error", r)
+	}
+}
+
+func TestCacheAndOffline(t *testing.T) {
+	host, requests := fakeRegistry(t)
+	path := filepath.Join(t.TempDir(), "cache", "registry.json")
+	ref := host + "/team/app@" + digestKept
+
+	cache, err := LoadCache(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := &Checker{Credentials: writeDockerConfig(t, host), Cache: cache}
+
+	for range 2 {
+		if r := checker.Check(context.Background(), ref); r.Status != StatusAvailable {
+			t.Fatalf("result = %+v", r)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("registry queried %d times, want 1", *requests)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Offline, a stale entry still answers and an unknown one does not.
+	cache, err = LoadCache(path, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := &Checker{Cache: cache, Offline: true}
+
+	if r := offline.Check(context.Background(), ref); r.Status != StatusAvailable || !r.Cached {
+		t.Errorf("cached result = %+v", r)
+	}
+	if r := offline.Check(context.Background(), host+"/team/app@"+digestGone); r.Status != StatusUnknown {
+		t.Errorf("uncached result = %+v, want unknown", r)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	host, _ := fakeRegistry(t)
+	checker := &Checker{Credentials: writeDockerConfig(t, host)}
+
+	digest, err := checker.Resolve(context.Background(), host+"/team/app:1.0")
+	if err != nil || digest != digestKept {
+		t.Errorf("Resolve(1.0) = %q, %v; want %s", digest, err, digestKept)
+	}
+
+	if _, err := checker.Resolve(context.Background(), host+"/team/app:2.0"); err == nil {
+		t.Error("expected an error for a missing tag")
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+
+	if scheme != "Bearer" || params["realm"] != "https://auth.docker.io/token" ||
+		params["service"] != "registry.docker.io" || params["scope"] != "repository:library/nginx:pull" {
+		t.Errorf("parseChallenge = %s, %v", scheme, params)
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]string{
+		"https://index.docker.io/v1/": "docker.io",
+		"registry-1.docker.io":        "docker.io",
+		"http://localhost:5000":       "localhost:5000",
+		"ghcr.io":                     "ghcr.io",
+	}
+
+	for in, want := range tests {
+		if got := normalizeHost(in); got != want {
+			t.Errorf("normalizeHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+)
+
+// schemaGen builds JSON schemas from Go types the way encoding/json would
+// marshal them. Named struct types become shared components.
+type schemaGen struct {
+	components map[string]any
+}
+
+func newSchemaGen() *schemaGen {
+	return &schemaGen{components: make(map[string]any)}
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schema returns the schema of t, registering components as needed.
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case t.Kind() != reflect.Struct && reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	default:
+		// Interfaces and anything else can hold any JSON value.
+		return map[string]any{}
+	}
+}
+
+// ref registers the named struct type t as a component and returns a reference to it.
+func (g *schemaGen) ref(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	name := componentName(t)
+	if _, ok := g.components[name]; !ok {
+		// Reserve the name first so recursive types terminate.
+		g.components[name] = nil
+		g.components[name] = g.object(t)
+	}
+
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// object builds the schema of a struct from its JSON field names.
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+
+	g.fields(t, properties, &required)
+
+	obj := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+
+	return obj
+}
+
+func (g *schemaGen) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened, like encoding/json does.
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, properties, required)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = g.schema(f.Type)
+
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// componentName names a component after its package and type, e.g.
+// "image.Summary". Types of this package go without the prefix.
+func componentName(t reflect.Type) string {
+	if t.PkgPath() == reflect.TypeFor[Report]().PkgPath() {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// Spec returns the OpenAPI 3.0 document of the API. The schemas are generated
+// from the Go types the handlers encode, so they cannot drift from the responses.
+func Spec() map[string]any {
+	g := newSchemaGen()
+
+	ref := func(v any) map[string]any {
+		return g.ref(reflect.TypeOf(v))
+	}
+	content := func(schema map[string]any) map[string]any {
+		return map[string]any{"application/json": map[string]any{"schema": schema}}
+	}
+	response := func(description string, schema map[string]any) map[string]any {
+		return map[string]any{"description": description, "content": content(schema)}
+	}
+	errorResponse := func(description string) map[string]any {
+		return response(description, ref(Error{}))
+	}
+	idParam := func(description string) []any {
+		return []any{map[string]any{
+			"name": "id", "in": "path", "required": true,
+			"description": description,
+			"schema":      map[string]any{"type": "string"},
+		}}
+	}
+	query := func(name, typ, description string) map[string]any {
+		return map[string]any{
+			"name": name, "in": "query", "description": description,
+			"schema": map[string]any{"type": typ},
+		}
+	}
+
+	plan := ref(Plan{})
+	runs := g.schema(reflect.TypeFor[[]*audit.Run]())
+
+	paths := map[string]any{
+		"/api/v1/report": map[string]any{
+			"get": map[string]any{
+				"operationId": "getReport",
+				"summary":     "Analyze the host and return its unused resources",
+				"responses": map[string]any{
+					"200": response("Current report", ref(Report{})),
+				},
+			},
+		},
+		"/api/v1/plans": map[string]any{
+			"post": map[string]any{
+				"operationId": "createPlan",
+				"summary":     "Analyze the host and plan the removal of the selected unused resources",
+				"requestBody": map[string]any{"required": false, "content": content(ref(PlanRequest{}))},
+				"responses": map[string]any{
+					"201": response("Created plan", plan),
+					"400": errorResponse("Invalid request"),
+				},
+			},
+		},
+		"/api/v1/plans/{id}": map[string]any{
+			"get": map[string]any{
+				"operationId": "getPlan",
+				"summary":     "Get a plan and, once applied, its result",
+				"parameters":  idParam("Plan ID"),
+				"responses": map[string]any{
+					"200": response("Plan", plan),
+					"404": errorResponse("Unknown plan"),
+				},
+			},
+		},
+		"/api/v1/plans/{id}/apply": map[string]any{
+			"post": map[string]any{
+				"operationId": "applyPlan",
+				"summary":     "Start removing the resources of a pending plan",
+				"parameters":  idParam("Plan ID"),
+				"responses": map[string]any{
+					"202": response("Plan started", plan),
+					"403": errorResponse("Server is read-only"),
+					"404": errorResponse("Unknown plan"),
+					"409": errorResponse("Plan is not pending or another plan is running"),
+				},
+			},
+		},
+		"/api/v1/plans/{id}/events": map[string]any{
+			"get": map[string]any{
+				"operationId": "streamPlanEvents",
+				"summary":     "Stream the progress of a plan as server-sent events",
+				"description": "Each SSE message carries an Event as JSON data; the stream ends after the \"finished\" event. Send Last-Event-ID to resume.",
+				"parameters":  idParam("Plan ID"),
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Event stream",
+						"content": map[string]any{
+							"text/event-stream": map[string]any{"schema": ref(Event{})},
+						},
+					},
+					"404": errorResponse("Unknown plan"),
+				},
+			},
+		},
+		"/api/v1/history": map[string]any{
+			"get": map[string]any{
+				"operationId": "listHistory",
+				"summary":     "List past cleanup runs from the audit log, newest first",
+				"parameters": []any{
+					query("since", "string", "Only runs started after this time (e.g. 24h, 7d, 2024-05-01)"),
+					query("user", "string", "Only runs by this user"),
+					query("host", "string", "Only runs on this host"),
+					query("kind", "string", "Only runs that removed this kind of resource"),
+					query("resource", "string", "Only runs that touched this resource (ID prefix or name)"),
+					query("failed", "boolean", "Only runs with failures"),
+					query("limit", "integer", "Maximum number of runs (default 50)"),
+				},
+				"responses": map[string]any{
+					"200": response("Runs", runs),
+					"400": errorResponse("Invalid query"),
+				},
+			},
+		},
+		"/api/v1/history/{id}": map[string]any{
+			"get": map[string]any{
+				"operationId": "getRun",
+				"summary":     "Get a single cleanup run",
+				"parameters":  idParam("Run ID or a unique prefix of it"),
+				"responses": map[string]any{
+					"200": response("Run", ref(audit.Run{})),
+					"404": errorResponse("Unknown run"),
+				},
+			},
+		},
+	}
+
+	for _, item := range paths {
+		for _, op := range item.(map[string]any) {
+			op.(map[string]any)["responses"].(map[string]any)["401"] = errorResponse("Missing or invalid token")
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "dockr API",
+			"description": "Analyze and clean unused Docker resources.",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.components,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{map[string]any{"bearer": []any{}}},
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaFollowsJSONEncoding(t *testing.T) {
+	type inner struct {
+		Value int `json:"value"`
+	}
+	type sample struct {
+		inner
+		Name     string `json:"name"`
+		Optional string `json:"optional,omitempty"`
+		Skipped  string `json:"-"`
+		Untagged bool
+		Labels   map[string]string `json:"labels"`
+		Data     []byte            `json:"data"`
+		Any      any               `json:"any"`
+		private  int
+	}
+
+	g := newSchemaGen()
+	g.ref(reflect.TypeFor[sample]())
+
+	obj := g.components["sample"].(map[string]any)
+	props := obj["properties"].(map[string]any)
+
+	for _, name := range []string{"value", "name", "optional", "Untagged", "labels", "data", "any"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("missing property %q", name)
+		}
+	}
+	for _, name := range []string{"Skipped", "-", "private", "inner"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("unexpected property %q", name)
+		}
+	}
+
+	for _, name := range obj["required"].([]string) {
+		if name == "optional" {
+			t.Error("omitempty field must not be required")
+		}
+	}
+
+	if got := props["data"].(map[string]any)["format"]; got != "byte" {
+		t.Errorf("[]byte format = %v, want byte", got)
+	}
+}
+
+func TestSpecUsesDomainTypes(t *testing.T) {
+	spec := Spec()
+
+	if _, err := json.Marshal(spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+
+	for _, name := range []string{"Report", "Plan", "PlanRequest", "Event", "Error", "domain.UnusedResources", "image.Summary", "volume.Volume", "audit.Run"} {
+		if schemas[name] == nil {
+			t.Errorf("missing component %q", name)
+		}
+	}
+
+	resources := schemas["domain.UnusedResources"].(map[string]any)["properties"].(map[string]any)
+	images := resources["images"].(map[string]any)["items"].(map[string]any)
+	if images["$ref"] != "#/components/schemas/image.Summary" {
+		t.Errorf("images items = %v", images)
+	}
+
+	summary := schemas["image.Summary"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := summary["Id"]; !ok {
+		t.Error("image.Summary has no Id property")
+	}
+}
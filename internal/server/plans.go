@@ -0,0 +1,132 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+)
+
+// planState is a plan with its progress. Subscribers wait on changed, which
+// is closed and replaced whenever an event is added.
+type planState struct {
+	id        string
+	plan      *dockr.Plan
+	createdAt time.Time
+
+	mu      sync.Mutex
+	status  PlanStatus
+	run     *audit.Run
+	err     error
+	events  []Event
+	changed chan struct{}
+}
+
+func newPlanState(id string, plan *dockr.Plan) *planState {
+	return &planState{
+		id:        id,
+		plan:      plan,
+		createdAt: time.Now().UTC(),
+		status:    StatusPending,
+		changed:   make(chan struct{}),
+	}
+}
+
+// info returns the API view of the plan.
+func (p *planState) info() Plan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info := Plan{
+		ID:        p.id,
+		Status:    p.status,
+		CreatedAt: p.createdAt,
+		Count:     p.plan.Resources.TotalCount(),
+		TotalSize: int64(p.plan.Resources.TotalSize()),
+		Resources: p.plan.Resources,
+		Run:       p.run,
+	}
+	if p.err != nil {
+		info.Error = p.err.Error()
+	}
+
+	return info
+}
+
+// start moves a pending plan to running. It reports false for any other status.
+func (p *planState) start() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status != StatusPending {
+		return false
+	}
+	p.status = StatusRunning
+
+	return true
+}
+
+func (p *planState) publish(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.append(e)
+}
+
+// finish records the outcome and sends the closing event.
+func (p *planState) finish(status PlanStatus, run *audit.Run, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status, p.run, p.err = status, run, err
+
+	e := Event{Type: eventFinished, Status: status, Total: p.plan.Resources.TotalCount()}
+	if run != nil {
+		e.Done = len(run.Entries)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	p.append(e)
+}
+
+func (p *planState) append(e Event) {
+	p.events = append(p.events, e)
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// since returns the events from index i on, a channel closed on the next
+// event, and whether the plan has finished.
+func (p *planState) since(i int) ([]Event, <-chan struct{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var events []Event
+	if i < len(p.events) {
+		events = append(events, p.events[i:]...)
+	}
+
+	finished := p.status != StatusPending && p.status != StatusRunning
+
+	return events, p.changed, finished
+}
+
+// newEvent converts a library event into its API form.
+func newEvent(e dockr.Event) Event {
+	out := Event{Type: string(e.Type), Done: e.Done, Total: e.Total}
+
+	if e.Resource != nil {
+		out.Kind = e.Resource.Kind
+		out.ID = e.Resource.ID
+		out.Name = e.Resource.Name
+		out.Size = e.Resource.Size
+	}
+	if e.Err != nil {
+		out.Error = e.Err.Error()
+	}
+
+	return out
+}
@@ -0,0 +1,421 @@
+// Package server exposes analysis and cleanup over an HTTP API.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+)
+
+const (
+	// maxPlans is how many plans are kept in memory; the oldest finished or
+	// pending ones are dropped first.
+	maxPlans = 100
+	// defaultHistoryLimit caps GET /api/v1/history without a limit.
+	defaultHistoryLimit = 50
+	maxRequestBody      = 1 << 20
+)
+
+// Options configures the API server.
+type Options struct {
+	// Token is the bearer token every API request must carry. With an empty
+	// token all API requests are rejected.
+	Token string
+	// ReadOnly rejects applying plans.
+	ReadOnly bool
+	// AuditLog is the audit log read by the history endpoints.
+	AuditLog string
+	// Analyze finds the unused resources on the host.
+	Analyze func(ctx context.Context) (*dockr.Report, error)
+	// Apply removes the resources of a plan. It returns a nil run if the run
+	// never started; the error then says why (a veto wraps domain.ErrSkipped).
+	// The plan is a copy the stored one is not affected by.
+	Apply func(ctx context.Context, plan *dockr.Plan) (*audit.Run, error)
+}
+
+// Server serves the HTTP API. Only one plan is applied at a time.
+type Server struct {
+	opts Options
+
+	mu      sync.Mutex
+	plans   map[string]*planState
+	order   []string
+	running *planState
+}
+
+// New creates a server.
+func New(opts Options) *Server {
+	return &Server{
+		opts:  opts,
+		plans: make(map[string]*planState),
+	}
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/v1/report", s.handleReport)
+	api.HandleFunc("POST /api/v1/plans", s.handleCreatePlan)
+	api.HandleFunc("GET /api/v1/plans/{id}", s.handleGetPlan)
+	api.HandleFunc("POST /api/v1/plans/{id}/apply", s.handleApplyPlan)
+	api.HandleFunc("GET /api/v1/plans/{id}/events", s.handlePlanEvents)
+	api.HandleFunc("GET /api/v1/history", s.handleHistory)
+	api.HandleFunc("GET /api/v1/history/{id}", s.handleRun)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Spec())
+	})
+	mux.Handle("/api/", s.authorize(api))
+
+	return mux
+}
+
+// Publish forwards a progress event of the running plan to its subscribers.
+// It is meant to be used as dockr.Options.OnEvent.
+func (s *Server) Publish(e dockr.Event) {
+	s.mu.Lock()
+	p := s.running
+	s.mu.Unlock()
+
+	if p == nil {
+		return
+	}
+
+	switch e.Type {
+	case dockr.EventRemoveStarted, dockr.EventRemoved, dockr.EventSkipped, dockr.EventFailed, dockr.EventCleanFinished:
+		p.publish(newEvent(e))
+	}
+}
+
+// authorize accepts requests carrying the token as a bearer token, or as the
+// access_token query parameter for clients such as EventSource that cannot
+// set headers.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("access_token")
+		}
+
+		if s.opts.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dockr"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.opts.Analyze(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Report{
+		Engine:      report.Engine,
+		Host:        report.Host,
+		GeneratedAt: report.GeneratedAt,
+		Count:       report.Resources.TotalCount(),
+		TotalSize:   int64(report.Resources.TotalSize()),
+		Resources:   report.Resources,
+	})
+}
+
+func (s *Server) handleCreatePlan(w http.ResponseWriter, r *http.Request) {
+	var req PlanRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, kind := range req.Kinds {
+		if !slices.Contains(domain.Kinds, kind) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown resource kind %q", kind))
+			return
+		}
+	}
+
+	report, err := s.opts.Analyze(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	plan := dockr.NewPlan(report).Filter(req.selects)
+	p := s.addPlan(plan)
+
+	writeJSON(w, http.StatusCreated, p.info())
+}
+
+// selects reports whether the request puts res into the plan.
+func (req PlanRequest) selects(res domain.Resource) bool {
+	key := res.Key()
+
+	if slices.Contains(req.Exclude, key) {
+		return false
+	}
+	if len(req.Kinds) > 0 && !slices.Contains(req.Kinds, res.Kind) {
+		return false
+	}
+	if len(req.Keys) > 0 && !slices.Contains(req.Keys, key) {
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) handleGetPlan(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.plan(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan %q not found", r.PathValue("id")))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p.info())
+}
+
+func (s *Server) handleApplyPlan(w http.ResponseWriter, r *http.Request) {
+	if s.opts.ReadOnly {
+		writeError(w, http.StatusForbidden, errors.New("server is read-only"))
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.plans[r.PathValue("id")]
+	switch {
+	case !ok:
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan %q not found", r.PathValue("id")))
+		return
+	case s.running != nil:
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("plan %s is running", s.running.id))
+		return
+	case !p.start():
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("plan %s is %s", p.id, p.info().Status))
+		return
+	}
+	s.running = p
+	s.mu.Unlock()
+
+	// The removal outlives the request; it is not cancelled when the client goes away.
+	go s.apply(p)
+
+	writeJSON(w, http.StatusAccepted, p.info())
+}
+
+func (s *Server) apply(p *planState) {
+	plan := *p.plan
+	run, err := s.opts.Apply(context.Background(), &plan)
+
+	s.mu.Lock()
+	s.running = nil
+	s.mu.Unlock()
+
+	status := StatusDone
+	switch {
+	case run == nil && errors.Is(err, domain.ErrSkipped):
+		status = StatusCancelled
+	case err != nil:
+		status = StatusFailed
+	}
+
+	p.finish(status, run, err)
+}
+
+func (s *Server) handlePlanEvents(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.plan(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("plan %q not found", r.PathValue("id")))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	next := 0
+	if last, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		next = last + 1
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		events, changed, finished := p.since(next)
+
+		for _, e := range events {
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", next, e.Type, data)
+			next++
+		}
+		flusher.Flush()
+
+		if finished {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := audit.Filter{
+		User:     q.Get("user"),
+		Host:     q.Get("host"),
+		Kind:     domain.ResourceKind(q.Get("kind")),
+		Resource: q.Get("resource"),
+	}
+
+	if since := q.Get("since"); since != "" {
+		t, err := audit.ParseSince(since, time.Now())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		filter.Since = t
+	}
+
+	if failed := q.Get("failed"); failed != "" {
+		v, err := strconv.ParseBool(failed)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid failed value %q", failed))
+			return
+		}
+		filter.Failed = v
+	}
+
+	limit := defaultHistoryLimit
+	if l := q.Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit value %q", l))
+			return
+		}
+		limit = v
+	}
+
+	runs, err := audit.Load(s.opts.AuditLog)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	matched := []*audit.Run{}
+	for i := len(runs) - 1; i >= 0 && len(matched) < limit; i-- {
+		if filter.Match(runs[i]) {
+			matched = append(matched, runs[i])
+		}
+	}
+
+	writeJSON(w, http.StatusOK, matched)
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	runs, err := audit.Load(s.opts.AuditLog)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	run, err := audit.Find(runs, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (s *Server) plan(id string) (*planState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.plans[id]
+	return p, ok
+}
+
+// addPlan stores a new pending plan, dropping the oldest plans that are not
+// running once there are more than maxPlans.
+func (s *Server) addPlan(plan *dockr.Plan) *planState {
+	p := newPlanState(newPlanID(), plan)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.plans[p.id] = p
+	s.order = append(s.order, p.id)
+
+	for i := 0; len(s.plans) > maxPlans && i < len(s.order); {
+		if id := s.order[i]; s.plans[id] != s.running {
+			delete(s.plans, id)
+			s.order = slices.Delete(s.order, i, i+1)
+			continue
+		}
+		i++
+	}
+
+	return p
+}
+
+func newPlanID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
@@ -0,0 +1,352 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/pkg/dockr"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+const testToken = "s3cret"
+
+func testReport(context.Context) (*dockr.Report, error) {
+	return &dockr.Report{
+		Engine:      domain.EngineDocker,
+		Host:        "unix:///var/run/docker.sock",
+		GeneratedAt: time.Now(),
+		Resources: &domain.UnusedResources{
+			Images:  []*image.Summary{{ID: "sha256:aaa", Size: 100}},
+			Volumes: []*volume.Volume{{Name: "data"}},
+		},
+	}, nil
+}
+
+// newTestServer returns a server whose Apply publishes a removal event per
+// resource and records a run.
+func newTestServer(t *testing.T, readOnly bool) (*Server, *httptest.Server) {
+	t.Helper()
+
+	var srv *Server
+	srv = New(Options{
+		Token:    testToken,
+		ReadOnly: readOnly,
+		AuditLog: filepath.Join(t.TempDir(), "audit.jsonl"),
+		Analyze:  testReport,
+		Apply: func(ctx context.Context, plan *dockr.Plan) (*audit.Run, error) {
+			run := audit.NewRun(nil, nil, audit.Policy{}, "")
+			for _, res := range plan.Resources.Items() {
+				srv.Publish(dockr.Event{Type: dockr.EventRemoved, Resource: &res})
+				run.Entries = append(run.Entries, audit.Entry{Kind: res.Kind, ID: res.ID, Result: audit.ResultRemoved})
+			}
+			return run, nil
+		},
+	})
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	return srv, ts
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func decode[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+
+	var v T
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return v
+}
+
+func TestAuthorization(t *testing.T) {
+	_, ts := newTestServer(t, false)
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{name: "no token", path: "/api/v1/report", want: http.StatusUnauthorized},
+		{name: "wrong token", path: "/api/v1/report", token: "nope", want: http.StatusUnauthorized},
+		{name: "bearer token", path: "/api/v1/report", token: testToken, want: http.StatusOK},
+		{name: "query token", path: "/api/v1/report?access_token=" + testToken, want: http.StatusOK},
+		{name: "openapi is public", path: "/openapi.json", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, http.MethodGet, ts.URL+tt.path, tt.token, "")
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyTokenRejectsEverything(t *testing.T) {
+	ts := httptest.NewServer(New(Options{Analyze: testReport}).Handler())
+	defer ts.Close()
+
+	resp := do(t, http.MethodGet, ts.URL+"/api/v1/report", "", "")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestReport(t *testing.T) {
+	_, ts := newTestServer(t, false)
+
+	report := decode[Report](t, do(t, http.MethodGet, ts.URL+"/api/v1/report", testToken, ""))
+	if report.Count != 2 || report.TotalSize != 100 || report.Engine != domain.EngineDocker {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Resources.Images) != 1 || report.Resources.Images[0].ID != "sha256:aaa" {
+		t.Errorf("unexpected resources: %+v", report.Resources)
+	}
+}
+
+func TestCreatePlan(t *testing.T) {
+	_, ts := newTestServer(t, false)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "everything", body: "", wantStatus: http.StatusCreated, wantCount: 2},
+		{name: "by kind", body: `{"kinds":["volume"]}`, wantStatus: http.StatusCreated, wantCount: 1},
+		{name: "by key", body: `{"keys":["image/sha256:aaa"]}`, wantStatus: http.StatusCreated, wantCount: 1},
+		{name: "exclude", body: `{"exclude":["volume/data","image/sha256:aaa"]}`, wantStatus: http.StatusCreated, wantCount: 0},
+		{name: "unknown kind", body: `{"kinds":["pod"]}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", body: `{"all":true}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, http.MethodPost, ts.URL+"/api/v1/plans", testToken, tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			plan := decode[Plan](t, resp)
+			if plan.Count != tt.wantCount || plan.Status != StatusPending || plan.ID == "" {
+				t.Errorf("unexpected plan: %+v", plan)
+			}
+		})
+	}
+}
+
+func TestApplyPlanStreamsEvents(t *testing.T) {
+	_, ts := newTestServer(t, false)
+
+	plan := decode[Plan](t, do(t, http.MethodPost, ts.URL+"/api/v1/plans", testToken, ""))
+
+	resp := do(t, http.MethodPost, ts.URL+"/api/v1/plans/"+plan.ID+"/apply", testToken, "")
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("apply status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	stream := do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID+"/events", testToken, "")
+	if ct := stream.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			types = append(types, name)
+		}
+	}
+
+	if got := strings.Join(types, ","); got != "removed,removed,finished" {
+		t.Errorf("events = %s, want removed,removed,finished", got)
+	}
+
+	done := decode[Plan](t, do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID, testToken, ""))
+	if done.Status != StatusDone || done.Run == nil || len(done.Run.Entries) != 2 {
+		t.Errorf("unexpected finished plan: %+v", done)
+	}
+
+	again := do(t, http.MethodPost, ts.URL+"/api/v1/plans/"+plan.ID+"/apply", testToken, "")
+	if again.StatusCode != http.StatusConflict {
+		t.Errorf("second apply status = %d, want %d", again.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestApplyPlanVetoed(t *testing.T) {
+	srv := New(Options{
+		Token:   testToken,
+		Analyze: testReport,
+		Apply: func(context.Context, *dockr.Plan) (*audit.Run, error) {
+			return nil, fmt.Errorf("maintenance window: %w", domain.ErrSkipped)
+		},
+	})
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	plan := decode[Plan](t, do(t, http.MethodPost, ts.URL+"/api/v1/plans", testToken, ""))
+	do(t, http.MethodPost, ts.URL+"/api/v1/plans/"+plan.ID+"/apply", testToken, "")
+
+	// Reading the stream to its end waits for the run to finish.
+	stream := do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID+"/events", testToken, "")
+	_, _ = bufio.NewReader(stream.Body).WriteTo(&strings.Builder{})
+
+	got := decode[Plan](t, do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID, testToken, ""))
+	if got.Status != StatusCancelled || !strings.Contains(got.Error, "maintenance window") {
+		t.Errorf("unexpected plan: %+v", got)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	_, ts := newTestServer(t, true)
+
+	plan := decode[Plan](t, do(t, http.MethodPost, ts.URL+"/api/v1/plans", testToken, ""))
+
+	resp := do(t, http.MethodPost, ts.URL+"/api/v1/plans/"+plan.ID+"/apply", testToken, "")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestUnknownPlan(t *testing.T) {
+	_, ts := newTestServer(t, false)
+
+	for _, path := range []string{"/api/v1/plans/nope", "/api/v1/plans/nope/events"} {
+		resp := do(t, http.MethodGet, ts.URL+path, testToken, "")
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	srv, ts := newTestServer(t, false)
+
+	old := audit.NewRun(nil, nil, audit.Policy{}, "")
+	old.StartedAt = time.Now().Add(-72 * time.Hour)
+	recent := audit.NewRun(nil, nil, audit.Policy{}, "")
+	recent.Entries = []audit.Entry{{Kind: domain.KindVolume, ID: "data", Result: audit.ResultFailed}}
+
+	for _, run := range []*audit.Run{old, recent} {
+		if err := audit.Append(srv.opts.AuditLog, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantIDs    []string
+	}{
+		{query: "", wantStatus: http.StatusOK, wantIDs: []string{recent.ID, old.ID}},
+		{query: "?since=24h", wantStatus: http.StatusOK, wantIDs: []string{recent.ID}},
+		{query: "?failed=true", wantStatus: http.StatusOK, wantIDs: []string{recent.ID}},
+		{query: "?limit=1", wantStatus: http.StatusOK, wantIDs: []string{recent.ID}},
+		{query: "?kind=image", wantStatus: http.StatusOK, wantIDs: []string{}},
+		{query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+		{query: "?limit=0", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp := do(t, http.MethodGet, ts.URL+"/api/v1/history"+tt.query, testToken, "")
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var ids []string
+			for _, run := range decode[[]*audit.Run](t, resp) {
+				ids = append(ids, run.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("runs = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+
+	run := decode[audit.Run](t, do(t, http.MethodGet, ts.URL+"/api/v1/history/"+recent.ID[:20], testToken, ""))
+	if run.ID != recent.ID {
+		t.Errorf("run = %s, want %s", run.ID, recent.ID)
+	}
+}
+
+func TestGetPlanWhileApplying(t *testing.T) {
+	applying := make(chan struct{})
+	release := make(chan struct{})
+
+	srv := New(Options{
+		Token:   testToken,
+		Analyze: testReport,
+		Apply: func(_ context.Context, plan *dockr.Plan) (*audit.Run, error) {
+			// Apply may narrow its plan, as dockr serve does after the
+			// re-pullability check.
+			plan.Resources = &domain.UnusedResources{}
+			plan.RemoveVolumes = true
+			close(applying)
+			<-release
+			return audit.NewRun(nil, nil, audit.Policy{}, ""), nil
+		},
+	})
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	plan := decode[Plan](t, do(t, http.MethodPost, ts.URL+"/api/v1/plans", testToken, ""))
+	do(t, http.MethodPost, ts.URL+"/api/v1/plans/"+plan.ID+"/apply", testToken, "")
+
+	<-applying
+	running := decode[Plan](t, do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID, testToken, ""))
+	close(release)
+
+	stream := do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID+"/events", testToken, "")
+	_, _ = bufio.NewReader(stream.Body).WriteTo(&strings.Builder{})
+	done := decode[Plan](t, do(t, http.MethodGet, ts.URL+"/api/v1/plans/"+plan.ID, testToken, ""))
+
+	for _, got := range []Plan{running, done} {
+		if got.Count != 2 {
+			t.Errorf("plan %s reports %d resources, want the 2 it was created with", got.Status, got.Count)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/audit"
+	"github.com/DobryySoul/dockr/internal/domain"
+)
+
+// Report is the response of GET /api/v1/report.
+type Report struct {
+	Engine      domain.Engine           `json:"engine"`
+	Host        string                  `json:"host"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Count       int                     `json:"count"`
+	TotalSize   int64                   `json:"total_size"`
+	Resources   *domain.UnusedResources `json:"resources"`
+}
+
+// PlanRequest is the body of POST /api/v1/plans. Without kinds and keys the
+// plan covers every unused resource; exclude always wins.
+type PlanRequest struct {
+	// Kinds limits the plan to these resource kinds.
+	Kinds []domain.ResourceKind `json:"kinds,omitempty"`
+	// Keys limits the plan to these resources, as "<kind>/<id>".
+	Keys []string `json:"keys,omitempty"`
+	// Exclude drops these resources, as "<kind>/<id>".
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// PlanStatus is the lifecycle state of a plan.
+type PlanStatus string
+
+const (
+	StatusPending   PlanStatus = "pending"
+	StatusRunning   PlanStatus = "running"
+	StatusDone      PlanStatus = "done"
+	StatusFailed    PlanStatus = "failed"
+	StatusCancelled PlanStatus = "cancelled"
+)
+
+// Plan is a set of resources to remove and, once applied, the audit record of the run.
+type Plan struct {
+	ID        string                  `json:"id"`
+	Status    PlanStatus              `json:"status"`
+	CreatedAt time.Time               `json:"created_at"`
+	Count     int                     `json:"count"`
+	TotalSize int64                   `json:"total_size"`
+	Resources *domain.UnusedResources `json:"resources"`
+	Run       *audit.Run              `json:"run,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+}
+
+// Event is a progress event of an applied plan, streamed as server-sent events.
+// The SSE event name equals Type; the last event of a plan is "finished".
+type Event struct {
+	Type   string              `json:"type"`
+	Kind   domain.ResourceKind `json:"kind,omitempty"`
+	ID     string              `json:"id,omitempty"`
+	Name   string              `json:"name,omitempty"`
+	Size   int64               `json:"size,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	Done   int                 `json:"done"`
+	Total  int                 `json:"total"`
+	Status PlanStatus          `json:"status,omitempty"`
+}
+
+// eventFinished is the type of the event that closes a plan's stream.
+const eventFinished = "finished"
+
+// Error is the body of every error response.
+type Error struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// ErrReadOnly is returned by every call of API that would change the host.
+var ErrReadOnly = errors.New("a snapshot is read-only")
+
+// API answers engine API calls from a snapshot. List calls honor label
+// filters only; every change fails with ErrReadOnly.
+type API struct {
+	s *Snapshot
+}
+
+var _ docker.API = (*API)(nil)
+
+// NewAPI serves the snapshot as an engine API.
+func NewAPI(s *Snapshot) *API {
+	return &API{s: s}
+}
+
+func (a *API) Close() error { return nil }
+
+// DaemonHost returns the address of the daemon the snapshot was captured from.
+func (a *API) DaemonHost() string { return a.s.Host }
+
+func (a *API) Info(context.Context) (system.Info, error) { return a.s.Info, nil }
+
+func (a *API) ServerVersion(context.Context) (types.Version, error) { return a.s.ServerVersion, nil }
+
+func (a *API) DiskUsage(context.Context, types.DiskUsageOptions) (types.DiskUsage, error) {
+	return a.s.DiskUsage, nil
+}
+
+func (a *API) ContainerList(_ context.Context, options container.ListOptions) ([]container.Summary, error) {
+	var containers []container.Summary
+	for _, c := range a.s.Containers {
+		if !options.All && c.Summary.State != "running" {
+			continue
+		}
+		if !matchLabels(options.Filters, c.Summary.Labels) {
+			continue
+		}
+		containers = append(containers, c.Summary)
+	}
+	return containers, nil
+}
+
+func (a *API) ContainerInspect(_ context.Context, containerID string) (container.InspectResponse, error) {
+	for _, c := range a.s.Containers {
+		if c.Summary.ID == containerID || strings.TrimPrefix(c.Inspect.Name, "/") == containerID {
+			return c.Inspect, nil
+		}
+	}
+	return container.InspectResponse{}, notFound("container", containerID)
+}
+
+func (a *API) ContainerInspectWithRaw(ctx context.Context, containerID string, _ bool) (container.InspectResponse, []byte, error) {
+	info, err := a.ContainerInspect(ctx, containerID)
+	return info, nil, err
+}
+
+func (a *API) ContainerRemove(context.Context, string, container.RemoveOptions) error {
+	return ErrReadOnly
+}
+
+func (a *API) ImageList(_ context.Context, options image.ListOptions) ([]image.Summary, error) {
+	var images []image.Summary
+	for _, img := range a.s.Images {
+		if matchLabels(options.Filters, img.Summary.Labels) {
+			images = append(images, img.Summary)
+		}
+	}
+	return images, nil
+}
+
+func (a *API) ImageInspect(_ context.Context, imageID string, _ ...client.ImageInspectOption) (image.InspectResponse, error) {
+	for _, img := range a.s.Images {
+		if img.Summary.ID == imageID || slices.Contains(img.Summary.RepoTags, imageID) {
+			return img.Inspect, nil
+		}
+	}
+	return image.InspectResponse{}, notFound("image", imageID)
+}
+
+func (a *API) ImageRemove(context.Context, string, image.RemoveOptions) ([]image.DeleteResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *API) ImageTag(context.Context, string, string) error {
+	return ErrReadOnly
+}
+
+func (a *API) ImagePush(context.Context, string, image.PushOptions) (io.ReadCloser, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *API) VolumeList(_ context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	var resp volume.ListResponse
+	for i := range a.s.Volumes {
+		v := a.s.Volumes[i]
+		if matchLabels(options.Filters, v.Labels) {
+			resp.Volumes = append(resp.Volumes, &v)
+		}
+	}
+	return resp, nil
+}
+
+func (a *API) VolumeInspect(_ context.Context, volumeID string) (volume.Volume, error) {
+	for _, v := range a.s.Volumes {
+		if v.Name == volumeID {
+			return v, nil
+		}
+	}
+	return volume.Volume{}, notFound("volume", volumeID)
+}
+
+func (a *API) VolumeRemove(context.Context, string, bool) error {
+	return ErrReadOnly
+}
+
+func (a *API) NetworkList(_ context.Context, options network.ListOptions) ([]network.Summary, error) {
+	var networks []network.Summary
+	for _, n := range a.s.Networks {
+		if matchLabels(options.Filters, n.Labels) {
+			networks = append(networks, n)
+		}
+	}
+	return networks, nil
+}
+
+func (a *API) NetworkInspect(_ context.Context, networkID string, _ network.InspectOptions) (network.Inspect, error) {
+	for _, n := range a.s.Networks {
+		if n.ID == networkID || n.Name == networkID {
+			return n, nil
+		}
+	}
+	return network.Inspect{}, notFound("network", networkID)
+}
+
+func (a *API) NetworkRemove(context.Context, string) error {
+	return ErrReadOnly
+}
+
+// matchLabels applies the "label" filter; other filters are ignored.
+func matchLabels(args filters.Args, labels map[string]string) bool {
+	return args.Len() == 0 || len(args.Get("label")) == 0 || args.MatchKVList("label", labels)
+}
+
+func notFound(kind, id string) error {
+	return fmt.Errorf("no such %s in snapshot: %s", kind, id)
+}
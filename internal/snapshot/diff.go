@@ -0,0 +1,204 @@
+package snapshot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+)
+
+// ChangeType is how a resource differs between two snapshots.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Change is one resource that differs between two snapshots. State is what
+// a change is detected on besides the size: the state of a container, the tags
+// of an image, and the number of containers using a volume or network.
+type Change struct {
+	Kind        domain.ResourceKind `json:"kind"`
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Change      ChangeType          `json:"change"`
+	StateBefore string              `json:"state_before,omitempty"`
+	StateAfter  string              `json:"state_after,omitempty"`
+	SizeBefore  int64               `json:"size_before"`
+	SizeAfter   int64               `json:"size_after"`
+	// Unused marks added or changed resources that the analysis of the later
+	// snapshot already reports as unused; see Diff.MarkUnused.
+	Unused bool `json:"unused"`
+}
+
+// Delta is the change in size.
+func (c Change) Delta() int64 {
+	return c.SizeAfter - c.SizeBefore
+}
+
+// Diff is what changed on a host between two snapshots.
+type Diff struct {
+	Host    string    `json:"host"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Changes []Change  `json:"changes"`
+}
+
+// Delta is the change in size of the resources of a kind.
+func (d *Diff) Delta(kind domain.ResourceKind) int64 {
+	var delta int64
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			delta += c.Delta()
+		}
+	}
+	return delta
+}
+
+// Of returns the changes of a kind.
+func (d *Diff) Of(kind domain.ResourceKind) []Change {
+	var changes []Change
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// MarkUnused flags the added and changed resources that are in unused, the
+// result of analyzing the later snapshot.
+func (d *Diff) MarkUnused(unused *domain.UnusedResources) {
+	keys := make(map[string]bool, unused.TotalCount())
+	for _, res := range unused.Items() {
+		keys[res.Key()] = true
+	}
+
+	for i := range d.Changes {
+		c := &d.Changes[i]
+		if c.Change != ChangeRemoved {
+			c.Unused = keys[domain.Resource{Kind: c.Kind, ID: c.ID}.Key()]
+		}
+	}
+}
+
+// entry is the state of one resource in a snapshot.
+type entry struct {
+	name  string
+	state string
+	size  int64
+}
+
+// Compare lists the resources that were added, removed or changed from before
+// to after, by kind in report order.
+func Compare(before, after *Snapshot) *Diff {
+	d := &Diff{Host: after.Host, From: before.CapturedAt, To: after.CapturedAt}
+
+	b, a := before.entries(), after.entries()
+	for _, kind := range domain.Kinds {
+		ids := make([]string, 0, len(b[kind])+len(a[kind]))
+		for id := range b[kind] {
+			ids = append(ids, id)
+		}
+		for id := range a[kind] {
+			if _, ok := b[kind][id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		slices.SortFunc(ids, func(x, y string) int {
+			return strings.Compare(entryName(a[kind], b[kind], x), entryName(a[kind], b[kind], y))
+		})
+
+		for _, id := range ids {
+			old, hadOld := b[kind][id]
+			cur, hasCur := a[kind][id]
+
+			c := Change{Kind: kind, ID: id, StateBefore: old.state, StateAfter: cur.state, SizeBefore: old.size, SizeAfter: cur.size}
+			switch {
+			case !hadOld:
+				c.Name, c.Change = cur.name, ChangeAdded
+			case !hasCur:
+				c.Name, c.Change = old.name, ChangeRemoved
+			case old.state != cur.state || old.size != cur.size:
+				c.Name, c.Change = cur.name, ChangeChanged
+			default:
+				continue
+			}
+			d.Changes = append(d.Changes, c)
+		}
+	}
+
+	return d
+}
+
+func entryName(a, b map[string]entry, id string) string {
+	if e, ok := a[id]; ok {
+		return e.name + "\x00" + id
+	}
+	return b[id].name + "\x00" + id
+}
+
+// entries indexes the resources of the snapshot by kind and ID.
+func (s *Snapshot) entries() map[domain.ResourceKind]map[string]entry {
+	entries := map[domain.ResourceKind]map[string]entry{
+		domain.KindImage:     {},
+		domain.KindContainer: {},
+		domain.KindVolume:    {},
+		domain.KindNetwork:   {},
+	}
+
+	for i := range s.Images {
+		img := &s.Images[i].Summary
+		res := domain.ImageResource(img)
+		entries[domain.KindImage][img.ID] = entry{name: res.Name, state: res.Name, size: img.Size}
+	}
+
+	for i := range s.Containers {
+		cont := &s.Containers[i].Summary
+		res := domain.ContainerResource(cont)
+		entries[domain.KindContainer][cont.ID] = entry{name: res.Name, state: cont.State, size: cont.SizeRw}
+	}
+
+	volumeUsage := make(map[string]entry, len(s.DiskUsage.Volumes))
+	for _, v := range s.DiskUsage.Volumes {
+		if v.UsageData != nil {
+			volumeUsage[v.Name] = entry{size: max(v.UsageData.Size, 0)}
+		}
+	}
+	mounts := make(map[string]int)
+	for _, c := range s.Containers {
+		for _, m := range c.Summary.Mounts {
+			if m.Type == "volume" {
+				mounts[m.Name]++
+			}
+		}
+	}
+	for _, v := range s.Volumes {
+		entries[domain.KindVolume][v.Name] = entry{name: v.Name, state: containerCount(mounts[v.Name]), size: volumeUsage[v.Name].size}
+	}
+
+	var containers []container.Summary
+	for _, c := range s.Containers {
+		containers = append(containers, c.Summary)
+	}
+	users := docker.NewNetworkUsers(containers)
+	for i := range s.Networks {
+		net := &s.Networks[i]
+		entries[domain.KindNetwork][net.ID] = entry{name: net.Name, state: containerCount(len(users.Of(net)))}
+	}
+
+	return entries
+}
+
+func containerCount(n int) string {
+	if n == 1 {
+		return "1 container"
+	}
+	return fmt.Sprintf("%d containers", n)
+}
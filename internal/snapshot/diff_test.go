@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func TestCompare(t *testing.T) {
+	before := testSnapshot()
+
+	after := testSnapshot()
+	after.Containers[1].Summary.State = "running"
+	after.Containers = append(after.Containers, Container{Summary: container.Summary{
+		ID: "c3", Names: []string{"/test"}, State: "exited", SizeRw: 100,
+		Mounts: []container.MountPoint{{Type: "volume", Name: "data"}},
+	}})
+	after.Images[0].Summary.RepoTags = []string{"app:1", "app:latest"}
+	after.Images = append(after.Images, Image{Summary: image.Summary{ID: "sha256:i2", RepoTags: []string{"test:tmp"}, Size: 1000}})
+	after.Volumes = append(after.Volumes, volume.Volume{Name: "cache"})
+	after.Networks = nil
+
+	d := Compare(before, after)
+
+	want := []struct {
+		kind   domain.ResourceKind
+		id     string
+		change ChangeType
+		state  string
+	}{
+		{domain.KindImage, "sha256:i1", ChangeChanged, "app:1, app:latest"},
+		{domain.KindImage, "sha256:i2", ChangeAdded, "test:tmp"},
+		{domain.KindContainer, "c2", ChangeChanged, "running"},
+		{domain.KindContainer, "c3", ChangeAdded, "exited"},
+		{domain.KindVolume, "cache", ChangeAdded, "0 containers"},
+		{domain.KindVolume, "data", ChangeChanged, "1 container"},
+		{domain.KindNetwork, "n1", ChangeRemoved, ""},
+	}
+
+	if len(d.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(d.Changes), len(want), d.Changes)
+	}
+	for i, w := range want {
+		c := d.Changes[i]
+		if c.Kind != w.kind || c.ID != w.id || c.Change != w.change || c.StateAfter != w.state {
+			t.Errorf("change %d = %+v, want %v %s %s %q", i, c, w.kind, w.id, w.change, w.state)
+		}
+	}
+
+	if got := d.Delta(domain.KindImage); got != 1000 {
+		t.Errorf("image delta = %d, want 1000", got)
+	}
+
+	d.MarkUnused(&domain.UnusedResources{
+		Images:   []*image.Summary{{ID: "sha256:i2"}},
+		Networks: []*network.Summary{{ID: "n1"}},
+	})
+	for _, c := range d.Changes {
+		if wantUnused := c.ID == "sha256:i2"; c.Unused != wantUnused {
+			t.Errorf("%s unused = %v, want %v", c.ID, c.Unused, wantUnused)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Redaction selects what Redact removes from a snapshot.
+type Redaction struct {
+	// Env replaces the values of environment variables of containers and images.
+	Env bool
+	// Labels replaces the values of labels on every resource.
+	Labels bool
+}
+
+// redactedEnv replaces the value of an environment variable.
+const redactedEnv = "[redacted]"
+
+// Redact removes secrets from the snapshot in place. Environment variables
+// keep their names. Labels keep their keys, and their values become a hash
+// that is salted per snapshot: equal values stay equal, so grouping by labels
+// (e.g. CI pipelines or Compose projects) still works, but the values cannot
+// be guessed by hashing candidates.
+func (s *Snapshot) Redact(r Redaction) {
+	if r.Env {
+		for i := range s.Containers {
+			if cfg := s.Containers[i].Inspect.Config; cfg != nil {
+				cfg.Env = redactEnv(cfg.Env)
+			}
+		}
+		for i := range s.Images {
+			if cfg := s.Images[i].Inspect.Config; cfg != nil {
+				cfg.Env = redactEnv(cfg.Env)
+			}
+		}
+		s.Redacted = append(s.Redacted, "env")
+	}
+
+	if r.Labels {
+		h := newLabelHasher()
+
+		for i := range s.Containers {
+			c := &s.Containers[i]
+			h.redact(c.Summary.Labels)
+			if c.Inspect.Config != nil {
+				h.redact(c.Inspect.Config.Labels)
+			}
+		}
+		for i := range s.Images {
+			img := &s.Images[i]
+			h.redact(img.Summary.Labels)
+			if img.Inspect.Config != nil {
+				h.redact(img.Inspect.Config.Labels)
+			}
+		}
+		for i := range s.Volumes {
+			h.redact(s.Volumes[i].Labels)
+		}
+		for i := range s.Networks {
+			h.redact(s.Networks[i].Labels)
+		}
+
+		for _, img := range s.DiskUsage.Images {
+			h.redact(img.Labels)
+		}
+		for _, c := range s.DiskUsage.Containers {
+			h.redact(c.Labels)
+		}
+		for _, v := range s.DiskUsage.Volumes {
+			h.redact(v.Labels)
+		}
+
+		s.Redacted = append(s.Redacted, "labels")
+	}
+}
+
+func redactEnv(env []string) []string {
+	redacted := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		redacted = append(redacted, name+"="+redactedEnv)
+	}
+	return redacted
+}
+
+type labelHasher struct {
+	salt []byte
+}
+
+func newLabelHasher() *labelHasher {
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+	return &labelHasher{salt: salt}
+}
+
+func (h *labelHasher) redact(labels map[string]string) {
+	for key, value := range labels {
+		if value == "" {
+			continue
+		}
+		sum := sha256.Sum256(append(append([]byte{}, h.salt...), value...))
+		labels[key] = "redacted-" + hex.EncodeToString(sum[:6])
+	}
+}
@@ -0,0 +1,140 @@
+// Package snapshot captures the state of a host into a file that dockr can
+// analyze later, without access to the daemon.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// Version is the format version written to new snapshots.
+const Version = 1
+
+// Snapshot is everything the analysis reads from a daemon.
+type Snapshot struct {
+	Version    int           `json:"version"`
+	CapturedAt time.Time     `json:"captured_at"`
+	Host       string        `json:"host"`
+	Engine     domain.Engine `json:"engine"`
+	// Redacted lists what was redacted: "env", "labels".
+	Redacted      []string          `json:"redacted,omitempty"`
+	Info          system.Info       `json:"info"`
+	ServerVersion types.Version     `json:"server_version"`
+	DiskUsage     types.DiskUsage   `json:"disk_usage"`
+	Containers    []Container       `json:"containers"`
+	Images        []Image           `json:"images"`
+	Volumes       []volume.Volume   `json:"volumes"`
+	Networks      []network.Inspect `json:"networks"`
+}
+
+// Container is a container as listed (with sizes) and inspected.
+type Container struct {
+	Summary container.Summary         `json:"summary"`
+	Inspect container.InspectResponse `json:"inspect"`
+}
+
+// Image is an image as listed and inspected.
+type Image struct {
+	Summary image.Summary         `json:"summary"`
+	Inspect image.InspectResponse `json:"inspect"`
+}
+
+// Capture reads the state of the host behind api.
+func Capture(ctx context.Context, api docker.API, engine domain.Engine) (*Snapshot, error) {
+	s := &Snapshot{
+		Version:    Version,
+		CapturedAt: time.Now().UTC(),
+		Host:       api.DaemonHost(),
+		Engine:     engine,
+	}
+
+	var err error
+	if s.Info, err = api.Info(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get daemon info: %w", err)
+	}
+	if s.ServerVersion, err = api.ServerVersion(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get daemon version: %w", err)
+	}
+	if s.DiskUsage, err = api.DiskUsage(ctx, types.DiskUsageOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	containers, err := api.ContainerList(ctx, container.ListOptions{All: true, Size: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+	for _, c := range containers {
+		info, err := api.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container with ID: %s, err: %w", c.ID, err)
+		}
+		s.Containers = append(s.Containers, Container{Summary: c, Inspect: info})
+	}
+
+	images, err := api.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker images: %w", err)
+	}
+	for _, img := range images {
+		info, err := api.ImageInspect(ctx, img.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image with ID: %s, err: %w", img.ID, err)
+		}
+		s.Images = append(s.Images, Image{Summary: img, Inspect: info})
+	}
+
+	volumes, err := api.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker volumes: %w", err)
+	}
+	for _, v := range volumes.Volumes {
+		s.Volumes = append(s.Volumes, *v)
+	}
+
+	if s.Networks, err = api.NetworkList(ctx, network.ListOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to list Docker networks: %w", err)
+	}
+
+	return s, nil
+}
+
+// Write encodes the snapshot as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads a snapshot file written by Write.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if s.Version == 0 || s.Version > Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s (this dockr reads up to %d)", s.Version, path, Version)
+	}
+
+	return &s, nil
+}
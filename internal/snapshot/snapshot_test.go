@@ -0,0 +1,132 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		Version: Version,
+		Host:    "unix:///var/run/docker.sock",
+		Containers: []Container{
+			{
+				Summary: container.Summary{ID: "c1", Names: []string{"/web"}, State: "running", Labels: map[string]string{"ci.job": "42"}},
+				Inspect: container.InspectResponse{
+					ContainerJSONBase: &container.ContainerJSONBase{Name: "/web"},
+					Config:            &container.Config{Env: []string{"DB_PASSWORD=hunter2", "EMPTY"}, Labels: map[string]string{"ci.job": "42"}},
+				},
+			},
+			{
+				Summary: container.Summary{ID: "c2", Names: []string{"/old"}, State: "exited", Labels: map[string]string{"ci.job": "42"}},
+			},
+		},
+		Images: []Image{
+			{Summary: image.Summary{ID: "sha256:i1", RepoTags: []string{"app:1"}, Labels: map[string]string{"ci.job": "7"}}},
+		},
+		Volumes: []volume.Volume{
+			{Name: "data", Labels: map[string]string{"empty": ""}},
+		},
+		Networks: []network.Inspect{
+			{ID: "n1", Name: "backend"},
+		},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	s := testSnapshot()
+	s.Redact(Redaction{Env: true, Labels: true})
+
+	env := s.Containers[0].Inspect.Config.Env
+	if env[0] != "DB_PASSWORD=[redacted]" || env[1] != "EMPTY=[redacted]" {
+		t.Errorf("env = %v", env)
+	}
+
+	job := s.Containers[0].Summary.Labels["ci.job"]
+	if job == "42" || !strings.HasPrefix(job, "redacted-") {
+		t.Errorf("label not redacted: %q", job)
+	}
+	if s.Containers[1].Summary.Labels["ci.job"] != job || s.Containers[0].Inspect.Config.Labels["ci.job"] != job {
+		t.Error("equal label values must stay equal")
+	}
+	if s.Images[0].Summary.Labels["ci.job"] == job {
+		t.Error("different label values must stay different")
+	}
+	if v, ok := s.Volumes[0].Labels["empty"]; !ok || v != "" {
+		t.Errorf("empty label = %q, %v; want kept", v, ok)
+	}
+
+	if strings.Join(s.Redacted, ",") != "env,labels" {
+		t.Errorf("Redacted = %v", s.Redacted)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	api := NewAPI(testSnapshot())
+	ctx := context.Background()
+
+	running, _ := api.ContainerList(ctx, container.ListOptions{})
+	all, _ := api.ContainerList(ctx, container.ListOptions{All: true})
+	if len(running) != 1 || len(all) != 2 {
+		t.Errorf("listed %d running and %d containers, want 1 and 2", len(running), len(all))
+	}
+
+	labeled, _ := api.ImageList(ctx, image.ListOptions{Filters: filters.NewArgs(filters.Arg("label", "ci.job=8"))})
+	if len(labeled) != 0 {
+		t.Errorf("label filter matched %d images", len(labeled))
+	}
+
+	if _, err := api.ContainerInspect(ctx, "web"); err != nil {
+		t.Errorf("inspect by name: %v", err)
+	}
+	if _, err := api.ImageInspect(ctx, "app:1"); err != nil {
+		t.Errorf("inspect by tag: %v", err)
+	}
+	if _, err := api.NetworkInspect(ctx, "missing", network.InspectOptions{}); err == nil {
+		t.Error("expected an error for a missing network")
+	}
+
+	if err := api.VolumeRemove(ctx, "data", false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("VolumeRemove = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "host.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testSnapshot().Write(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Containers) != 2 || s.Containers[0].Inspect.Name != "/web" {
+		t.Errorf("loaded %+v", s.Containers)
+	}
+
+	future := filepath.Join(dir, "future.json")
+	if err := os.WriteFile(future, []byte(`{"version": 99}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(future); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
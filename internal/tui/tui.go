@@ -0,0 +1,460 @@
+// Package tui implements the full-screen resource picker used by `dockr -i` and `dockr tui`.
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/formatter"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Inspector returns the inspect object shown in the detail pane.
+type Inspector func(res domain.Resource) (any, error)
+
+type sortMode int
+
+const (
+	sortSize sortMode = iota
+	sortAge
+	sortName
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortAge:
+		return "age (oldest first)"
+	case sortName:
+		return "name"
+	default:
+		return "size (largest first)"
+	}
+}
+
+const (
+	headerLines = 2
+	footerLines = 3
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+	groupStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10"))
+	cursorStyle   = lipgloss.NewStyle().Reverse(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	detailStyle   = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderTop(true)
+)
+
+type item struct {
+	res      domain.Resource
+	selected bool
+}
+
+type model struct {
+	items   []item
+	visible []int
+	cursor  int
+	offset  int
+
+	sort      sortMode
+	filter    string
+	filtering bool
+
+	showDetail bool
+	details    map[string]string
+	// loading holds the resources whose details are being fetched.
+	loading map[string]bool
+	inspect Inspector
+
+	width, height int
+
+	confirmed bool
+}
+
+func newModel(resources *domain.UnusedResources, inspect Inspector) *model {
+	m := &model{
+		details: make(map[string]string),
+		loading: make(map[string]bool),
+		inspect: inspect,
+		height:  24,
+		width:   80,
+	}
+	for _, res := range resources.Items() {
+		m.items = append(m.items, item{res: res})
+	}
+	m.refresh()
+	return m
+}
+
+// Run shows the picker and returns the subset of resources the user selected.
+// It returns nil if the user quit without confirming.
+func Run(resources *domain.UnusedResources, inspect Inspector) (*domain.UnusedResources, error) {
+	m := newModel(resources, inspect)
+
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		return nil, fmt.Errorf("terminal UI failed: %w", err)
+	}
+
+	if !m.confirmed {
+		return nil, nil
+	}
+
+	return m.selection(resources), nil
+}
+
+func (m *model) selection(resources *domain.UnusedResources) *domain.UnusedResources {
+	selected := make(map[string]bool)
+	for _, it := range m.items {
+		if it.selected {
+			selected[it.res.Key()] = true
+		}
+	}
+
+	return resources.Select(func(res domain.Resource) bool {
+		return selected[res.Key()]
+	})
+}
+
+// refresh recomputes the visible rows after the filter or sort order changed.
+func (m *model) refresh() {
+	var current string
+	if m.cursor < len(m.visible) {
+		current = m.items[m.visible[m.cursor]].res.Key()
+	}
+
+	needle := strings.ToLower(m.filter)
+	m.visible = m.visible[:0]
+	for i, it := range m.items {
+		if needle == "" ||
+			strings.Contains(strings.ToLower(it.res.Name), needle) ||
+			strings.Contains(strings.ToLower(it.res.ID), needle) {
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	kindOrder := make(map[domain.ResourceKind]int, len(domain.Kinds))
+	for i, k := range domain.Kinds {
+		kindOrder[k] = i
+	}
+
+	sort.SliceStable(m.visible, func(a, b int) bool {
+		ra, rb := m.items[m.visible[a]].res, m.items[m.visible[b]].res
+		if ra.Kind != rb.Kind {
+			return kindOrder[ra.Kind] < kindOrder[rb.Kind]
+		}
+		switch m.sort {
+		case sortAge:
+			return ra.Created.Before(rb.Created)
+		case sortName:
+			return ra.Name < rb.Name
+		default:
+			return ra.Size > rb.Size
+		}
+	})
+
+	m.cursor = 0
+	for i, idx := range m.visible {
+		if m.items[idx].res.Key() == current {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+func (m *model) selectedStats() (count int, size int64) {
+	for _, it := range m.items {
+		if it.selected {
+			count++
+			size += it.res.Size
+		}
+	}
+	return count, size
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+// detailMsg carries the rendered details of a resource fetched by fetchDetail.
+type detailMsg struct {
+	key    string
+	detail string
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case detailMsg:
+		m.details[msg.key] = msg.detail
+		delete(m.loading, msg.key)
+	case tea.KeyMsg:
+		var cmd tea.Cmd
+		if m.filtering {
+			m.updateFilter(msg)
+		} else {
+			cmd = m.updateList(msg)
+		}
+		return m, tea.Batch(cmd, m.fetchDetail())
+	}
+	return m, nil
+}
+
+// fetchDetail returns a command inspecting the resource under the cursor when
+// the detail pane shows it and its details are neither cached nor on the way.
+func (m *model) fetchDetail() tea.Cmd {
+	if !m.showDetail || m.cursor >= len(m.visible) {
+		return nil
+	}
+
+	res := m.items[m.visible[m.cursor]].res
+	key := res.Key()
+	if _, ok := m.details[key]; ok || m.loading[key] {
+		return nil
+	}
+	m.loading[key] = true
+
+	inspect := m.inspect
+	return func() tea.Msg {
+		return detailMsg{key: key, detail: describe(inspect, res)}
+	}
+}
+
+func (m *model) updateFilter(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filtering = false
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+	case tea.KeyBackspace:
+		if m.filter != "" {
+			r := []rune(m.filter)
+			m.filter = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	default:
+		return
+	}
+	m.refresh()
+}
+
+func (m *model) updateList(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		return tea.Quit
+	case "enter":
+		if count, _ := m.selectedStats(); count > 0 {
+			m.confirmed = true
+			return tea.Quit
+		}
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.visible)-1 {
+			m.cursor++
+		}
+	case "pgup":
+		m.cursor = max(m.cursor-m.listHeight(), 0)
+	case "pgdown":
+		m.cursor = max(min(m.cursor+m.listHeight(), len(m.visible)-1), 0)
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = max(len(m.visible)-1, 0)
+	case " ", "x":
+		if m.cursor < len(m.visible) {
+			it := &m.items[m.visible[m.cursor]]
+			it.selected = !it.selected
+		}
+	case "a":
+		m.toggleAllVisible()
+	case "s":
+		m.sort = (m.sort + 1) % 3
+		m.refresh()
+	case "/":
+		m.filtering = true
+	case "i", "tab":
+		m.showDetail = !m.showDetail
+	}
+	return nil
+}
+
+// toggleAllVisible selects every visible item, or clears them if all are already selected.
+func (m *model) toggleAllVisible() {
+	all := true
+	for _, idx := range m.visible {
+		if !m.items[idx].selected {
+			all = false
+			break
+		}
+	}
+	for _, idx := range m.visible {
+		m.items[idx].selected = !all
+	}
+}
+
+func (m *model) detailHeight() int {
+	if !m.showDetail {
+		return 0
+	}
+	return m.height / 2
+}
+
+func (m *model) listHeight() int {
+	return max(m.height-headerLines-footerLines-m.detailHeight(), 1)
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("dockr — select resources to delete"))
+	fmt.Fprintf(&b, "   sort: %s", m.sort)
+	if m.filter != "" || m.filtering {
+		fmt.Fprintf(&b, "   filter: %s", m.filter)
+		if m.filtering {
+			b.WriteString("█")
+		}
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(m.viewList())
+
+	if m.showDetail {
+		b.WriteString(m.viewDetail())
+	}
+
+	count, size := m.selectedStats()
+	fmt.Fprintf(&b, "\nSelected: %d/%d   Reclaim: %.2f MB\n", count, len(m.items), float64(size)/1024/1024)
+	b.WriteString(dimStyle.Render("space toggle • a all • s sort • / filter • i inspect • enter delete selected • q quit"))
+
+	return b.String()
+}
+
+func (m *model) viewList() string {
+	type row struct {
+		text   string
+		cursor bool
+	}
+
+	var (
+		rows      []row
+		cursorRow int
+		lastKind  domain.ResourceKind
+	)
+	for i, idx := range m.visible {
+		it := m.items[idx]
+		if it.res.Kind != lastKind {
+			lastKind = it.res.Kind
+			rows = append(rows, row{text: groupStyle.Render(groupTitle(lastKind))})
+		}
+		if i == m.cursor {
+			cursorRow = len(rows)
+		}
+		rows = append(rows, row{text: m.renderItem(it), cursor: i == m.cursor})
+	}
+
+	height := m.listHeight()
+	if cursorRow < m.offset {
+		m.offset = cursorRow
+	}
+	if cursorRow >= m.offset+height {
+		m.offset = cursorRow - height + 1
+	}
+
+	var b strings.Builder
+	if len(rows) == 0 {
+		b.WriteString(dimStyle.Render("  no resources match the filter") + "\n")
+		height--
+	}
+	for i := m.offset; i < len(rows) && i < m.offset+height; i++ {
+		if rows[i].cursor {
+			b.WriteString(cursorStyle.Render(rows[i].text))
+		} else {
+			b.WriteString(rows[i].text)
+		}
+		b.WriteString("\n")
+		height--
+	}
+	for ; height > 0; height-- {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func groupTitle(kind domain.ResourceKind) string {
+	title := string(kind)
+	return strings.ToUpper(title[:1]) + title[1:] + "s"
+}
+
+func (m *model) renderItem(it item) string {
+	check := "[ ]"
+	if it.selected {
+		check = selectedStyle.Render("[x]")
+	}
+
+	id := it.res.ID
+	if it.res.Kind != domain.KindVolume {
+		id = formatter.TruncateID(strings.TrimPrefix(id, "sha256:"))
+	}
+
+	size := ""
+	if it.res.Kind != domain.KindNetwork {
+		size = fmt.Sprintf("%.2f MB", float64(it.res.Size)/1024/1024)
+	}
+
+	return fmt.Sprintf("  %s %-12s  %-40s %12s  %s",
+		check,
+		formatter.Truncate(id, 12),
+		formatter.Truncate(it.res.Name, 40),
+		size,
+		formatter.Age(it.res.Created),
+	)
+}
+
+func (m *model) viewDetail() string {
+	height := m.detailHeight()
+	if m.cursor >= len(m.visible) {
+		return detailStyle.Render(strings.Repeat("\n", max(height-2, 0))) + "\n"
+	}
+
+	detail, ok := m.details[m.items[m.visible[m.cursor]].res.Key()]
+	if !ok {
+		detail = "Loading..."
+	}
+
+	lines := strings.Split(detail, "\n")
+	if len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	return detailStyle.Width(m.width).Render(strings.Join(lines, "\n")) + "\n"
+}
+
+// describe renders the inspect object of the resource, falling back to the list summary.
+func describe(inspect Inspector, res domain.Resource) string {
+	obj := res.Object
+	if inspect != nil {
+		inspected, err := inspect(res)
+		if err != nil {
+			return err.Error()
+		}
+		obj = inspected
+	}
+
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
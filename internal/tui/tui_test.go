@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/domain"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func testResources() *domain.UnusedResources {
+	return &domain.UnusedResources{
+		Images: []*image.Summary{
+			{ID: "sha256:small", RepoTags: []string{"alpine:3"}, Size: 5 * 1024 * 1024, Created: 300},
+			{ID: "sha256:big", RepoTags: []string{"ubuntu:22.04"}, Size: 80 * 1024 * 1024, Created: 100},
+		},
+		Containers: []*container.Summary{
+			{ID: "c1", Names: []string{"/web"}, State: "exited", SizeRw: 1024, Created: 200},
+		},
+		Volumes: []*volume.Volume{
+			{Name: "pgdata"},
+		},
+	}
+}
+
+func press(m *model, keys ...string) {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		m.Update(msg)
+	}
+}
+
+func TestSortBySizeWithinGroups(t *testing.T) {
+	m := newModel(testResources(), nil)
+
+	first := m.items[m.visible[0]].res
+	if first.ID != "sha256:big" {
+		t.Fatalf("expected largest image first, got %s", first.ID)
+	}
+
+	press(m, "s")
+	first = m.items[m.visible[0]].res
+	if first.ID != "sha256:big" {
+		t.Fatalf("expected oldest image first, got %s", first.ID)
+	}
+
+	last := m.items[m.visible[len(m.visible)-1]].res
+	if last.Kind != domain.KindVolume {
+		t.Fatalf("expected volumes after images and containers, got %s", last.Kind)
+	}
+}
+
+func TestToggleAndSelection(t *testing.T) {
+	resources := testResources()
+	m := newModel(resources, nil)
+
+	// Enter does nothing while nothing is selected.
+	press(m, "enter")
+	if m.confirmed {
+		t.Fatal("expected enter to be ignored with an empty selection")
+	}
+
+	// Select the big image and the container.
+	press(m, " ", "j", "j", " ", "enter")
+	if !m.confirmed {
+		t.Fatal("expected selection to be confirmed")
+	}
+
+	count, size := m.selectedStats()
+	if count != 2 || size != 80*1024*1024+1024 {
+		t.Errorf("unexpected selection stats: %d resources, %d bytes", count, size)
+	}
+
+	selected := m.selection(resources)
+	if len(selected.Images) != 1 || selected.Images[0].ID != "sha256:big" {
+		t.Errorf("expected only the big image, got %+v", selected.Images)
+	}
+	if len(selected.Containers) != 1 || len(selected.Volumes) != 0 {
+		t.Errorf("unexpected selection: %d containers, %d volumes", len(selected.Containers), len(selected.Volumes))
+	}
+}
+
+func TestFilterAndSelectAll(t *testing.T) {
+	m := newModel(testResources(), nil)
+
+	press(m, "/", "a", "l", "p", "enter")
+	if len(m.visible) != 1 {
+		t.Fatalf("expected filter to match one resource, got %d", len(m.visible))
+	}
+
+	press(m, "a")
+	if count, _ := m.selectedStats(); count != 1 {
+		t.Errorf("expected only the filtered resource to be selected, got %d", count)
+	}
+
+	press(m, "i")
+	if m.View() == "" {
+		t.Error("expected a rendered view with the detail pane")
+	}
+
+	press(m, "/", "esc")
+	if len(m.visible) != 4 {
+		t.Errorf("expected esc to clear the filter, got %d visible", len(m.visible))
+	}
+}
+
+func TestDetailFetchedOutsideView(t *testing.T) {
+	calls := 0
+	m := newModel(testResources(), func(res domain.Resource) (any, error) {
+		calls++
+		return map[string]string{"Id": res.ID}, nil
+	})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	if !strings.Contains(m.View(), "Loading...") || calls != 0 {
+		t.Fatalf("expected a placeholder without inspecting, got %d calls", calls)
+	}
+	if cmd == nil {
+		t.Fatal("expected a command fetching the details")
+	}
+
+	m.Update(cmd())
+	if !strings.Contains(m.View(), `"Id": "sha256:big"`) || calls != 1 {
+		t.Errorf("expected the inspected details after one call, got %d calls:\n%s", calls, m.View())
+	}
+
+	// Cached details are not fetched again.
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	if cmd != nil {
+		t.Error("expected no fetch for cached details")
+	}
+}
@@ -0,0 +1,421 @@
+// Package dockr is the public Go API of dockr. It finds unused Docker resources
+// and removes them, the same way the dockr command does.
+//
+// A typical embedding analyzes the host, narrows the plan down and cleans:
+//
+//	client, err := dockr.New(ctx, dockr.Options{
+//		ExcludeTags: []string{"prod"},
+//		Rules:       []dockr.Rule{keepDatabases},
+//		OnEvent:     func(e dockr.Event) { log.Println(e.Type, e.Resource.Name) },
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	report, err := client.Analyze(ctx)
+//	if err != nil {
+//		return err
+//	}
+//
+//	result, err := client.Clean(ctx, dockr.NewPlan(report))
+//
+// The API follows semantic versioning together with the dockr module; types
+// re-exported from internal packages are part of that contract.
+package dockr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/archive"
+	"github.com/DobryySoul/dockr/internal/cleaner"
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/ipam"
+	"github.com/DobryySoul/dockr/internal/logs"
+	"github.com/DobryySoul/dockr/internal/planner"
+	"github.com/DobryySoul/dockr/internal/snapshot"
+)
+
+// Options configures a Client. The zero value connects to the auto-detected
+// engine and applies only the built-in analysis rules.
+type Options struct {
+	// Engine selects the container engine; EngineAuto discovers the socket.
+	Engine Engine
+	// ExcludeTags protects images whose tags contain any of these strings.
+	ExcludeTags []string
+	// Volumes limits which unused volumes are reported; the zero value
+	// allows anonymous volumes of any age and no named ones.
+	Volumes VolumePolicy
+	// Rules override the built-in verdict for individual resources.
+	// They are evaluated in order; the first one that does not defer wins.
+	// A delete verdict is ignored for resources in use.
+	Rules []Rule
+	// Analyzers are asked about the resources not in use after Rules; see
+	// LoadAnalyzers.
+	Analyzers *Analyzers
+	// Policy is evaluated after Rules and Analyzers; see CompilePolicy.
+	Policy *Policy
+	// OnEvent receives progress events from Analyze and Clean. It is called
+	// synchronously, so it should return quickly.
+	OnEvent func(Event)
+	// Observers are notified around every removal and may veto it by
+	// returning an error wrapping ErrSkipped from BeforeRemove.
+	Observers []Observer
+	// Snapshot, when set, is analyzed instead of a live engine. Everything
+	// that reads the host works; removals fail.
+	Snapshot *Snapshot
+}
+
+// Client analyzes and cleans one Docker host.
+type Client struct {
+	opts   Options
+	docker *docker.DockerClient
+}
+
+// New connects to the engine described by opts, or serves opts.Snapshot.
+func New(ctx context.Context, opts Options) (*Client, error) {
+	if opts.Snapshot != nil {
+		dc := &docker.DockerClient{Cli: snapshot.NewAPI(opts.Snapshot), Engine: opts.Snapshot.Engine}
+		return &Client{opts: opts, docker: dc}, nil
+	}
+
+	if opts.Engine == "" {
+		opts.Engine = EngineAuto
+	}
+
+	dc, err := docker.NewDockerClient(ctx, opts.Engine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+
+	return &Client{opts: opts, docker: dc}, nil
+}
+
+// Close releases the connection to the engine.
+func (c *Client) Close() error {
+	return c.docker.Cli.Close()
+}
+
+// Engine returns the engine the client is connected to.
+func (c *Client) Engine() Engine {
+	return c.docker.Engine
+}
+
+// Host returns the address of the engine API.
+func (c *Client) Host() string {
+	return c.docker.Cli.DaemonHost()
+}
+
+// Use registers additional observers for all subsequent Clean calls.
+func (c *Client) Use(observers ...Observer) {
+	c.opts.Observers = append(c.opts.Observers, observers...)
+}
+
+// Analyze finds the unused resources on the host.
+func (c *Client) Analyze(ctx context.Context) (*Report, error) {
+	c.emit(Event{Type: EventAnalyzeStarted})
+
+	rules, err := c.rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := c.docker.FindUnusedResourcer(ctx, c.opts.ExcludeTags, c.opts.Volumes, rules)
+	if err != nil {
+		return nil, fmt.Errorf("analysis error: %w", err)
+	}
+
+	report := &Report{
+		Resources:   resources,
+		Engine:      c.docker.Engine,
+		Host:        c.Host(),
+		GeneratedAt: time.Now(),
+	}
+
+	c.emit(Event{Type: EventAnalyzeFinished, Total: resources.TotalCount()})
+
+	return report, nil
+}
+
+// Session finds every resource carrying all the labels ("key=value"), in use
+// or not. Clean the report with a forced plan to tear the session down.
+func (c *Client) Session(ctx context.Context, labels []string) (*Report, error) {
+	resources, err := c.docker.FindLabeled(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Resources:   resources,
+		Engine:      c.docker.Engine,
+		Host:        c.Host(),
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// Labeled finds every resource carrying at least one of the label keys.
+func (c *Client) Labeled(ctx context.Context, keys []string) (*Report, error) {
+	resources, err := c.docker.FindLabeledAny(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Resources:   resources,
+		Engine:      c.docker.Engine,
+		Host:        c.Host(),
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// Groups groups the report by the policy's labels and decides which groups to
+// remove; see GroupPolicy. Groups.Resources turns the decision into a plan.
+func (c *Client) Groups(ctx context.Context, report *Report, policy GroupPolicy) (*Groups, error) {
+	imageUsers, err := c.docker.ImageUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return planner.PlanGroups(report.Resources, policy, imageUsers, time.Now()), nil
+}
+
+// AddressSpace reports how the networks' subnets use the address pools and
+// where they overlap each other or the host's routes. Networks in the
+// report's resources count as unused. With no pools, the engine's built-in
+// defaults are assumed. Host routes are only checked when the engine listens
+// on a local unix socket.
+func (c *Client) AddressSpace(ctx context.Context, report *Report, pools []AddressPool) (*AddressSpace, error) {
+	networks, err := c.docker.NetworkSubnets(ctx, report.Resources.Networks)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pools) == 0 {
+		pools = ipam.DefaultPools(c.Engine())
+	}
+
+	var routes []ipam.Route
+	if strings.HasPrefix(c.Host(), "unix://") {
+		if routes, err = ipam.HostRoutes(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ipam.Analyze(networks, routes, pools), nil
+}
+
+// Logs measures the log files of all containers, the largest first, and flags
+// those larger than threshold. root is prepended to the log paths the engine
+// reports, for when its data directory is mounted elsewhere; the files are
+// only reachable when the engine runs on this host.
+func (c *Client) Logs(ctx context.Context, root string, threshold int64) (*LogReport, error) {
+	containers, err := c.docker.ContainerLogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return logs.Analyze(containers, root, threshold), nil
+}
+
+// Duplicates finds tags pointing to the same image and images built from
+// identical content. Apply the outcome with a Plan whose Tags are its Untag
+// or Collapse changes.
+func (c *Client) Duplicates(ctx context.Context, policy DuplicatePolicy) (*Duplicates, error) {
+	images, err := c.docker.ListImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.docker.FillUsage(ctx, &UnusedResources{Images: images}); err != nil {
+		return nil, err
+	}
+
+	digests, err := c.docker.ContentDigests(ctx, images)
+	if err != nil {
+		return nil, err
+	}
+
+	imageUsers, err := c.docker.ImageUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return planner.PlanDuplicates(images, digests, imageUsers, policy), nil
+}
+
+// Archiver creates an archiver that pushes images through this client's
+// engine and verifies the pushed digests with checker. Pass the results of
+// its Archive to Clean to keep the images that were not archived.
+func (c *Client) Archiver(cfg ArchiveConfig, checker *RegistryChecker) (*Archiver, error) {
+	return archive.New(cfg, c.docker, checker)
+}
+
+// Snapshot captures the state of the host for offline analysis. Redact it
+// before sharing it.
+func (c *Client) Snapshot(ctx context.Context) (*Snapshot, error) {
+	return snapshot.Capture(ctx, c.docker.Cli, c.Engine())
+}
+
+// Explain finds the resources matching query by ID prefix, name or tag and
+// explains why Analyze does or does not report them.
+func (c *Client) Explain(ctx context.Context, query string) ([]Explanation, error) {
+	rules, err := c.rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.docker.Explain(ctx, query, c.opts.ExcludeTags, c.opts.Volumes, rules)
+}
+
+// HostReport describes the engine and its disk usage by resource type.
+func (c *Client) HostReport(ctx context.Context) (*HostReport, error) {
+	return c.docker.HostReport(ctx)
+}
+
+// Inspect returns the full inspect object of a resource.
+func (c *Client) Inspect(ctx context.Context, res Resource) (any, error) {
+	return c.docker.Inspect(ctx, res)
+}
+
+// Budget narrows resources, e.g. the Resources of a report after filtering,
+// down to the least disruptive ones that free at least target bytes. Only
+// resources in the set are picked. Their image and volume sizes are refreshed
+// from the daemon's disk usage on the way.
+func (c *Client) Budget(ctx context.Context, resources *UnusedResources, target int64) (*Budget, error) {
+	if err := c.docker.FillUsage(ctx, resources); err != nil {
+		return nil, err
+	}
+
+	lastUsed, err := c.docker.LastUsed(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return planner.PlanBudget(resources, target, lastUsed, time.Now()), nil
+}
+
+// Footprint returns the disk space used by images, containers and volumes.
+func (c *Client) Footprint(ctx context.Context) (int64, error) {
+	return c.docker.Footprint(ctx)
+}
+
+// Clean removes the resources in the plan, containers first (see
+// planner.RemovalOrder). It stops at the first removal that
+// fails; resources vetoed by an observer are skipped. The result lists what
+// happened to every resource that was attempted, also when an error is returned.
+// Extra observers apply to this call only, after the ones registered with Use.
+func (c *Client) Clean(ctx context.Context, plan *Plan, extra ...Observer) (*Result, error) {
+	result := &Result{}
+	progress := &progressObserver{client: c, result: result, total: plan.Resources.TotalCount() + len(plan.Tags)}
+
+	observers := append(cleaner.Observers{}, c.opts.Observers...)
+	observers = append(observers, extra...)
+	observers = append(observers, progress)
+
+	resources := plan.Resources
+
+	var err error
+	if len(plan.Tags) > 0 {
+		var incomplete map[string]bool
+		incomplete, err = cleaner.ChangeTags(ctx, c.docker, plan.Tags, observers)
+
+		// An image whose tags did not all move away may still be needed.
+		resources = resources.Select(func(res Resource) bool {
+			if res.Kind == KindImage && incomplete[res.ID] {
+				if err == nil {
+					observers.AfterRemove(ctx, res, fmt.Errorf("%w: not all of its tags were changed", ErrSkipped))
+				}
+				return false
+			}
+			return true
+		})
+	}
+
+	if err == nil {
+		err = cleaner.Clean(ctx, c.docker, resources, cleaner.Options{
+			Force:         plan.Force,
+			RemoveVolumes: plan.RemoveVolumes,
+		}, observers)
+	}
+
+	c.emit(Event{Type: EventCleanFinished, Done: progress.done, Total: progress.total, Err: err})
+
+	return result, err
+}
+
+// rules returns the rules of the options followed by those of the analyzer
+// plugins and the policy.
+func (c *Client) rules(ctx context.Context) ([]Rule, error) {
+	rules := slices.Clip(c.opts.Rules)
+
+	if !c.opts.Analyzers.Empty() {
+		// Resources in use are never removed, so the plugins are not asked.
+		candidates, err := c.docker.Candidates(ctx, c.opts.Volumes)
+		if err != nil {
+			return nil, err
+		}
+
+		analyzers, err := c.opts.Analyzers.Rules(ctx, candidates.Items())
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, analyzers...)
+	}
+
+	if !c.opts.Policy.Empty() {
+		facts, err := c.docker.PolicyFacts(ctx, c.opts.Policy.NeedsLastUsed())
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, c.opts.Policy.Rule(facts))
+	}
+
+	return rules, nil
+}
+
+func (c *Client) emit(e Event) {
+	if c.opts.OnEvent != nil {
+		c.opts.OnEvent(e)
+	}
+}
+
+// progressObserver turns removals into events and collects the result.
+type progressObserver struct {
+	client *Client
+	result *Result
+	done   int
+	total  int
+}
+
+func (p *progressObserver) BeforeRemove(_ context.Context, res domain.Resource) error {
+	p.client.emit(Event{Type: EventRemoveStarted, Resource: &res, Done: p.done, Total: p.total})
+	return nil
+}
+
+func (p *progressObserver) AfterRemove(_ context.Context, res domain.Resource, err error) {
+	p.done++
+
+	removal := Removal{Resource: res, Err: err}
+	event := Event{Resource: &res, Err: err, Done: p.done, Total: p.total}
+
+	switch {
+	case err == nil:
+		p.result.Removed = append(p.result.Removed, removal)
+		p.result.Reclaimed += res.Size
+		event.Type = EventRemoved
+	case errors.Is(err, domain.ErrSkipped):
+		p.result.Skipped = append(p.result.Skipped, removal)
+		event.Type = EventSkipped
+	default:
+		p.result.Failed = append(p.result.Failed, removal)
+		event.Type = EventFailed
+	}
+
+	p.client.emit(event)
+}
@@ -0,0 +1,253 @@
+package dockr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/DobryySoul/dockr/internal/policy"
+	"github.com/DobryySoul/dockr/internal/registry"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestProgressObserver(t *testing.T) {
+	var events []EventType
+
+	c := &Client{opts: Options{OnEvent: func(e Event) { events = append(events, e.Type) }}}
+	result := &Result{}
+	p := &progressObserver{client: c, result: result, total: 3}
+
+	ctx := context.Background()
+	removed := Resource{Kind: KindImage, ID: "sha256:a", Size: 100}
+	skipped := Resource{Kind: KindVolume, ID: "data", Size: 50}
+	failed := Resource{Kind: KindContainer, ID: "c1", Size: 10}
+
+	for _, step := range []struct {
+		res Resource
+		err error
+	}{
+		{removed, nil},
+		{skipped, fmt.Errorf("vetoed by hook: %w", ErrSkipped)},
+		{failed, errors.New("conflict")},
+	} {
+		if err := p.BeforeRemove(ctx, step.res); err != nil {
+			t.Fatalf("BeforeRemove returned %v", err)
+		}
+		p.AfterRemove(ctx, step.res, step.err)
+	}
+
+	want := []EventType{
+		EventRemoveStarted, EventRemoved,
+		EventRemoveStarted, EventSkipped,
+		EventRemoveStarted, EventFailed,
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	if len(result.Removed) != 1 || len(result.Skipped) != 1 || len(result.Failed) != 1 {
+		t.Errorf("result = %+v, want one of each", result)
+	}
+	if result.Reclaimed != 100 {
+		t.Errorf("Reclaimed = %d, want 100", result.Reclaimed)
+	}
+	if p.done != 3 {
+		t.Errorf("done = %d, want 3", p.done)
+	}
+}
+
+func TestSnapshotClient(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := New(ctx, Options{Snapshot: &Snapshot{
+		Engine: EngineDocker,
+		Containers: []SnapshotContainer{
+			{Summary: container.Summary{ID: "c1", Names: []string{"/web"}, ImageID: "sha256:used", State: "running"}},
+			{Summary: container.Summary{ID: "c2", Names: []string{"/old"}, ImageID: "sha256:used", State: "exited"}},
+		},
+		Images: []SnapshotImage{
+			{Summary: image.Summary{ID: "sha256:used", RepoTags: []string{"app:1"}}},
+			{Summary: image.Summary{ID: "sha256:gone", RepoTags: []string{"app:0"}}},
+		},
+		Networks: []network.Inspect{{ID: "n1", Name: "bridge"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := client.Analyze(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Resources.Images) != 1 || len(report.Resources.Containers) != 1 || len(report.Resources.Networks) != 0 {
+		t.Errorf("unused = %+v", report.Resources.Items())
+	}
+
+	explanations, err := client.Explain(ctx, "app:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(explanations) != 1 || explanations[0].Unused || explanations[0].Reasons[0] != "used by 2 container(s): web, old" {
+		t.Errorf("explanations = %+v", explanations)
+	}
+
+	if _, err := client.Clean(ctx, NewPlan(report)); err == nil {
+		t.Error("expected cleaning a snapshot to fail")
+	}
+}
+
+func TestAnalyzersClient(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell analyzers are not tested on windows")
+	}
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+cat >/dev/null
+echo '{"verdicts":[{"kind":"image","id":"sha256:gone","verdict":"keep","reason":"release image"}]}'
+`
+	if err := os.WriteFile(filepath.Join(dir, "catalog"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	analyzers, err := LoadAnalyzers(AnalyzersConfig{Dir: dir, CacheTTL: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []Rule{func(res Resource) Decision {
+		if res.ID == "sha256:gone" {
+			return Decision{Verdict: VerdictDelete, Reason: "expired"}
+		}
+		return Decision{}
+	}}
+	policy, err := CompilePolicy(PolicyConfig{Rules: []policy.RuleConfig{{Name: "dangling", Kind: "image", Expr: `"delete"`}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(ctx, Options{Analyzers: analyzers, Policy: policy, Snapshot: &Snapshot{
+		Engine: EngineDocker,
+		Images: []SnapshotImage{{Summary: image.Summary{ID: "sha256:gone", RepoTags: []string{"app:0"}}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the rule of the options the plugin's keep wins over the policy.
+	explanations, err := client.Explain(ctx, "app:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a rule says keep: analyzer catalog: release image",
+		"overridden: a rule says delete: policy dangling",
+		"not reported as unused: dockr keeps it",
+	}
+	if len(explanations) != 1 || !slices.Equal(explanations[0].Reasons, want) {
+		t.Errorf("explanations = %+v", explanations)
+	}
+
+	client.opts.Rules = rules
+	if explanations, err = client.Explain(ctx, "app:0"); err != nil {
+		t.Fatal(err)
+	}
+	if len(explanations) != 1 || !explanations[0].Unused || explanations[0].Reasons[0] != "a rule says delete: expired" {
+		t.Errorf("explanations = %+v", explanations)
+	}
+}
+
+func TestRuleCannotDeleteInUse(t *testing.T) {
+	ctx := context.Background()
+
+	deleteAll := func(Resource) Decision { return Decision{Verdict: VerdictDelete, Reason: "delete everything"} }
+	client, err := New(ctx, Options{Rules: []Rule{deleteAll}, Snapshot: &Snapshot{
+		Engine: EngineDocker,
+		Containers: []SnapshotContainer{
+			{Summary: container.Summary{ID: "c1", Names: []string{"/web"}, ImageID: "sha256:used", State: "running"}},
+			{Summary: container.Summary{ID: "c2", Names: []string{"/old"}, ImageID: "sha256:used", State: "exited"}},
+		},
+		Images: []SnapshotImage{
+			{Summary: image.Summary{ID: "sha256:used", RepoTags: []string{"app:1"}}},
+			{Summary: image.Summary{ID: "sha256:excluded", RepoTags: []string{"app:prod"}}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.opts.ExcludeTags = []string{"prod"}
+
+	report, err := client.Analyze(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, res := range report.Resources.Items() {
+		keys = append(keys, res.Key())
+	}
+	// The running container and the image of the containers stay; an excluded
+	// tag is only a preference the rule overrides.
+	if want := []string{"image/sha256:excluded", "container/c2"}; !slices.Equal(keys, want) {
+		t.Errorf("unused = %v, want %v", keys, want)
+	}
+
+	explanations, err := client.Explain(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(explanations) != 1 || explanations[0].Unused || !slices.Contains(explanations[0].Reasons, "in use: rules cannot delete it") {
+		t.Errorf("explanations = %+v", explanations)
+	}
+}
+
+func TestBudgetKeepsProtectedImages(t *testing.T) {
+	ctx := context.Background()
+	const gb = 1024 * 1024 * 1024
+
+	client, err := New(ctx, Options{Snapshot: &Snapshot{
+		Engine: EngineDocker,
+		Images: []SnapshotImage{
+			{Summary: image.Summary{ID: "sha256:lost", RepoTags: []string{"app:0"}, RepoDigests: []string{"app@sha256:0"}, Size: 10 * gb}},
+			{Summary: image.Summary{ID: "sha256:pullable", RepoTags: []string{"app:1"}, RepoDigests: []string{"app@sha256:1"}, Size: gb}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := client.Analyze(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// --check-registry=protect: the manifest of app:0 is gone from its registry.
+	protected := registry.Protect(report.Resources, []registry.ImageCheck{
+		{ImageID: "sha256:lost", Status: registry.StatusMissing},
+		{ImageID: "sha256:pullable", Status: registry.StatusAvailable},
+	})
+
+	budget, err := client.Budget(ctx, protected, 5*gb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, res := range budget.Resources(protected).Items() {
+		keys = append(keys, res.Key())
+	}
+	if want := []string{"image/sha256:pullable"}; !slices.Equal(keys, want) {
+		t.Errorf("selected = %v, want %v", keys, want)
+	}
+	if budget.Covered() {
+		t.Error("expected the target to stay uncovered without the protected image")
+	}
+}
@@ -0,0 +1,54 @@
+package dockr_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DobryySoul/dockr/pkg/dockr"
+)
+
+// keepDatabases protects volumes that look like database storage.
+func keepDatabases(res dockr.Resource) dockr.Decision {
+	if res.Kind == dockr.KindVolume && strings.Contains(res.Name, "db") {
+		return dockr.Decision{Verdict: dockr.VerdictKeep, Reason: "database volume"}
+	}
+	return dockr.Decision{}
+}
+
+func Example() {
+	ctx := context.Background()
+
+	client, err := dockr.New(ctx, dockr.Options{
+		ExcludeTags: []string{"prod"},
+		Rules:       []dockr.Rule{keepDatabases},
+		OnEvent: func(e dockr.Event) {
+			if e.Type == dockr.EventRemoved {
+				fmt.Printf("removed %s %s (%d/%d)\n", e.Resource.Kind, e.Resource.Name, e.Done, e.Total)
+			}
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	report, err := client.Analyze(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Only remove what has been around for more than a day.
+	plan := dockr.NewPlan(report).Filter(func(res dockr.Resource) bool {
+		return time.Since(res.Created) > 24*time.Hour
+	})
+
+	result, err := client.Clean(ctx, plan)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("reclaimed %d bytes\n", result.Reclaimed)
+}
@@ -0,0 +1,264 @@
+package dockr
+
+import (
+	"time"
+
+	"github.com/DobryySoul/dockr/internal/archive"
+	"github.com/DobryySoul/dockr/internal/cleaner"
+	"github.com/DobryySoul/dockr/internal/docker"
+	"github.com/DobryySoul/dockr/internal/domain"
+	"github.com/DobryySoul/dockr/internal/extern"
+	"github.com/DobryySoul/dockr/internal/ipam"
+	"github.com/DobryySoul/dockr/internal/logs"
+	"github.com/DobryySoul/dockr/internal/planner"
+	"github.com/DobryySoul/dockr/internal/policy"
+	"github.com/DobryySoul/dockr/internal/registry"
+	"github.com/DobryySoul/dockr/internal/snapshot"
+)
+
+// Engine identifies the container engine behind the Docker-compatible API.
+type Engine = domain.Engine
+
+const (
+	EngineAuto   = domain.EngineAuto
+	EngineDocker = domain.EngineDocker
+	EnginePodman = domain.EnginePodman
+)
+
+// ResourceKind is the type of a Docker resource.
+type ResourceKind = domain.ResourceKind
+
+const (
+	KindImage     = domain.KindImage
+	KindContainer = domain.KindContainer
+	KindVolume    = domain.KindVolume
+	KindNetwork   = domain.KindNetwork
+	KindTag       = domain.KindTag
+)
+
+// Resource is a flat view of a single image, container, volume or network.
+type Resource = domain.Resource
+
+// UnusedResources groups resources by kind with the original Docker API objects.
+type UnusedResources = domain.UnusedResources
+
+// Verdict is the outcome of a Rule.
+type Verdict = domain.Verdict
+
+const (
+	VerdictDefault = domain.VerdictDefault
+	VerdictKeep    = domain.VerdictKeep
+	VerdictDelete  = domain.VerdictDelete
+)
+
+// Decision is a rule's verdict with a reason.
+type Decision = domain.Decision
+
+// Rule decides about a single resource; returning VerdictDefault defers to
+// the built-in analysis. Rules see every resource on the host.
+type Rule = domain.Rule
+
+// PolicyConfig is a set of rule expressions, as in the policy section of the
+// configuration file.
+type PolicyConfig = policy.Config
+
+// Policy is a compiled PolicyConfig.
+type Policy = policy.Policy
+
+// CompilePolicy type-checks the rule expressions of cfg.
+func CompilePolicy(cfg PolicyConfig) (*Policy, error) {
+	return policy.Compile(cfg)
+}
+
+// AnalyzersConfig locates the analyzer plugins, as in the analyzers section
+// of the configuration file.
+type AnalyzersConfig = extern.Config
+
+// Analyzers runs the analyzer plugins: executables that receive the
+// resources of the host as JSON and answer with keep or delete verdicts.
+type Analyzers = extern.Runner
+
+// LoadAnalyzers discovers the analyzer plugins of cfg.
+func LoadAnalyzers(cfg AnalyzersConfig) (*Analyzers, error) {
+	return extern.New(cfg)
+}
+
+// Observer is notified around every single removal.
+type Observer = cleaner.Observer
+
+// DuplicatePolicy decides which tags and identical images are redundant.
+type DuplicatePolicy = planner.DuplicatePolicy
+
+// TagPolicy picks the canonical tag of an image.
+type TagPolicy = planner.TagPolicy
+
+const (
+	TagPolicyLatest   = planner.TagPolicyLatest
+	TagPolicyVersion  = planner.TagPolicyVersion
+	TagPolicyShortest = planner.TagPolicyShortest
+)
+
+// Duplicates is the redundant tags and identical-content images of a host.
+type Duplicates = planner.Duplicates
+
+// TagChange untags an image or moves a tag to another image.
+type TagChange = planner.TagChange
+
+// RegistryChecker asks registries whether images can be pulled again.
+type RegistryChecker = registry.Checker
+
+// ArchiveConfig selects the images to push to an archive repository before removal.
+type ArchiveConfig = archive.Config
+
+// Archiver pushes images to an archive repository.
+type Archiver = archive.Archiver
+
+// ArchiveResults is the outcome of Archiver.Archive; as an Observer it keeps
+// the images that were not archived.
+type ArchiveResults = archive.Results
+
+// Snapshot is the state of a host captured for offline analysis.
+type Snapshot = snapshot.Snapshot
+
+// SnapshotContainer is a container in a snapshot, as listed and inspected.
+type SnapshotContainer = snapshot.Container
+
+// SnapshotImage is an image in a snapshot, as listed and inspected.
+type SnapshotImage = snapshot.Image
+
+// Redaction selects what Snapshot.Redact removes.
+type Redaction = snapshot.Redaction
+
+// LoadSnapshot reads a snapshot file written by Snapshot.Write.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	return snapshot.Load(path)
+}
+
+// SnapshotDiff is what changed on a host between two snapshots.
+type SnapshotDiff = snapshot.Diff
+
+// SnapshotChange is one resource that differs between two snapshots.
+type SnapshotChange = snapshot.Change
+
+// CompareSnapshots lists the resources added, removed or changed from before
+// to after. Mark the new ones that are already unused with
+// SnapshotDiff.MarkUnused and the analysis of after.
+func CompareSnapshots(before, after *Snapshot) *SnapshotDiff {
+	return snapshot.Compare(before, after)
+}
+
+// Explanation is why Analyze does or does not report a resource.
+type Explanation = docker.Explanation
+
+// HostReport is an overview of the engine and its disk usage.
+type HostReport = docker.HostReport
+
+// TypeUsage is the disk usage of one resource type.
+type TypeUsage = docker.TypeUsage
+
+// VolumePolicy limits which unused volumes Analyze reports by class and age.
+type VolumePolicy = domain.VolumePolicy
+
+// VolumeScope selects the classes of unused volumes to remove.
+type VolumeScope = domain.VolumeScope
+
+const (
+	VolumeScopeAnonymous = domain.VolumeScopeAnonymous
+	VolumeScopeNamed     = domain.VolumeScopeNamed
+)
+
+// Budget is a selection of resources that frees a target amount of space.
+type Budget = planner.Budget
+
+// GroupPolicy selects CI resources by job, pipeline and branch labels.
+type GroupPolicy = planner.GroupPolicy
+
+// Groups is the per-group decision of a GroupPolicy.
+type Groups = planner.Groups
+
+// Group is the set of resources sharing the values of the grouping labels.
+type Group = planner.Group
+
+// AddressPool is a default address pool network subnets are allocated from.
+type AddressPool = ipam.Pool
+
+// AddressSpace is the address pool usage and subnet overlaps of the networks.
+type AddressSpace = ipam.Report
+
+// LogReport is the disk usage and rotation settings of container logs.
+type LogReport = logs.Report
+
+// LogEntry is the log usage of one container.
+type LogEntry = logs.Entry
+
+// ErrSkipped marks a removal that was vetoed rather than failed.
+var ErrSkipped = domain.ErrSkipped
+
+// Report is the result of Analyze.
+type Report struct {
+	Resources   *UnusedResources
+	Engine      Engine
+	Host        string
+	GeneratedAt time.Time
+}
+
+// Plan is the set of resources Clean removes.
+type Plan struct {
+	Resources *UnusedResources
+	// Force removes resources even while they are in use: running containers
+	// are killed and images are removed with all their tags.
+	Force bool
+	// RemoveVolumes removes the anonymous volumes of the removed containers
+	// with them. Named volumes are never removed this way.
+	RemoveVolumes bool
+	// Tags are untagged or moved before anything is removed; they never
+	// delete an image.
+	Tags []TagChange
+}
+
+// NewPlan plans the removal of everything in the report.
+func NewPlan(report *Report) *Plan {
+	return &Plan{Resources: report.Resources}
+}
+
+// Filter returns a plan with only the resources for which keep returns true.
+func (p *Plan) Filter(keep func(Resource) bool) *Plan {
+	return &Plan{Resources: p.Resources.Select(keep), Force: p.Force, RemoveVolumes: p.RemoveVolumes, Tags: p.Tags}
+}
+
+// Removal is the outcome of removing a single resource.
+type Removal struct {
+	Resource Resource
+	Err      error
+}
+
+// Result summarizes a Clean call.
+type Result struct {
+	Removed   []Removal
+	Skipped   []Removal
+	Failed    []Removal
+	Reclaimed int64
+}
+
+// EventType is the kind of a progress event.
+type EventType string
+
+const (
+	EventAnalyzeStarted  EventType = "analyze_started"
+	EventAnalyzeFinished EventType = "analyze_finished"
+	EventRemoveStarted   EventType = "remove_started"
+	EventRemoved         EventType = "removed"
+	EventSkipped         EventType = "skipped"
+	EventFailed          EventType = "failed"
+	EventCleanFinished   EventType = "clean_finished"
+)
+
+// Event reports progress. Resource is set for removal events; Done and Total
+// count the resources of the current Clean call.
+type Event struct {
+	Type     EventType
+	Resource *Resource
+	Err      error
+	Done     int
+	Total    int
+}